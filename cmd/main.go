@@ -23,6 +23,7 @@ import (
 
 	"k8s.io/klog"
 
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver"
 )
 
@@ -32,6 +33,7 @@ const etcAmazonEfs = "/etc/amazon/efs"
 func main() {
 	var (
 		endpoint                 = flag.String("endpoint", "unix://tmp/csi.sock", "CSI Endpoint")
+		mode                     = flag.String("mode", string(driver.AllMode), "The service the driver is deployed for: controller, node or all. Background tasks of a service only run in the modes it is deployed for")
 		version                  = flag.Bool("version", false, "Print the version and exit")
 		efsUtilsCfgDirPath       = flag.String("efs-utils-config-dir-path", "/var/amazon/efs", "The preferred path for the efs-utils config directory. efs-utils-config-legacy-dir-path will be used if it is not empty, otherwise efs-utils-config-dir-path will be used.")
 		efsUtilsCfgLegacyDirPath = flag.String("efs-utils-config-legacy-dir-path", "/etc/amazon/efs-legacy", "The path to the legacy efs-utils config directory mounted from the host path /etc/amazon/efs")
@@ -39,11 +41,25 @@ func main() {
 		volMetricsOptIn          = flag.Bool("vol-metrics-opt-in", false, "Opt in to emit volume metrics")
 		volMetricsRefreshPeriod  = flag.Float64("vol-metrics-refresh-period", 240, "Refresh period for volume metrics in minutes")
 		volMetricsFsRateLimit    = flag.Int("vol-metrics-fs-rate-limit", 5, "Volume metrics routines rate limiter per file system")
+		nodeStageOptIn           = flag.Bool("node-stage-opt-in", false, "Opt in to mount volumes once per node at their staging path and bind mount them into pods")
+		sharedMountsOptIn        = flag.Bool("shared-mounts-opt-in", false, "Opt in to mount each file system once per node and bind mount the subpath of its volumes into pods")
 		deleteAccessPointRootDir = flag.Bool("delete-access-point-root-dir", false,
 			"Opt in to delete access point root directory by DeleteVolume. By default, DeleteVolume will delete the access point behind Persistent Volume and deleting access point will not delete the access point root directory or its contents.")
 		deleteProvisionedDir = flag.Bool("delete-provisioned-dir", false,
 			"Opt in to delete any provisioned directories and their contents. By default, DeleteVolume will not delete the directory behind Persistent Volume")
-		tags = flag.String("tags", "", "Space separated key:value pairs which will be added as tags for EFS resources. For example, 'environment:prod region:us-east-1'")
+		tags             = flag.String("tags", "", "Space separated key:value pairs which will be added as tags for EFS resources. For example, 'environment:prod region:us-east-1'")
+		metricsAddress   = flag.String("metrics-address", "", "The address to serve Prometheus metrics on at /metrics, e.g. ':8080'. Metrics are not served when empty")
+		backupVaultName  = flag.String("backup-vault-name", "Default", "AWS Backup vault in which volume snapshots are stored")
+		awsMaxRetries    = flag.Int("aws-api-max-retries", cloud.DefaultRetryOptions.MaxRetries, "Number of times throttled or transiently failed AWS API calls are retried")
+		awsRetryDelay    = flag.Duration("aws-api-retry-delay", cloud.DefaultRetryOptions.MinDelay, "Delay before the first retry of an AWS API call that failed for a transient reason, doubling with each retry")
+		awsThrottleDelay = flag.Duration("aws-api-throttle-delay", cloud.DefaultRetryOptions.MinThrottleDelay, "Delay before the first retry of a throttled AWS API call, doubling with each retry")
+		awsMaxRetryDelay = flag.Duration("aws-api-max-retry-delay", cloud.DefaultRetryOptions.MaxDelay, "Maximum delay between two attempts of an AWS API call")
+		awsRateLimit     = flag.Float64("aws-api-rate-limit", cloud.DefaultRetryOptions.RateLimit, "Number of AWS API calls per second made to a region, including retries. Calls are not rate limited when 0")
+		awsBurst         = flag.Int("aws-api-burst", cloud.DefaultRetryOptions.Burst, "Number of AWS API calls that can be made to a region at once before aws-api-rate-limit applies. Defaults to aws-api-rate-limit rounded up when 0")
+		awsUseFIPS       = flag.Bool("aws-use-fips-endpoint", false, "Call the FIPS endpoints of the AWS APIs")
+		awsUseDualStack  = flag.Bool("aws-use-dualstack-endpoint", false, "Call the dual-stack endpoints of the AWS APIs, which serve both IPv4 and IPv6")
+		awsEfsEndpoint   = flag.String("aws-efs-endpoint", "", "URL of the EFS API overriding the endpoint of the region, e.g. of a local EFS emulator")
+		awsCacheTTL      = flag.Duration("aws-api-cache-ttl", cloud.DefaultCacheTTL, "How long descriptions of available file systems and mount targets are cached. Nothing is cached when 0")
 	)
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -62,7 +78,36 @@ func main() {
 	if err != nil {
 		klog.Fatalln(err)
 	}
-	drv := driver.NewDriver(*endpoint, etcAmazonEfs, *efsUtilsStaticFilesPath, *tags, *volMetricsOptIn, *volMetricsRefreshPeriod, *volMetricsFsRateLimit, *deleteAccessPointRootDir, *deleteProvisionedDir)
+	err = cloud.SetRetryOptions(cloud.RetryOptions{
+		MaxRetries:       *awsMaxRetries,
+		MinDelay:         *awsRetryDelay,
+		MinThrottleDelay: *awsThrottleDelay,
+		MaxDelay:         *awsMaxRetryDelay,
+		RateLimit:        *awsRateLimit,
+		Burst:            *awsBurst,
+	})
+	if err != nil {
+		klog.Fatalln(err)
+	}
+	if err := cloud.SetCacheTTL(*awsCacheTTL); err != nil {
+		klog.Fatalln(err)
+	}
+	err = cloud.SetEndpointOptions(cloud.EndpointOptions{
+		UseFIPS:      *awsUseFIPS,
+		UseDualStack: *awsUseDualStack,
+		EfsEndpoint:  *awsEfsEndpoint,
+	})
+	if err != nil {
+		klog.Fatalln(err)
+	}
+	if *metricsAddress != "" {
+		driver.ServeMetrics(*metricsAddress)
+	}
+	driverMode, err := driver.ParseMode(*mode)
+	if err != nil {
+		klog.Fatalln(err)
+	}
+	drv := driver.NewDriver(*endpoint, etcAmazonEfs, *efsUtilsStaticFilesPath, *tags, *volMetricsOptIn, *volMetricsRefreshPeriod, *volMetricsFsRateLimit, *nodeStageOptIn, *sharedMountsOptIn, *deleteAccessPointRootDir, *deleteProvisionedDir, *backupVaultName, driverMode)
 	if err := drv.Run(); err != nil {
 		klog.Fatalln(err)
 	}
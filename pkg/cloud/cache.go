@@ -0,0 +1,251 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+// DefaultCacheTTL is how long descriptions of file systems and mount targets are cached.
+const DefaultCacheTTL = time.Minute
+
+var (
+	cacheMu  sync.Mutex
+	cacheTTL = DefaultCacheTTL
+)
+
+// SetCacheTTL sets how long the clouds created afterwards cache descriptions. Nothing is cached when ttl is 0.
+func SetCacheTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("cache TTL must not be negative")
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cacheTTL = ttl
+	return nil
+}
+
+func getCacheTTL() time.Duration {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return cacheTTL
+}
+
+// cachedCloud caches the descriptions of available file systems and mount targets, which provisioning reads for
+// every volume but which seldom change. Descriptions are invalidated when the resources they describe are changed
+// through the cloud, or reported not found by any call.
+type cachedCloud struct {
+	Cloud
+	cache *ttlCache
+}
+
+// newCachedCloud returns c caching its descriptions for ttl, or c itself if ttl is 0.
+func newCachedCloud(c Cloud, ttl time.Duration) Cloud {
+	if ttl == 0 {
+		return c
+	}
+	return &cachedCloud{Cloud: c, cache: newTTLCache(ttl)}
+}
+
+const (
+	fileSystemKeyPrefix  = "fs/"
+	mountTargetKeyPrefix = "mt/"
+)
+
+func fileSystemKey(fileSystemId string) string {
+	return fileSystemKeyPrefix + fileSystemId
+}
+
+// mountTargetsKey is the prefix of the keys of the mount targets of the file system, which are keyed by AZ.
+func mountTargetsKey(fileSystemId string) string {
+	return mountTargetKeyPrefix + fileSystemId + "/"
+}
+
+// invalidateFileSystem drops the descriptions of the file system and its mount targets.
+func (c *cachedCloud) invalidateFileSystem(fileSystemId string) {
+	c.cache.delete(fileSystemKey(fileSystemId))
+	c.cache.deletePrefix(mountTargetsKey(fileSystemId))
+}
+
+// checkNotFound invalidates the file system if err reports that it, or a resource of it, was not found.
+func (c *cachedCloud) checkNotFound(fileSystemId string, err error) {
+	if errors.Is(err, ErrNotFound) {
+		c.invalidateFileSystem(fileSystemId)
+	}
+}
+
+func (c *cachedCloud) DescribeFileSystem(ctx context.Context, fileSystemId string) (*FileSystem, error) {
+	value, err := c.cache.get(ctx, fileSystemKey(fileSystemId), func() (interface{}, bool, error) {
+		fs, err := c.Cloud.DescribeFileSystem(ctx, fileSystemId)
+		if err != nil {
+			return nil, false, err
+		}
+		// File systems are polled until they become available, so only cache them once they are
+		return fs, fs.LifeCycleState == efs.LifeCycleStateAvailable, nil
+	})
+	if err != nil {
+		c.checkNotFound(fileSystemId, err)
+		return nil, err
+	}
+	fs := *value.(*FileSystem)
+	return &fs, nil
+}
+
+func (c *cachedCloud) DescribeMountTargets(ctx context.Context, fileSystemId, azName string) (*MountTarget, error) {
+	value, err := c.cache.get(ctx, mountTargetsKey(fileSystemId)+azName, func() (interface{}, bool, error) {
+		mountTarget, err := c.Cloud.DescribeMountTargets(ctx, fileSystemId, azName)
+		return mountTarget, err == nil, err
+	})
+	if err != nil {
+		c.checkNotFound(fileSystemId, err)
+		return nil, err
+	}
+	mountTarget := *value.(*MountTarget)
+	return &mountTarget, nil
+}
+
+func (c *cachedCloud) DeleteFileSystem(ctx context.Context, fileSystemId string) error {
+	defer c.invalidateFileSystem(fileSystemId)
+	return c.Cloud.DeleteFileSystem(ctx, fileSystemId)
+}
+
+func (c *cachedCloud) CreateMountTarget(ctx context.Context, fileSystemId, subnetId string, securityGroups []string) (*MountTarget, error) {
+	defer c.cache.deletePrefix(mountTargetsKey(fileSystemId))
+	return c.Cloud.CreateMountTarget(ctx, fileSystemId, subnetId, securityGroups)
+}
+
+func (c *cachedCloud) DeleteMountTarget(ctx context.Context, mountTargetId string) error {
+	// The file system of the mount target is unknown, so drop the mount targets of all file systems
+	defer c.cache.deletePrefix(mountTargetKeyPrefix)
+	return c.Cloud.DeleteMountTarget(ctx, mountTargetId)
+}
+
+func (c *cachedCloud) ListMountTargets(ctx context.Context, fileSystemId string) ([]*MountTarget, error) {
+	mountTargets, err := c.Cloud.ListMountTargets(ctx, fileSystemId)
+	c.checkNotFound(fileSystemId, err)
+	return mountTargets, err
+}
+
+func (c *cachedCloud) CreateAccessPoint(ctx context.Context, volumeName string, accessPointOpts *AccessPointOptions) (*AccessPoint, error) {
+	accessPoint, err := c.Cloud.CreateAccessPoint(ctx, volumeName, accessPointOpts)
+	c.checkNotFound(accessPointOpts.FileSystemId, err)
+	return accessPoint, err
+}
+
+func (c *cachedCloud) ListAccessPoints(ctx context.Context, fileSystemId string) ([]*AccessPoint, error) {
+	accessPoints, err := c.Cloud.ListAccessPoints(ctx, fileSystemId)
+	c.checkNotFound(fileSystemId, err)
+	return accessPoints, err
+}
+
+func (c *cachedCloud) DescribeAccessPoints(ctx context.Context, fileSystemId, nextToken string, maxResults int64) ([]*AccessPoint, string, error) {
+	accessPoints, next, err := c.Cloud.DescribeAccessPoints(ctx, fileSystemId, nextToken, maxResults)
+	c.checkNotFound(fileSystemId, err)
+	return accessPoints, next, err
+}
+
+func (c *cachedCloud) PutLifecycleConfiguration(ctx context.Context, fileSystemId, transitionToIA string) error {
+	err := c.Cloud.PutLifecycleConfiguration(ctx, fileSystemId, transitionToIA)
+	c.checkNotFound(fileSystemId, err)
+	return err
+}
+
+// ttlCache is a cache of values that expire after ttl. Concurrent gets of a missing value share a single fetch, so that
+// a burst of calls needing the same value makes one call to AWS.
+type ttlCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	// done is closed once the value is fetched
+	done    chan struct{}
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		ttl:     ttl,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// get returns the value of key, calling fetch if it is not cached. fetch reports whether the value it returns can be
+// cached; errors never are. A get waiting for the fetch of another get gives up when ctx is done, and fetches the
+// value itself when the other fetch failed because its context was done.
+func (c *ttlCache) get(ctx context.Context, key string, fetch func() (interface{}, bool, error)) (interface{}, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok {
+			select {
+			case <-entry.done:
+				if time.Now().Before(entry.expires) {
+					c.mu.Unlock()
+					return entry.value, nil
+				}
+				ok = false
+			default:
+			}
+		}
+		if !ok {
+			entry = &cacheEntry{done: make(chan struct{})}
+			c.entries[key] = entry
+			c.mu.Unlock()
+			return c.fetch(key, entry, fetch)
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !isCanceled(entry.err) {
+			return entry.value, entry.err
+		}
+	}
+}
+
+func (c *ttlCache) fetch(key string, entry *cacheEntry, fetch func() (interface{}, bool, error)) (interface{}, error) {
+	value, cacheable, err := fetch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.value, entry.err = value, err
+	entry.expires = time.Now().Add(c.ttl)
+	close(entry.done)
+	if (err != nil || !cacheable) && c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	return value, err
+}
+
+func (c *ttlCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *ttlCache) deletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// isCanceled reports whether err is the failure of a call because its context was done.
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || hasErrorCode(err, request.CanceledErrorCode)
+}
@@ -0,0 +1,218 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingCloud counts the calls describing file systems and mount targets, which return its file systems and mount
+// targets or ErrNotFound.
+type countingCloud struct {
+	Cloud
+	mu                   sync.Mutex
+	fileSystems          map[string]*FileSystem
+	mountTargets         map[string]*MountTarget
+	describeFileSystems  int
+	describeMountTargets int
+	// delay delays the calls, so that concurrent calls overlap
+	delay time.Duration
+}
+
+func (c *countingCloud) DescribeFileSystem(ctx context.Context, fileSystemId string) (*FileSystem, error) {
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.describeFileSystems++
+	if fs, ok := c.fileSystems[fileSystemId]; ok {
+		return fs, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (c *countingCloud) DescribeMountTargets(ctx context.Context, fileSystemId, azName string) (*MountTarget, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.describeMountTargets++
+	if mountTarget, ok := c.mountTargets[fileSystemId]; ok {
+		return mountTarget, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (c *countingCloud) CreateMountTarget(ctx context.Context, fileSystemId, subnetId string, securityGroups []string) (*MountTarget, error) {
+	return &MountTarget{}, nil
+}
+
+func (c *countingCloud) CreateAccessPoint(ctx context.Context, volumeName string, accessPointOpts *AccessPointOptions) (*AccessPoint, error) {
+	return nil, ErrNotFound
+}
+
+func TestCachedCloud(t *testing.T) {
+	var (
+		fsId = "fs-abcd1234"
+		ctx  = context.Background()
+	)
+	newClouds := func(lifeCycleState string, ttl time.Duration) (*countingCloud, Cloud) {
+		counting := &countingCloud{
+			fileSystems:  map[string]*FileSystem{fsId: {FileSystemId: fsId, LifeCycleState: lifeCycleState}},
+			mountTargets: map[string]*MountTarget{fsId: {MountTargetId: "fsmt-abcd1234", LifeCycleState: "available"}},
+		}
+		return counting, newCachedCloud(counting, ttl)
+	}
+
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Available file system is described once",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", time.Minute)
+				for i := 0; i < 3; i++ {
+					fs, err := c.DescribeFileSystem(ctx, fsId)
+					if err != nil || fs.FileSystemId != fsId {
+						t.Fatalf("DescribeFileSystem failed: %v", err)
+					}
+				}
+				if counting.describeFileSystems != 1 {
+					t.Fatalf("Expected 1 describe call, got %d", counting.describeFileSystems)
+				}
+			},
+		},
+		{
+			name: "Success: File system that is not available is described every time",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("creating", time.Minute)
+				for i := 0; i < 3; i++ {
+					if _, err := c.DescribeFileSystem(ctx, fsId); err != nil {
+						t.Fatalf("DescribeFileSystem failed: %v", err)
+					}
+				}
+				if counting.describeFileSystems != 3 {
+					t.Fatalf("Expected 3 describe calls, got %d", counting.describeFileSystems)
+				}
+			},
+		},
+		{
+			name: "Success: File system is described again once expired",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", 10*time.Millisecond)
+				c.DescribeFileSystem(ctx, fsId)
+				time.Sleep(20 * time.Millisecond)
+				c.DescribeFileSystem(ctx, fsId)
+				if counting.describeFileSystems != 2 {
+					t.Fatalf("Expected 2 describe calls, got %d", counting.describeFileSystems)
+				}
+			},
+		},
+		{
+			name: "Success: Concurrent calls share one describe call",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", time.Minute)
+				counting.delay = 50 * time.Millisecond
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if _, err := c.DescribeFileSystem(ctx, fsId); err != nil {
+							t.Errorf("DescribeFileSystem failed: %v", err)
+						}
+					}()
+				}
+				wg.Wait()
+				if counting.describeFileSystems != 1 {
+					t.Fatalf("Expected 1 describe call, got %d", counting.describeFileSystems)
+				}
+			},
+		},
+		{
+			name: "Success: Mount targets are cached by AZ and invalidated on creation",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", time.Minute)
+				c.DescribeMountTargets(ctx, fsId, "us-east-1a")
+				c.DescribeMountTargets(ctx, fsId, "us-east-1a")
+				c.DescribeMountTargets(ctx, fsId, "us-east-1b")
+				if counting.describeMountTargets != 2 {
+					t.Fatalf("Expected 2 describe calls, got %d", counting.describeMountTargets)
+				}
+				c.CreateMountTarget(ctx, fsId, "subnet-abcd1234", nil)
+				c.DescribeMountTargets(ctx, fsId, "us-east-1a")
+				if counting.describeMountTargets != 3 {
+					t.Fatalf("Expected 3 describe calls, got %d", counting.describeMountTargets)
+				}
+			},
+		},
+		{
+			name: "Success: Not found error invalidates the file system",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", time.Minute)
+				c.DescribeFileSystem(ctx, fsId)
+				c.DescribeMountTargets(ctx, fsId, "")
+
+				_, err := c.CreateAccessPoint(ctx, "volume", &AccessPointOptions{FileSystemId: fsId})
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Expected ErrNotFound, got %v", err)
+				}
+				delete(counting.fileSystems, fsId)
+				if _, err := c.DescribeFileSystem(ctx, fsId); !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Expected ErrNotFound, got %v", err)
+				}
+				c.DescribeMountTargets(ctx, fsId, "")
+				if counting.describeFileSystems != 2 || counting.describeMountTargets != 2 {
+					t.Fatalf("Expected 2 calls of each, got %d and %d", counting.describeFileSystems, counting.describeMountTargets)
+				}
+			},
+		},
+		{
+			name: "Success: Nothing is cached without TTL",
+			testFunc: func(t *testing.T) {
+				counting, c := newClouds("available", 0)
+				if c != counting {
+					t.Fatal("Expected cloud not to be wrapped")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestTTLCache_CanceledFetch(t *testing.T) {
+	cache := newTTLCache(time.Minute)
+	started := make(chan struct{})
+
+	// The first get fails because its context is done, while a second get waits for it
+	go cache.get(context.Background(), "key", func() (interface{}, bool, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return nil, false, context.Canceled
+	})
+	<-started
+	value, err := cache.get(context.Background(), "key", func() (interface{}, bool, error) {
+		return "value", true, nil
+	})
+	if err != nil || value != "value" {
+		t.Fatalf("Expected second get to fetch the value itself, got %v, %v", value, err)
+	}
+
+	// A get gives up waiting once its own context is done
+	cache.delete("key")
+	started = make(chan struct{})
+	go cache.get(context.Background(), "key", func() (interface{}, bool, error) {
+		close(started)
+		time.Sleep(time.Second)
+		return "value", true, nil
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.get(ctx, "key", nil); err != context.DeadlineExceeded {
+		t.Fatalf("Expected get to give up, got %v", err)
+	}
+}
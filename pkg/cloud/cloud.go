@@ -18,45 +18,67 @@ package cloud
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/backup"
 	"github.com/aws/aws-sdk-go/service/efs"
 	"k8s.io/klog"
 )
 
 const (
 	AccessDeniedException = "AccessDeniedException"
-)
 
-var (
-	ErrNotFound      = errors.New("Resource was not found")
-	ErrAlreadyExists = errors.New("Resource already exists")
-	ErrAccessDenied  = errors.New("Access denied")
+	backupResourceTypeEfs = "EFS"
+
+	// recoveryPointTagsTTL is how long the tags of recovery points are cached. The driver tags recovery points when
+	// creating them and never changes their tags afterwards.
+	recoveryPointTagsTTL = time.Hour
 )
 
 type FileSystem struct {
-	FileSystemId string
+	FileSystemId   string
+	FileSystemArn  string
+	LifeCycleState string
+	// AvailabilityZoneName is only set for One Zone file systems
+	AvailabilityZoneName string
+	// SizeInBytes is the metered size of the data stored in the file system, which EFS updates about once an hour
+	SizeInBytes int64
+	Tags        map[string]string
+}
+
+type FileSystemOptions struct {
+	PerformanceMode              string
+	ThroughputMode               string
+	ProvisionedThroughputInMibps float64
+	Encrypted                    bool
+	KmsKeyId                     string
+	Tags                         map[string]string
 }
 
 type AccessPoint struct {
 	AccessPointId      string
 	FileSystemId       string
 	AccessPointRootDir string
+	PosixUser          *PosixUser
+	Tags               map[string]string
 	// Capacity is used for testing purpose only
 	// EFS does not consider capacity while provisioning new file systems or access points
 	CapacityGiB int64
 }
 
+type PosixUser struct {
+	Uid int64
+	Gid int64
+}
+
 type AccessPointOptions struct {
 	// Capacity is used for testing purpose only.
 	// EFS does not consider capacity while provisioning new file systems or access points
@@ -71,10 +93,39 @@ type AccessPointOptions struct {
 }
 
 type MountTarget struct {
-	AZName        string
-	AZId          string
-	MountTargetId string
-	IPAddress     string
+	AZName         string
+	AZId           string
+	MountTargetId  string
+	IPAddress      string
+	SubnetId       string
+	LifeCycleState string
+}
+
+type BackupJob struct {
+	BackupJobId       string
+	RecoveryPointArn  string
+	ResourceArn       string
+	State             string
+	CreationDate      time.Time
+	BackupSizeInBytes int64
+}
+
+type BackupJobOptions struct {
+	BackupVaultName  string
+	IamRoleArn       string
+	ResourceArn      string
+	IdempotencyToken string
+	Tags             map[string]string
+}
+
+type RecoveryPoint struct {
+	RecoveryPointArn  string
+	BackupVaultName   string
+	ResourceArn       string
+	Status            string
+	CreationDate      time.Time
+	BackupSizeInBytes int64
+	Tags              map[string]string
 }
 
 // Efs abstracts efs client(https://docs.aws.amazon.com/sdk-for-go/api/service/efs/)
@@ -84,6 +135,22 @@ type Efs interface {
 	DescribeAccessPointsWithContext(aws.Context, *efs.DescribeAccessPointsInput, ...request.Option) (*efs.DescribeAccessPointsOutput, error)
 	DescribeFileSystemsWithContext(aws.Context, *efs.DescribeFileSystemsInput, ...request.Option) (*efs.DescribeFileSystemsOutput, error)
 	DescribeMountTargetsWithContext(aws.Context, *efs.DescribeMountTargetsInput, ...request.Option) (*efs.DescribeMountTargetsOutput, error)
+	CreateFileSystemWithContext(aws.Context, *efs.CreateFileSystemInput, ...request.Option) (*efs.FileSystemDescription, error)
+	DeleteFileSystemWithContext(aws.Context, *efs.DeleteFileSystemInput, ...request.Option) (*efs.DeleteFileSystemOutput, error)
+	CreateMountTargetWithContext(aws.Context, *efs.CreateMountTargetInput, ...request.Option) (*efs.MountTargetDescription, error)
+	DeleteMountTargetWithContext(aws.Context, *efs.DeleteMountTargetInput, ...request.Option) (*efs.DeleteMountTargetOutput, error)
+	PutLifecycleConfigurationWithContext(aws.Context, *efs.PutLifecycleConfigurationInput, ...request.Option) (*efs.PutLifecycleConfigurationOutput, error)
+	TagResourceWithContext(aws.Context, *efs.TagResourceInput, ...request.Option) (*efs.TagResourceOutput, error)
+}
+
+// Backup abstracts backup client(https://docs.aws.amazon.com/sdk-for-go/api/service/backup/)
+type Backup interface {
+	StartBackupJobWithContext(aws.Context, *backup.StartBackupJobInput, ...request.Option) (*backup.StartBackupJobOutput, error)
+	DescribeBackupJobWithContext(aws.Context, *backup.DescribeBackupJobInput, ...request.Option) (*backup.DescribeBackupJobOutput, error)
+	DescribeRecoveryPointWithContext(aws.Context, *backup.DescribeRecoveryPointInput, ...request.Option) (*backup.DescribeRecoveryPointOutput, error)
+	DeleteRecoveryPointWithContext(aws.Context, *backup.DeleteRecoveryPointInput, ...request.Option) (*backup.DeleteRecoveryPointOutput, error)
+	ListRecoveryPointsByBackupVaultWithContext(aws.Context, *backup.ListRecoveryPointsByBackupVaultInput, ...request.Option) (*backup.ListRecoveryPointsByBackupVaultOutput, error)
+	ListTagsWithContext(aws.Context, *backup.ListTagsInput, ...request.Option) (*backup.ListTagsOutput, error)
 }
 
 type Cloud interface {
@@ -91,28 +158,53 @@ type Cloud interface {
 	CreateAccessPoint(ctx context.Context, volumeName string, accessPointOpts *AccessPointOptions) (accessPoint *AccessPoint, err error)
 	DeleteAccessPoint(ctx context.Context, accessPointId string) (err error)
 	DescribeAccessPoint(ctx context.Context, accessPointId string) (accessPoint *AccessPoint, err error)
+	TagAccessPoint(ctx context.Context, accessPointId string, tags map[string]string) (err error)
+	ListAccessPoints(ctx context.Context, fileSystemId string) (accessPoints []*AccessPoint, err error)
+	DescribeAccessPoints(ctx context.Context, fileSystemId, nextToken string, maxResults int64) (accessPoints []*AccessPoint, next string, err error)
 	DescribeFileSystem(ctx context.Context, fileSystemId string) (fs *FileSystem, err error)
+	ListFileSystems(ctx context.Context) (fileSystems []*FileSystem, err error)
 	DescribeMountTargets(ctx context.Context, fileSystemId, az string) (fs *MountTarget, err error)
+	ListMountTargets(ctx context.Context, fileSystemId string) (mountTargets []*MountTarget, err error)
+	CreateFileSystem(ctx context.Context, volumeName string, fileSystemOpts *FileSystemOptions) (fs *FileSystem, err error)
+	DeleteFileSystem(ctx context.Context, fileSystemId string) (err error)
+	PutLifecycleConfiguration(ctx context.Context, fileSystemId, transitionToIA string) (err error)
+	CreateMountTarget(ctx context.Context, fileSystemId, subnetId string, securityGroups []string) (mountTarget *MountTarget, err error)
+	DeleteMountTarget(ctx context.Context, mountTargetId string) (err error)
+	StartBackupJob(ctx context.Context, backupJobOpts *BackupJobOptions) (backupJob *BackupJob, err error)
+	DescribeBackupJob(ctx context.Context, backupJobId string) (backupJob *BackupJob, err error)
+	DescribeRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (recoveryPoint *RecoveryPoint, err error)
+	DeleteRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (err error)
+	ListRecoveryPoints(ctx context.Context, backupVaultName, resourceArn, nextToken string, maxResults int64) (recoveryPoints []*RecoveryPoint, next string, err error)
 }
 
 type cloud struct {
 	metadata MetadataService
 	efs      Efs
+	backup   Backup
+	// recoveryPointTags caches the tags of recovery points, which are not part of their listing and would otherwise
+	// be fetched with one call per recovery point every time recovery points are listed
+	recoveryPointTags *ttlCache
 }
 
 // NewCloud returns a new instance of AWS cloud
 // It panics if session is invalid
 func NewCloud() (Cloud, error) {
-	return createCloud("")
+	_, metadata, err := discoverMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return newCachedCloud(newCloud(metadata, nil), getCacheTTL()), nil
 }
 
-// NewCloudWithRole returns a new instance of AWS cloud after assuming an aws role
-// It panics if driver does not have permissions to assume role.
-func NewCloudWithRole(awsRoleArn string) (Cloud, error) {
-	return createCloud(awsRoleArn)
+// NewCloudWithRole returns an instance of AWS cloud assuming an aws role.
+// Clouds are pooled by role and options, so the role is only assumed again when its credentials are about to expire.
+// It fails if the driver does not have permissions to assume the role.
+func NewCloudWithRole(ctx context.Context, opts RoleOptions) (Cloud, error) {
+	return defaultRolePool.get(ctx, opts)
 }
 
-func createCloud(awsRoleArn string) (Cloud, error) {
+// discoverMetadata returns the default session and the metadata of the instance or task the driver runs on.
+func discoverMetadata() (*session.Session, MetadataService, error) {
 	sess := session.Must(session.NewSession(&aws.Config{}))
 	svc := ec2metadata.New(sess)
 	api, err := DefaultKubernetesAPIClient()
@@ -124,30 +216,47 @@ func createCloud(awsRoleArn string) (Cloud, error) {
 	metadataProvider, err := GetNewMetadataProvider(svc, api)
 
 	if err != nil {
-		return nil, fmt.Errorf("error creating MetadataProvider: %v", err)
+		return nil, nil, fmt.Errorf("error creating MetadataProvider: %v", err)
 	}
 
 	metadata, err := metadataProvider.getMetadata()
 
 	if err != nil {
-		return nil, fmt.Errorf("could not get metadata: %v", err)
+		return nil, nil, fmt.Errorf("could not get metadata: %v", err)
 	}
+	return withRegionEndpoints(sess, metadata.GetRegion()), metadata, nil
+}
 
-	efs_client := createEfsClient(awsRoleArn, metadata, sess)
+// newCloud returns a cloud calling the region of metadata with creds, or with the default credentials if creds is nil.
+func newCloud(metadata MetadataService, creds *credentials.Credentials) *cloud {
+	efs_client := createEfsClient(creds, metadata)
 	klog.V(5).Infof("EFS Client created using the following endpoint: %+v", efs_client.(*efs.EFS).Client.ClientInfo.Endpoint)
 
 	return &cloud{
-		metadata: metadata,
-		efs:      efs_client,
-	}, nil
+		metadata:          metadata,
+		efs:               efs_client,
+		backup:            createBackupClient(creds, metadata),
+		recoveryPointTags: newTTLCache(recoveryPointTagsTTL),
+	}
+}
+
+func createEfsClient(creds *credentials.Credentials, metadata MetadataService) Efs {
+	return efs.New(createClientSession(creds, metadata), efsEndpointConfig())
+}
+
+func createBackupClient(creds *credentials.Credentials, metadata MetadataService) Backup {
+	return backup.New(createClientSession(creds, metadata))
 }
 
-func createEfsClient(awsRoleArn string, metadata MetadataService, sess *session.Session) Efs {
-	config := aws.NewConfig().WithRegion(metadata.GetRegion())
-	if awsRoleArn != "" {
-		config = config.WithCredentials(stscreds.NewCredentials(sess, awsRoleArn))
+func createClientSession(creds *credentials.Credentials, metadata MetadataService) *session.Session {
+	config := withEndpoints(aws.NewConfig().WithRegion(metadata.GetRegion()))
+	if creds != nil {
+		config = config.WithCredentials(creds)
 	}
-	return efs.New(session.Must(session.NewSession(config)))
+	clientSession := session.Must(session.NewSession(config))
+	addRetries(clientSession, metadata.GetRegion())
+	addMetricsHandlers(&clientSession.Handlers)
+	return clientSession
 }
 
 func (c *cloud) GetMetadata() MetadataService {
@@ -177,10 +286,7 @@ func (c *cloud) CreateAccessPoint(ctx context.Context, volumeName string, access
 	klog.V(5).Infof("Calling Create AP with input: %+v", *createAPInput)
 	res, err := c.efs.CreateAccessPointWithContext(ctx, createAPInput)
 	if err != nil {
-		if isAccessDenied(err) {
-			return nil, ErrAccessDenied
-		}
-		return nil, fmt.Errorf("Failed to create access point: %v", err)
+		return nil, wrapError(err, "Failed to create access point")
 	}
 
 	return &AccessPoint{
@@ -194,13 +300,7 @@ func (c *cloud) DeleteAccessPoint(ctx context.Context, accessPointId string) (er
 	deleteAccessPointInput := &efs.DeleteAccessPointInput{AccessPointId: &accessPointId}
 	_, err = c.efs.DeleteAccessPointWithContext(ctx, deleteAccessPointInput)
 	if err != nil {
-		if isAccessDenied(err) {
-			return ErrAccessDenied
-		}
-		if isAccessPointNotFound(err) {
-			return ErrNotFound
-		}
-		return fmt.Errorf("Failed to delete access point: %v, error: %v", accessPointId, err)
+		return wrapError(err, "Failed to delete access point: %v", accessPointId)
 	}
 
 	return nil
@@ -212,13 +312,7 @@ func (c *cloud) DescribeAccessPoint(ctx context.Context, accessPointId string) (
 	}
 	res, err := c.efs.DescribeAccessPointsWithContext(ctx, describeAPInput)
 	if err != nil {
-		if isAccessDenied(err) {
-			return nil, ErrAccessDenied
-		}
-		if isAccessPointNotFound(err) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("Describe Access Point failed: %v", err)
+		return nil, wrapError(err, "Describe Access Point failed")
 	}
 
 	accessPoints := res.AccessPoints
@@ -230,30 +324,192 @@ func (c *cloud) DescribeAccessPoint(ctx context.Context, accessPointId string) (
 		AccessPointId:      *accessPoints[0].AccessPointId,
 		FileSystemId:       *accessPoints[0].FileSystemId,
 		AccessPointRootDir: *accessPoints[0].RootDirectory.Path,
+		PosixUser:          parsePosixUser(accessPoints[0].PosixUser),
+		Tags:               parseTagsFromEfs(accessPoints[0].Tags),
 	}, nil
 }
 
+// TagAccessPoint adds the given tags to an access point, overwriting the value of existing keys.
+func (c *cloud) TagAccessPoint(ctx context.Context, accessPointId string, tags map[string]string) (err error) {
+	tagResourceInput := &efs.TagResourceInput{
+		ResourceId: &accessPointId,
+		Tags:       parseEfsTags(tags),
+	}
+	klog.V(5).Infof("Calling TagResource with input: %+v", *tagResourceInput)
+	_, err = c.efs.TagResourceWithContext(ctx, tagResourceInput)
+	if err != nil {
+		return wrapError(err, "Failed to tag access point: %v", accessPointId)
+	}
+
+	return nil
+}
+
+// ListAccessPoints returns every access point of the given file system, following
+// DescribeAccessPoints pagination until all pages have been read.
+func (c *cloud) ListAccessPoints(ctx context.Context, fileSystemId string) (accessPoints []*AccessPoint, err error) {
+	nextToken := ""
+	for {
+		page, next, err := c.DescribeAccessPoints(ctx, fileSystemId, nextToken, 0)
+		if err != nil {
+			return nil, err
+		}
+		accessPoints = append(accessPoints, page...)
+
+		if next == "" {
+			break
+		}
+		nextToken = next
+	}
+
+	return accessPoints, nil
+}
+
+// DescribeAccessPoints returns a single page of access points along with the token of the next page, which is empty
+// on the last page. All access points visible to the caller are described if fileSystemId is empty, and the EFS
+// default page size is used if maxResults is 0.
+func (c *cloud) DescribeAccessPoints(ctx context.Context, fileSystemId, nextToken string, maxResults int64) (accessPoints []*AccessPoint, next string, err error) {
+	describeAPInput := &efs.DescribeAccessPointsInput{}
+	if fileSystemId != "" {
+		describeAPInput.FileSystemId = &fileSystemId
+	}
+	if nextToken != "" {
+		describeAPInput.NextToken = &nextToken
+	}
+	if maxResults > 0 {
+		describeAPInput.MaxResults = &maxResults
+	}
+
+	klog.V(5).Infof("Calling DescribeAccessPoints with input: %+v", *describeAPInput)
+	res, err := c.efs.DescribeAccessPointsWithContext(ctx, describeAPInput)
+	if err != nil {
+		if nextToken != "" && hasErrorCode(err, efs.ErrCodeBadRequest) {
+			return nil, "", newError(ErrInvalidToken, err, "Describe Access Points failed")
+		}
+		return nil, "", wrapError(err, "Describe Access Points failed")
+	}
+
+	for _, ap := range res.AccessPoints {
+		accessPoint := &AccessPoint{
+			AccessPointId: aws.StringValue(ap.AccessPointId),
+			FileSystemId:  aws.StringValue(ap.FileSystemId),
+			PosixUser:     parsePosixUser(ap.PosixUser),
+			Tags:          parseTagsFromEfs(ap.Tags),
+		}
+		if ap.RootDirectory != nil {
+			accessPoint.AccessPointRootDir = aws.StringValue(ap.RootDirectory.Path)
+		}
+		accessPoints = append(accessPoints, accessPoint)
+	}
+
+	return accessPoints, aws.StringValue(res.NextToken), nil
+}
+
 func (c *cloud) DescribeFileSystem(ctx context.Context, fileSystemId string) (fs *FileSystem, err error) {
 	describeFsInput := &efs.DescribeFileSystemsInput{FileSystemId: &fileSystemId}
 	klog.V(5).Infof("Calling DescribeFileSystems with input: %+v", *describeFsInput)
 	res, err := c.efs.DescribeFileSystemsWithContext(ctx, describeFsInput)
 	if err != nil {
-		if isAccessDenied(err) {
-			return nil, ErrAccessDenied
-		}
-		if isFileSystemNotFound(err) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("Describe File System failed: %v", err)
+		return nil, wrapError(err, "Describe File System failed")
 	}
 
 	fileSystems := res.FileSystems
 	if len(fileSystems) == 0 || len(fileSystems) > 1 {
 		return nil, fmt.Errorf("DescribeFileSystem failed. Expected exactly 1 file system in DescribeFileSystem result. However, recevied %d file systems", len(fileSystems))
 	}
-	return &FileSystem{
-		FileSystemId: *res.FileSystems[0].FileSystemId,
-	}, nil
+	return parseFileSystem(res.FileSystems[0]), nil
+}
+
+// ListFileSystems returns all file systems visible to the caller, reading every page of DescribeFileSystems.
+func (c *cloud) ListFileSystems(ctx context.Context) (fileSystems []*FileSystem, err error) {
+	describeFsInput := &efs.DescribeFileSystemsInput{}
+	for {
+		klog.V(5).Infof("Calling DescribeFileSystems with input: %+v", *describeFsInput)
+		res, err := c.efs.DescribeFileSystemsWithContext(ctx, describeFsInput)
+		if err != nil {
+			return nil, wrapError(err, "Describe File Systems failed")
+		}
+		for _, fileSystem := range res.FileSystems {
+			fileSystems = append(fileSystems, parseFileSystem(fileSystem))
+		}
+
+		if aws.StringValue(res.NextMarker) == "" {
+			break
+		}
+		describeFsInput.Marker = res.NextMarker
+	}
+
+	return fileSystems, nil
+}
+
+// CreateFileSystem creates a file system using volumeName as its creation token. If a file system with that
+// creation token already exists, e.g. because a previous CreateVolume call timed out, it is returned instead.
+func (c *cloud) CreateFileSystem(ctx context.Context, volumeName string, fileSystemOpts *FileSystemOptions) (fs *FileSystem, err error) {
+	describeFsInput := &efs.DescribeFileSystemsInput{CreationToken: &volumeName}
+	klog.V(5).Infof("Calling DescribeFileSystems with input: %+v", *describeFsInput)
+	res, err := c.efs.DescribeFileSystemsWithContext(ctx, describeFsInput)
+	if err != nil {
+		return nil, wrapError(err, "Describe File System failed")
+	}
+	if len(res.FileSystems) > 0 {
+		klog.V(5).Infof("File system with creation token %v already exists", volumeName)
+		return parseFileSystem(res.FileSystems[0]), nil
+	}
+
+	createFsInput := &efs.CreateFileSystemInput{
+		CreationToken: &volumeName,
+		Encrypted:     aws.Bool(fileSystemOpts.Encrypted),
+		Tags:          parseEfsTags(fileSystemOpts.Tags),
+	}
+	if fileSystemOpts.PerformanceMode != "" {
+		createFsInput.PerformanceMode = &fileSystemOpts.PerformanceMode
+	}
+	if fileSystemOpts.ThroughputMode != "" {
+		createFsInput.ThroughputMode = &fileSystemOpts.ThroughputMode
+	}
+	if fileSystemOpts.ProvisionedThroughputInMibps > 0 {
+		createFsInput.ProvisionedThroughputInMibps = &fileSystemOpts.ProvisionedThroughputInMibps
+	}
+	if fileSystemOpts.KmsKeyId != "" {
+		createFsInput.KmsKeyId = &fileSystemOpts.KmsKeyId
+	}
+
+	klog.V(5).Infof("Calling CreateFileSystem with input: %+v", *createFsInput)
+	fileSystem, err := c.efs.CreateFileSystemWithContext(ctx, createFsInput)
+	if err != nil {
+		return nil, wrapError(err, "Failed to create file system")
+	}
+
+	return parseFileSystem(fileSystem), nil
+}
+
+func (c *cloud) DeleteFileSystem(ctx context.Context, fileSystemId string) (err error) {
+	deleteFsInput := &efs.DeleteFileSystemInput{FileSystemId: &fileSystemId}
+	klog.V(5).Infof("Calling DeleteFileSystem with input: %+v", *deleteFsInput)
+	_, err = c.efs.DeleteFileSystemWithContext(ctx, deleteFsInput)
+	if err != nil {
+		return wrapError(err, "Failed to delete file system: %v", fileSystemId)
+	}
+
+	return nil
+}
+
+// PutLifecycleConfiguration sets the transition to infrequent access policy of the file system, e.g. AFTER_30_DAYS.
+func (c *cloud) PutLifecycleConfiguration(ctx context.Context, fileSystemId, transitionToIA string) (err error) {
+	putLifecycleInput := &efs.PutLifecycleConfigurationInput{
+		FileSystemId: &fileSystemId,
+		LifecyclePolicies: []*efs.LifecyclePolicy{
+			{
+				TransitionToIA: &transitionToIA,
+			},
+		},
+	}
+	klog.V(5).Infof("Calling PutLifecycleConfiguration with input: %+v", *putLifecycleInput)
+	_, err = c.efs.PutLifecycleConfigurationWithContext(ctx, putLifecycleInput)
+	if err != nil {
+		return wrapError(err, "Failed to put lifecycle configuration on file system: %v", fileSystemId)
+	}
+
+	return nil
 }
 
 func (c *cloud) DescribeMountTargets(ctx context.Context, fileSystemId, azName string) (fs *MountTarget, err error) {
@@ -261,13 +517,7 @@ func (c *cloud) DescribeMountTargets(ctx context.Context, fileSystemId, azName s
 	klog.V(5).Infof("Calling DescribeMountTargets with input: %+v", *describeMtInput)
 	res, err := c.efs.DescribeMountTargetsWithContext(ctx, describeMtInput)
 	if err != nil {
-		if isAccessDenied(err) {
-			return nil, ErrAccessDenied
-		}
-		if isFileSystemNotFound(err) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("Describe Mount Targets failed: %v", err)
+		return nil, wrapError(err, "Describe Mount Targets failed")
 	}
 
 	mountTargets := res.MountTargets
@@ -302,31 +552,231 @@ func (c *cloud) DescribeMountTargets(ctx context.Context, fileSystemId, azName s
 	}, nil
 }
 
-func isFileSystemNotFound(err error) bool {
-	if awsErr, ok := err.(awserr.Error); ok {
-		if awsErr.Code() == efs.ErrCodeFileSystemNotFound {
-			return true
+// ListMountTargets returns every mount target of the file system regardless of its life cycle state.
+func (c *cloud) ListMountTargets(ctx context.Context, fileSystemId string) (mountTargets []*MountTarget, err error) {
+	describeMtInput := &efs.DescribeMountTargetsInput{FileSystemId: &fileSystemId}
+	for {
+		klog.V(5).Infof("Calling DescribeMountTargets with input: %+v", *describeMtInput)
+		res, err := c.efs.DescribeMountTargetsWithContext(ctx, describeMtInput)
+		if err != nil {
+			return nil, wrapError(err, "Describe Mount Targets failed")
+		}
+
+		for _, mt := range res.MountTargets {
+			mountTargets = append(mountTargets, parseMountTarget(mt))
+		}
+
+		if aws.StringValue(res.NextMarker) == "" {
+			break
+		}
+		describeMtInput.Marker = res.NextMarker
+	}
+
+	return mountTargets, nil
+}
+
+// CreateMountTarget creates a mount target for the file system in the given subnet. If the file system already has a
+// mount target in the subnet's availability zone, an ErrMountTargetConflict error, which is an ErrAlreadyExists error,
+// is returned.
+func (c *cloud) CreateMountTarget(ctx context.Context, fileSystemId, subnetId string, securityGroups []string) (mountTarget *MountTarget, err error) {
+	createMtInput := &efs.CreateMountTargetInput{
+		FileSystemId: &fileSystemId,
+		SubnetId:     &subnetId,
+	}
+	if len(securityGroups) > 0 {
+		createMtInput.SecurityGroups = aws.StringSlice(securityGroups)
+	}
+
+	klog.V(5).Infof("Calling CreateMountTarget with input: %+v", *createMtInput)
+	res, err := c.efs.CreateMountTargetWithContext(ctx, createMtInput)
+	if err != nil {
+		return nil, wrapError(err, "Failed to create mount target in subnet %v", subnetId)
+	}
+
+	return parseMountTarget(res), nil
+}
+
+func (c *cloud) DeleteMountTarget(ctx context.Context, mountTargetId string) (err error) {
+	deleteMtInput := &efs.DeleteMountTargetInput{MountTargetId: &mountTargetId}
+	klog.V(5).Infof("Calling DeleteMountTarget with input: %+v", *deleteMtInput)
+	_, err = c.efs.DeleteMountTargetWithContext(ctx, deleteMtInput)
+	if err != nil {
+		return wrapError(err, "Failed to delete mount target: %v", mountTargetId)
+	}
+
+	return nil
+}
+
+// StartBackupJob starts an on-demand backup of a file system. Calls with the same idempotency token return the job
+// started by the first call.
+func (c *cloud) StartBackupJob(ctx context.Context, backupJobOpts *BackupJobOptions) (backupJob *BackupJob, err error) {
+	startBackupJobInput := &backup.StartBackupJobInput{
+		BackupVaultName:   &backupJobOpts.BackupVaultName,
+		IamRoleArn:        &backupJobOpts.IamRoleArn,
+		IdempotencyToken:  &backupJobOpts.IdempotencyToken,
+		RecoveryPointTags: aws.StringMap(backupJobOpts.Tags),
+		ResourceArn:       &backupJobOpts.ResourceArn,
+	}
+
+	klog.V(5).Infof("Calling StartBackupJob with input: %+v", *startBackupJobInput)
+	res, err := c.backup.StartBackupJobWithContext(ctx, startBackupJobInput)
+	if err != nil {
+		return nil, wrapError(err, "Failed to start backup job of %v", backupJobOpts.ResourceArn)
+	}
+
+	return &BackupJob{
+		BackupJobId:      aws.StringValue(res.BackupJobId),
+		RecoveryPointArn: aws.StringValue(res.RecoveryPointArn),
+		ResourceArn:      backupJobOpts.ResourceArn,
+		State:            backup.JobStateCreated,
+		CreationDate:     aws.TimeValue(res.CreationDate),
+	}, nil
+}
+
+func (c *cloud) DescribeBackupJob(ctx context.Context, backupJobId string) (backupJob *BackupJob, err error) {
+	describeBackupJobInput := &backup.DescribeBackupJobInput{BackupJobId: &backupJobId}
+	klog.V(5).Infof("Calling DescribeBackupJob with input: %+v", *describeBackupJobInput)
+	res, err := c.backup.DescribeBackupJobWithContext(ctx, describeBackupJobInput)
+	if err != nil {
+		return nil, wrapError(err, "Describe Backup Job failed")
+	}
+
+	return &BackupJob{
+		BackupJobId:       aws.StringValue(res.BackupJobId),
+		RecoveryPointArn:  aws.StringValue(res.RecoveryPointArn),
+		ResourceArn:       aws.StringValue(res.ResourceArn),
+		State:             aws.StringValue(res.State),
+		CreationDate:      aws.TimeValue(res.CreationDate),
+		BackupSizeInBytes: aws.Int64Value(res.BackupSizeInBytes),
+	}, nil
+}
+
+func (c *cloud) DescribeRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (recoveryPoint *RecoveryPoint, err error) {
+	describeRecoveryPointInput := &backup.DescribeRecoveryPointInput{
+		BackupVaultName:  &backupVaultName,
+		RecoveryPointArn: &recoveryPointArn,
+	}
+	klog.V(5).Infof("Calling DescribeRecoveryPoint with input: %+v", *describeRecoveryPointInput)
+	res, err := c.backup.DescribeRecoveryPointWithContext(ctx, describeRecoveryPointInput)
+	if err != nil {
+		if hasErrorCode(err, backup.ErrCodeInvalidParameterValueException) {
+			return nil, newError(ErrNotFound, err, "Describe Recovery Point failed")
+		}
+		return nil, wrapError(err, "Describe Recovery Point failed")
+	}
+
+	tags, err := c.getRecoveryPointTags(ctx, recoveryPointArn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoveryPoint{
+		RecoveryPointArn:  aws.StringValue(res.RecoveryPointArn),
+		BackupVaultName:   aws.StringValue(res.BackupVaultName),
+		ResourceArn:       aws.StringValue(res.ResourceArn),
+		Status:            aws.StringValue(res.Status),
+		CreationDate:      aws.TimeValue(res.CreationDate),
+		BackupSizeInBytes: aws.Int64Value(res.BackupSizeInBytes),
+		Tags:              tags,
+	}, nil
+}
+
+func (c *cloud) DeleteRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (err error) {
+	deleteRecoveryPointInput := &backup.DeleteRecoveryPointInput{
+		BackupVaultName:  &backupVaultName,
+		RecoveryPointArn: &recoveryPointArn,
+	}
+	klog.V(5).Infof("Calling DeleteRecoveryPoint with input: %+v", *deleteRecoveryPointInput)
+	_, err = c.backup.DeleteRecoveryPointWithContext(ctx, deleteRecoveryPointInput)
+	c.recoveryPointTags.delete(recoveryPointArn)
+	if err != nil {
+		if hasErrorCode(err, backup.ErrCodeInvalidParameterValueException) {
+			return newError(ErrNotFound, err, "Failed to delete recovery point: %v", recoveryPointArn)
 		}
+		return wrapError(err, "Failed to delete recovery point: %v", recoveryPointArn)
 	}
-	return false
+
+	return nil
 }
 
-func isAccessPointNotFound(err error) bool {
-	if awsErr, ok := err.(awserr.Error); ok {
-		if awsErr.Code() == efs.ErrCodeAccessPointNotFound {
-			return true
+// ListRecoveryPoints returns a single page of the EFS recovery points in the backup vault, optionally restricted to
+// those of resourceArn, along with the token of the next page.
+func (c *cloud) ListRecoveryPoints(ctx context.Context, backupVaultName, resourceArn, nextToken string, maxResults int64) (recoveryPoints []*RecoveryPoint, next string, err error) {
+	listRecoveryPointsInput := &backup.ListRecoveryPointsByBackupVaultInput{
+		BackupVaultName: &backupVaultName,
+		ByResourceType:  aws.String(backupResourceTypeEfs),
+	}
+	if resourceArn != "" {
+		listRecoveryPointsInput.ByResourceArn = &resourceArn
+	}
+	if nextToken != "" {
+		listRecoveryPointsInput.NextToken = &nextToken
+	}
+	if maxResults > 0 {
+		listRecoveryPointsInput.MaxResults = &maxResults
+	}
+
+	klog.V(5).Infof("Calling ListRecoveryPointsByBackupVault with input: %+v", *listRecoveryPointsInput)
+	res, err := c.backup.ListRecoveryPointsByBackupVaultWithContext(ctx, listRecoveryPointsInput)
+	if err != nil {
+		if nextToken != "" && hasErrorCode(err, backup.ErrCodeInvalidParameterValueException) {
+			return nil, "", newError(ErrInvalidToken, err, "List Recovery Points failed")
+		}
+		return nil, "", wrapError(err, "List Recovery Points failed")
+	}
+
+	for _, rp := range res.RecoveryPoints {
+		tags, err := c.getRecoveryPointTags(ctx, aws.StringValue(rp.RecoveryPointArn))
+		if err != nil {
+			return nil, "", err
 		}
+		recoveryPoints = append(recoveryPoints, &RecoveryPoint{
+			RecoveryPointArn:  aws.StringValue(rp.RecoveryPointArn),
+			BackupVaultName:   aws.StringValue(rp.BackupVaultName),
+			ResourceArn:       aws.StringValue(rp.ResourceArn),
+			Status:            aws.StringValue(rp.Status),
+			CreationDate:      aws.TimeValue(rp.CreationDate),
+			BackupSizeInBytes: aws.Int64Value(rp.BackupSizeInBytes),
+			Tags:              tags,
+		})
 	}
-	return false
+
+	return recoveryPoints, aws.StringValue(res.NextToken), nil
+}
+
+// getRecoveryPointTags returns the tags of the recovery point, fetching them only if they are not cached. Recovery
+// points without tags are not cached, as their tags may not have been applied yet.
+func (c *cloud) getRecoveryPointTags(ctx context.Context, recoveryPointArn string) (map[string]string, error) {
+	value, err := c.recoveryPointTags.get(ctx, recoveryPointArn, func() (interface{}, bool, error) {
+		tags, err := c.listBackupTags(ctx, recoveryPointArn)
+		return tags, len(tags) > 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for k, v := range value.(map[string]string) {
+		tags[k] = v
+	}
+	return tags, nil
 }
 
-func isAccessDenied(err error) bool {
-	if awsErr, ok := err.(awserr.Error); ok {
-		if awsErr.Code() == AccessDeniedException {
-			return true
+func (c *cloud) listBackupTags(ctx context.Context, resourceArn string) (map[string]string, error) {
+	tags := map[string]string{}
+	listTagsInput := &backup.ListTagsInput{ResourceArn: &resourceArn}
+	for {
+		res, err := c.backup.ListTagsWithContext(ctx, listTagsInput)
+		if err != nil {
+			return nil, wrapError(err, "List Tags of %v failed", resourceArn)
 		}
+		for k, v := range res.Tags {
+			tags[k] = aws.StringValue(v)
+		}
+		if aws.StringValue(res.NextToken) == "" {
+			return tags, nil
+		}
+		listTagsInput.NextToken = res.NextToken
 	}
-	return false
 }
 
 func isDriverBootedInECS() bool {
@@ -347,6 +797,49 @@ func parseEfsTags(tagMap map[string]string) []*efs.Tag {
 	return efsTags
 }
 
+func parseTagsFromEfs(efsTags []*efs.Tag) map[string]string {
+	tags := make(map[string]string, len(efsTags))
+	for _, tag := range efsTags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags
+}
+
+func parseFileSystem(fileSystem *efs.FileSystemDescription) *FileSystem {
+	fs := &FileSystem{
+		FileSystemId:         aws.StringValue(fileSystem.FileSystemId),
+		FileSystemArn:        aws.StringValue(fileSystem.FileSystemArn),
+		LifeCycleState:       aws.StringValue(fileSystem.LifeCycleState),
+		AvailabilityZoneName: aws.StringValue(fileSystem.AvailabilityZoneName),
+		Tags:                 parseTagsFromEfs(fileSystem.Tags),
+	}
+	if fileSystem.SizeInBytes != nil {
+		fs.SizeInBytes = aws.Int64Value(fileSystem.SizeInBytes.Value)
+	}
+	return fs
+}
+
+func parseMountTarget(mountTarget *efs.MountTargetDescription) *MountTarget {
+	return &MountTarget{
+		AZName:         aws.StringValue(mountTarget.AvailabilityZoneName),
+		AZId:           aws.StringValue(mountTarget.AvailabilityZoneId),
+		MountTargetId:  aws.StringValue(mountTarget.MountTargetId),
+		IPAddress:      aws.StringValue(mountTarget.IpAddress),
+		SubnetId:       aws.StringValue(mountTarget.SubnetId),
+		LifeCycleState: aws.StringValue(mountTarget.LifeCycleState),
+	}
+}
+
+func parsePosixUser(posixUser *efs.PosixUser) *PosixUser {
+	if posixUser == nil {
+		return nil
+	}
+	return &PosixUser{
+		Uid: aws.Int64Value(posixUser.Uid),
+		Gid: aws.Int64Value(posixUser.Gid),
+	}
+}
+
 func getAvailableMountTargets(mountTargets []*efs.MountTargetDescription) []*efs.MountTargetDescription {
 	availableMountTargets := []*efs.MountTargetDescription{}
 	for _, mt := range mountTargets {
@@ -3,10 +3,13 @@ package cloud
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/backup"
 	"github.com/aws/aws-sdk-go/service/efs"
 	"github.com/golang/mock/gomock"
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud/mocks"
@@ -139,7 +142,7 @@ func TestCreateAccessPoint(t *testing.T) {
 				if err == nil {
 					t.Fatalf("CreateAccessPoint did not fail")
 				}
-				if err != ErrAccessDenied {
+				if !errors.Is(err, ErrAccessDenied) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
 				}
 				mockCtl.Finish()
@@ -191,7 +194,7 @@ func TestDeleteAccessPoint(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DeleteAccessPoint did not fail")
 				}
-				if err != ErrNotFound {
+				if !errors.Is(err, ErrNotFound) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrNotFound, err)
 				}
 				mockctl.Finish()
@@ -210,7 +213,7 @@ func TestDeleteAccessPoint(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DeleteAccessPoint did not fail")
 				}
-				if err != ErrAccessDenied {
+				if !errors.Is(err, ErrAccessDenied) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
 				}
 				mockctl.Finish()
@@ -396,7 +399,7 @@ func TestDescribeAccessPoint(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DescribeAccessPoint did not fail")
 				}
-				if err != ErrNotFound {
+				if !errors.Is(err, ErrNotFound) {
 					t.Fatalf("Failed. Expected: %v, Actuak: %v", ErrNotFound, err)
 				}
 				mockctl.Finish()
@@ -415,7 +418,7 @@ func TestDescribeAccessPoint(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DescribeAccessPoint did not fail")
 				}
-				if err != ErrAccessDenied {
+				if !errors.Is(err, ErrAccessDenied) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
 				}
 				mockctl.Finish()
@@ -443,6 +446,292 @@ func TestDescribeAccessPoint(t *testing.T) {
 	}
 }
 
+func TestTagAccessPoint(t *testing.T) {
+	var (
+		accessPointId = "fsap-abcd1234xyz987"
+		tags          = map[string]string{"key": "value"}
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().TagResourceWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(func(ctx context.Context, input *efs.TagResourceInput, opts ...request.Option) (*efs.TagResourceOutput, error) {
+					if aws.StringValue(input.ResourceId) != accessPointId {
+						t.Fatalf("ResourceId mismatched. Expected: %v, Actual: %v", accessPointId, aws.StringValue(input.ResourceId))
+					}
+					if !reflect.DeepEqual(tags, parseTagsFromEfs(input.Tags)) {
+						t.Fatalf("Tags mismatched. Expected: %v, Actual: %v", tags, parseTagsFromEfs(input.Tags))
+					}
+					return &efs.TagResourceOutput{}, nil
+				})
+				err := c.TagAccessPoint(ctx, accessPointId, tags)
+				if err != nil {
+					t.Fatalf("TagAccessPoint failed: %v", err)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access Point Not Found",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().TagResourceWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(efs.ErrCodeAccessPointNotFound, "Access Point not found", errors.New("TagResourceWithContext failed")))
+				err := c.TagAccessPoint(ctx, accessPointId, tags)
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Failed. Expected: %v, Actual: %v", ErrNotFound, err)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access Denied",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().TagResourceWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")))
+				err := c.TagAccessPoint(ctx, accessPointId, tags)
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Failed. Expected: %v, Actual: %v", ErrAccessDenied, err)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Other",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().TagResourceWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, errors.New("TagResourceWithContext failed"))
+				err := c.TagAccessPoint(ctx, accessPointId, tags)
+				if err == nil {
+					t.Fatalf("TagAccessPoint did not fail")
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestListAccessPoints(t *testing.T) {
+	var (
+		fsId            = "fs-abcd1234"
+		nextToken       = "next"
+		uid       int64 = 1001
+		gid       int64 = 1001
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: all pages are read",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				firstPage := &efs.DescribeAccessPointsOutput{
+					AccessPoints: []*efs.AccessPointDescription{
+						{
+							AccessPointId: aws.String("fsap-1"),
+							FileSystemId:  aws.String(fsId),
+							PosixUser: &efs.PosixUser{
+								Gid: aws.Int64(gid),
+								Uid: aws.Int64(uid),
+							},
+							RootDirectory: &efs.RootDirectory{
+								Path: aws.String("/one"),
+							},
+						},
+					},
+					NextToken: aws.String(nextToken),
+				}
+				secondPage := &efs.DescribeAccessPointsOutput{
+					AccessPoints: []*efs.AccessPointDescription{
+						{
+							AccessPointId: aws.String("fsap-2"),
+							FileSystemId:  aws.String(fsId),
+						},
+					},
+				}
+				ctx := context.Background()
+				gomock.InOrder(
+					mockEfs.EXPECT().DescribeAccessPointsWithContext(gomock.Eq(ctx), gomock.Any()).Return(firstPage, nil),
+					mockEfs.EXPECT().DescribeAccessPointsWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+						func(_ aws.Context, input *efs.DescribeAccessPointsInput, _ ...request.Option) (*efs.DescribeAccessPointsOutput, error) {
+							if aws.StringValue(input.NextToken) != nextToken {
+								t.Fatalf("NextToken mismatched. Expected: %v, Actual: %v", nextToken, aws.StringValue(input.NextToken))
+							}
+							return secondPage, nil
+						}),
+				)
+
+				res, err := c.ListAccessPoints(ctx, fsId)
+				if err != nil {
+					t.Fatalf("List Access Points failed: %v", err)
+				}
+
+				if len(res) != 2 {
+					t.Fatalf("Expected 2 access points, got %d", len(res))
+				}
+
+				if res[0].PosixUser == nil || res[0].PosixUser.Gid != gid {
+					t.Fatalf("PosixUser mismatched. Expected gid: %v, Actual: %+v", gid, res[0].PosixUser)
+				}
+
+				if res[0].AccessPointRootDir != "/one" {
+					t.Fatalf("AccessPointRootDir mismatched. Expected: %v, Actual: %v", "/one", res[0].AccessPointRootDir)
+				}
+
+				if res[1].PosixUser != nil {
+					t.Fatalf("Expected nil PosixUser, got %+v", res[1].PosixUser)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: File System Not Found",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeAccessPointsWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(efs.ErrCodeFileSystemNotFound, "File System not found", errors.New("File System not found")))
+				_, err := c.ListAccessPoints(ctx, fsId)
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrNotFound, err)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access Denied",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeAccessPointsWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")))
+				_, err := c.ListAccessPoints(ctx, fsId)
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestListFileSystems(t *testing.T) {
+	var (
+		marker = "next"
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: all pages are read",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				firstPage := &efs.DescribeFileSystemsOutput{
+					FileSystems: []*efs.FileSystemDescription{
+						{
+							FileSystemId: aws.String("fs-1"),
+							Tags: []*efs.Tag{
+								{Key: aws.String("key"), Value: aws.String("value")},
+							},
+						},
+					},
+					NextMarker: aws.String(marker),
+				}
+				secondPage := &efs.DescribeFileSystemsOutput{
+					FileSystems: []*efs.FileSystemDescription{
+						{
+							FileSystemId: aws.String("fs-2"),
+						},
+					},
+				}
+				ctx := context.Background()
+				gomock.InOrder(
+					mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(firstPage, nil),
+					mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+						func(_ aws.Context, input *efs.DescribeFileSystemsInput, _ ...request.Option) (*efs.DescribeFileSystemsOutput, error) {
+							if aws.StringValue(input.Marker) != marker {
+								t.Fatalf("Marker mismatched. Expected: %v, Actual: %v", marker, aws.StringValue(input.Marker))
+							}
+							return secondPage, nil
+						}),
+				)
+
+				res, err := c.ListFileSystems(ctx)
+				if err != nil {
+					t.Fatalf("List File Systems failed: %v", err)
+				}
+
+				if len(res) != 2 {
+					t.Fatalf("Expected 2 file systems, got %d", len(res))
+				}
+
+				if res[0].Tags["key"] != "value" {
+					t.Fatalf("Tags mismatched. Expected key=value, Actual: %v", res[0].Tags)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access Denied",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")))
+				_, err := c.ListFileSystems(ctx)
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
 func TestDescribeFileSystem(t *testing.T) {
 	var (
 		fsId = "fs-abcd1234"
@@ -466,6 +755,10 @@ func TestDescribeFileSystem(t *testing.T) {
 							FileSystemId:  aws.String(fsId),
 							Name:          aws.String("test"),
 							OwnerId:       aws.String("1234567890"),
+							SizeInBytes: &efs.FileSystemSize{
+								Value: aws.Int64(6144),
+							},
+							AvailabilityZoneName: aws.String("us-east-1a"),
 						},
 					},
 				}
@@ -484,6 +777,14 @@ func TestDescribeFileSystem(t *testing.T) {
 				if fsId != res.FileSystemId {
 					t.Fatalf("FileSystemId mismatched. Expected: %v, Actual: %v", fsId, res.FileSystemId)
 				}
+
+				if res.SizeInBytes != 6144 {
+					t.Fatalf("SizeInBytes mismatched. Expected: %v, Actual: %v", 6144, res.SizeInBytes)
+				}
+
+				if res.AvailabilityZoneName != "us-east-1a" {
+					t.Fatalf("AvailabilityZoneName mismatched. Expected: %v, Actual: %v", "us-east-1a", res.AvailabilityZoneName)
+				}
 				mockctl.Finish()
 			},
 		},
@@ -555,7 +856,7 @@ func TestDescribeFileSystem(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DescribeFileSystem did not fail")
 				}
-				if err != ErrNotFound {
+				if !errors.Is(err, ErrNotFound) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrNotFound, err)
 				}
 				mockctl.Finish()
@@ -574,7 +875,7 @@ func TestDescribeFileSystem(t *testing.T) {
 				if err == nil {
 					t.Fatalf("DescribeFileSystem did not fail")
 				}
-				if err != ErrAccessDenied {
+				if !errors.Is(err, ErrAccessDenied) {
 					t.Fatalf("Failed. Expected: %v, Actual:%v", ErrAccessDenied, err)
 				}
 				mockctl.Finish()
@@ -688,12 +989,12 @@ func TestDescribeMountTargets(t *testing.T) {
 		{
 			name:        "Fail: File System Not Found",
 			mockError:   awserr.New(efs.ErrCodeFileSystemNotFound, "File system not found", errors.New("File system not found")),
-			expectError: errtyp{message: "Resource was not found"},
+			expectError: errtyp{message: "Describe Mount Targets failed: FileSystemNotFound: File system not found\ncaused by: File system not found"},
 		},
 		{
 			name:        "Fail: Access Denied",
 			mockError:   awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")),
-			expectError: errtyp{message: "Access denied"},
+			expectError: errtyp{message: "Describe Mount Targets failed: AccessDeniedException: Access Denied\ncaused by: Access Denied"},
 		},
 		{
 			name:        "Fail: Other",
@@ -725,6 +1026,189 @@ func TestDescribeMountTargets(t *testing.T) {
 	}
 }
 
+func TestStartBackupJob(t *testing.T) {
+	var (
+		fsArn         = "arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/fs-abcd1234"
+		recoveryPoint = "arn:aws:backup:us-east-1:123456789012:recovery-point:abcd"
+		backupJobOpts = &BackupJobOptions{
+			BackupVaultName:  "Default",
+			IamRoleArn:       "arn:aws:iam::123456789012:role/backup",
+			ResourceArn:      fsArn,
+			IdempotencyToken: "snapshot-1",
+			Tags:             map[string]string{"efs.csi.aws.com/source-path": "/dir"},
+		}
+	)
+	testCases := []struct {
+		name          string
+		mockOutput    *backup.StartBackupJobOutput
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "Success",
+			mockOutput: &backup.StartBackupJobOutput{
+				BackupJobId:      aws.String("job"),
+				RecoveryPointArn: aws.String(recoveryPoint),
+			},
+		},
+		{
+			name:          "Fail: Backup vault not found",
+			mockError:     awserr.New(backup.ErrCodeResourceNotFoundException, "Vault not found", errors.New("Vault not found")),
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "Fail: Idempotency token reused",
+			mockError:     awserr.New(backup.ErrCodeAlreadyExistsException, "Already exists", errors.New("Already exists")),
+			expectedError: ErrAlreadyExists,
+		},
+		{
+			name:          "Fail: Access Denied",
+			mockError:     awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")),
+			expectedError: ErrAccessDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockctl := gomock.NewController(t)
+			mockBackup := mocks.NewMockBackup(mockctl)
+			c := &cloud{backup: mockBackup, recoveryPointTags: newTTLCache(recoveryPointTagsTTL)}
+
+			ctx := context.Background()
+			mockBackup.EXPECT().StartBackupJobWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, input *backup.StartBackupJobInput, opts ...request.Option) (*backup.StartBackupJobOutput, error) {
+					if aws.StringValue(input.IdempotencyToken) != backupJobOpts.IdempotencyToken {
+						t.Fatalf("IdempotencyToken mismatched. Expected: %v, Actual: %v", backupJobOpts.IdempotencyToken, aws.StringValue(input.IdempotencyToken))
+					}
+					if aws.StringValue(input.RecoveryPointTags["efs.csi.aws.com/source-path"]) != "/dir" {
+						t.Fatalf("Unexpected recovery point tags: %v", input.RecoveryPointTags)
+					}
+					return tc.mockOutput, tc.mockError
+				})
+
+			res, err := c.StartBackupJob(ctx, backupJobOpts)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedError, err)
+			}
+			if err == nil && res.RecoveryPointArn != recoveryPoint {
+				t.Fatalf("RecoveryPointArn mismatched. Expected: %v, Actual: %v", recoveryPoint, res.RecoveryPointArn)
+			}
+			mockctl.Finish()
+		})
+	}
+}
+
+func TestListRecoveryPoints(t *testing.T) {
+	var (
+		vault         = "Default"
+		fsArn         = "arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/fs-abcd1234"
+		recoveryPoint = "arn:aws:backup:us-east-1:123456789012:recovery-point:abcd"
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Recovery points are returned with their tags",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockBackup := mocks.NewMockBackup(mockctl)
+				c := &cloud{backup: mockBackup, recoveryPointTags: newTTLCache(recoveryPointTagsTTL)}
+
+				ctx := context.Background()
+				output := &backup.ListRecoveryPointsByBackupVaultOutput{
+					RecoveryPoints: []*backup.RecoveryPointByBackupVault{
+						{
+							RecoveryPointArn: aws.String(recoveryPoint),
+							BackupVaultName:  aws.String(vault),
+							ResourceArn:      aws.String(fsArn),
+							Status:           aws.String(backup.RecoveryPointStatusCompleted),
+						},
+					},
+					NextToken: aws.String("next"),
+				}
+				mockBackup.EXPECT().ListRecoveryPointsByBackupVaultWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, input *backup.ListRecoveryPointsByBackupVaultInput, opts ...request.Option) (*backup.ListRecoveryPointsByBackupVaultOutput, error) {
+						if aws.StringValue(input.ByResourceArn) != fsArn || aws.Int64Value(input.MaxResults) != 1 {
+							t.Fatalf("Unexpected input: %v", input)
+						}
+						return output, nil
+					})
+				mockBackup.EXPECT().ListTagsWithContext(gomock.Eq(ctx), gomock.Any()).Return(&backup.ListTagsOutput{
+					Tags: map[string]*string{"efs.csi.aws.com/cluster": aws.String("true")},
+				}, nil)
+
+				res, next, err := c.ListRecoveryPoints(ctx, vault, fsArn, "", 1)
+				if err != nil {
+					t.Fatalf("ListRecoveryPoints failed: %v", err)
+				}
+				if len(res) != 1 || res[0].Tags["efs.csi.aws.com/cluster"] != "true" {
+					t.Fatalf("Unexpected recovery points: %+v", res)
+				}
+				if next != "next" {
+					t.Fatalf("Next token mismatched. Expected: next, Actual: %v", next)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Success: Tags of recovery points are fetched once",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockBackup := mocks.NewMockBackup(mockctl)
+				c := &cloud{backup: mockBackup, recoveryPointTags: newTTLCache(recoveryPointTagsTTL)}
+
+				ctx := context.Background()
+				untagged := "arn:aws:backup:us-east-1:123456789012:recovery-point:efgh"
+				output := &backup.ListRecoveryPointsByBackupVaultOutput{
+					RecoveryPoints: []*backup.RecoveryPointByBackupVault{
+						{RecoveryPointArn: aws.String(recoveryPoint)},
+						{RecoveryPointArn: aws.String(untagged)},
+					},
+				}
+				mockBackup.EXPECT().ListRecoveryPointsByBackupVaultWithContext(gomock.Eq(ctx), gomock.Any()).Return(output, nil).Times(2)
+				mockBackup.EXPECT().ListTagsWithContext(gomock.Eq(ctx), gomock.Eq(&backup.ListTagsInput{ResourceArn: aws.String(recoveryPoint)})).Return(&backup.ListTagsOutput{
+					Tags: map[string]*string{"efs.csi.aws.com/cluster": aws.String("true")},
+				}, nil).Times(1)
+				// Tags may not have been applied yet to a recovery point without tags
+				mockBackup.EXPECT().ListTagsWithContext(gomock.Eq(ctx), gomock.Eq(&backup.ListTagsInput{ResourceArn: aws.String(untagged)})).Return(&backup.ListTagsOutput{}, nil).Times(2)
+
+				for i := 0; i < 2; i++ {
+					res, _, err := c.ListRecoveryPoints(ctx, vault, "", "", 0)
+					if err != nil {
+						t.Fatalf("ListRecoveryPoints failed: %v", err)
+					}
+					if len(res) != 2 || res[0].Tags["efs.csi.aws.com/cluster"] != "true" {
+						t.Fatalf("Unexpected recovery points: %+v", res)
+					}
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Invalid token",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockBackup := mocks.NewMockBackup(mockctl)
+				c := &cloud{backup: mockBackup, recoveryPointTags: newTTLCache(recoveryPointTagsTTL)}
+
+				ctx := context.Background()
+				mockBackup.EXPECT().ListRecoveryPointsByBackupVaultWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(backup.ErrCodeInvalidParameterValueException, "Invalid token", errors.New("Invalid token")))
+
+				_, _, err := c.ListRecoveryPoints(ctx, vault, "", "invalid", 0)
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Expected error %v, got %v", ErrInvalidToken, err)
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
 func testResult(t *testing.T, funcName string, ret interface{}, err error, expectError errtyp) {
 	if expectError.message == "" {
 		if err != nil {
@@ -743,3 +1227,302 @@ func testResult(t *testing.T, funcName string, ret interface{}, err error, expec
 		}
 	}
 }
+
+func TestCreateFileSystem(t *testing.T) {
+	var (
+		fsId       = "fs-abcd1234"
+		volumeName = "volumeName"
+		kmsKeyId   = "arn:aws:kms:us-east-1:1234567890:key/abcd"
+		fsOpts     = &FileSystemOptions{
+			PerformanceMode:              efs.PerformanceModeMaxIo,
+			ThroughputMode:               efs.ThroughputModeProvisioned,
+			ProvisionedThroughputInMibps: 128,
+			Encrypted:                    true,
+			KmsKeyId:                     kmsKeyId,
+			Tags:                         map[string]string{"cluster": "efs"},
+		}
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(&efs.DescribeFileSystemsOutput{}, nil)
+				mockEfs.EXPECT().CreateFileSystemWithContext(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, input *efs.CreateFileSystemInput, opts ...request.Option) (*efs.FileSystemDescription, error) {
+						if aws.StringValue(input.CreationToken) != volumeName {
+							t.Fatalf("CreationToken mismatched. Expected: %v, Actual: %v", volumeName, aws.StringValue(input.CreationToken))
+						}
+						if aws.StringValue(input.KmsKeyId) != kmsKeyId {
+							t.Fatalf("KmsKeyId mismatched. Expected: %v, Actual: %v", kmsKeyId, aws.StringValue(input.KmsKeyId))
+						}
+						if aws.Float64Value(input.ProvisionedThroughputInMibps) != 128 {
+							t.Fatalf("ProvisionedThroughputInMibps mismatched. Expected: 128, Actual: %v", aws.Float64Value(input.ProvisionedThroughputInMibps))
+						}
+						return &efs.FileSystemDescription{
+							FileSystemId:   aws.String(fsId),
+							LifeCycleState: aws.String(efs.LifeCycleStateCreating),
+							Tags:           input.Tags,
+						}, nil
+					})
+
+				res, err := c.CreateFileSystem(ctx, volumeName, fsOpts)
+				if err != nil {
+					t.Fatalf("CreateFileSystem failed: %v", err)
+				}
+				if res.FileSystemId != fsId {
+					t.Fatalf("FileSystemId mismatched. Expected: %v, Actual: %v", fsId, res.FileSystemId)
+				}
+				if res.LifeCycleState != efs.LifeCycleStateCreating {
+					t.Fatalf("LifeCycleState mismatched. Expected: %v, Actual: %v", efs.LifeCycleStateCreating, res.LifeCycleState)
+				}
+				if res.Tags["cluster"] != "efs" {
+					t.Fatalf("Tags mismatched. Expected: %v, Actual: %v", fsOpts.Tags, res.Tags)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Success: File system with the same creation token already exists",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				output := &efs.DescribeFileSystemsOutput{
+					FileSystems: []*efs.FileSystemDescription{
+						{
+							CreationToken:  aws.String(volumeName),
+							FileSystemId:   aws.String(fsId),
+							LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+						},
+					},
+				}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(output, nil)
+				res, err := c.CreateFileSystem(ctx, volumeName, fsOpts)
+				if err != nil {
+					t.Fatalf("CreateFileSystem failed: %v", err)
+				}
+				if res.FileSystemId != fsId {
+					t.Fatalf("FileSystemId mismatched. Expected: %v, Actual: %v", fsId, res.FileSystemId)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access Denied",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(&efs.DescribeFileSystemsOutput{}, nil)
+				mockEfs.EXPECT().CreateFileSystemWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")))
+				_, err := c.CreateFileSystem(ctx, volumeName, fsOpts)
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Expected error %v, got %v", ErrAccessDenied, err)
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: Other",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeFileSystemsWithContext(gomock.Eq(ctx), gomock.Any()).Return(&efs.DescribeFileSystemsOutput{}, nil)
+				mockEfs.EXPECT().CreateFileSystemWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, errors.New("CreateFileSystemWithContext failed"))
+				_, err := c.CreateFileSystem(ctx, volumeName, fsOpts)
+				if err == nil {
+					t.Fatalf("CreateFileSystem did not fail")
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestDeleteFileSystem(t *testing.T) {
+	var (
+		fsId = "fs-abcd1234"
+	)
+	testCases := []struct {
+		name          string
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "Success",
+		},
+		{
+			name:          "Fail: File System not found",
+			mockError:     awserr.New(efs.ErrCodeFileSystemNotFound, "File System not found", errors.New("File System not found")),
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "Fail: Access Denied",
+			mockError:     awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")),
+			expectedError: ErrAccessDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockctl := gomock.NewController(t)
+			mockEfs := mocks.NewMockEfs(mockctl)
+			c := &cloud{efs: mockEfs}
+
+			ctx := context.Background()
+			mockEfs.EXPECT().DeleteFileSystemWithContext(gomock.Eq(ctx), gomock.Any()).Return(&efs.DeleteFileSystemOutput{}, tc.mockError)
+			err := c.DeleteFileSystem(ctx, fsId)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedError, err)
+			}
+			mockctl.Finish()
+		})
+	}
+}
+
+func TestListMountTargets(t *testing.T) {
+	var (
+		fsId = "fs-abcd1234"
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: All pages are read",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				first := &efs.DescribeMountTargetsOutput{
+					MountTargets: []*efs.MountTargetDescription{
+						{
+							MountTargetId:  aws.String("fsmt-abcd1234"),
+							SubnetId:       aws.String("subnet-1"),
+							LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+						},
+					},
+					NextMarker: aws.String("marker"),
+				}
+				second := &efs.DescribeMountTargetsOutput{
+					MountTargets: []*efs.MountTargetDescription{
+						{
+							MountTargetId:  aws.String("fsmt-efgh5678"),
+							SubnetId:       aws.String("subnet-2"),
+							LifeCycleState: aws.String(efs.LifeCycleStateCreating),
+						},
+					},
+				}
+				gomock.InOrder(
+					mockEfs.EXPECT().DescribeMountTargetsWithContext(gomock.Eq(ctx), gomock.Any()).Return(first, nil),
+					mockEfs.EXPECT().DescribeMountTargetsWithContext(gomock.Eq(ctx), gomock.Any()).Return(second, nil),
+				)
+
+				res, err := c.ListMountTargets(ctx, fsId)
+				if err != nil {
+					t.Fatalf("ListMountTargets failed: %v", err)
+				}
+				if len(res) != 2 {
+					t.Fatalf("Expected 2 mount targets, got %d", len(res))
+				}
+				if res[1].SubnetId != "subnet-2" || res[1].LifeCycleState != efs.LifeCycleStateCreating {
+					t.Fatalf("Mount target mismatched: %+v", res[1])
+				}
+				mockctl.Finish()
+			},
+		},
+		{
+			name: "Fail: File System not found",
+			testFunc: func(t *testing.T) {
+				mockctl := gomock.NewController(t)
+				mockEfs := mocks.NewMockEfs(mockctl)
+				c := &cloud{efs: mockEfs}
+
+				ctx := context.Background()
+				mockEfs.EXPECT().DescribeMountTargetsWithContext(gomock.Eq(ctx), gomock.Any()).Return(nil, awserr.New(efs.ErrCodeFileSystemNotFound, "File System not found", errors.New("File System not found")))
+				_, err := c.ListMountTargets(ctx, fsId)
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Expected error %v, got %v", ErrNotFound, err)
+				}
+				mockctl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestCreateMountTarget(t *testing.T) {
+	var (
+		fsId     = "fs-abcd1234"
+		subnetId = "subnet-1"
+	)
+	testCases := []struct {
+		name          string
+		mockOutput    *efs.MountTargetDescription
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "Success",
+			mockOutput: &efs.MountTargetDescription{
+				MountTargetId:  aws.String("fsmt-abcd1234"),
+				SubnetId:       aws.String(subnetId),
+				LifeCycleState: aws.String(efs.LifeCycleStateCreating),
+			},
+		},
+		{
+			name:          "Fail: Mount target conflict",
+			mockError:     awserr.New(efs.ErrCodeMountTargetConflict, "Mount target conflict", errors.New("Mount target conflict")),
+			expectedError: ErrAlreadyExists,
+		},
+		{
+			name:          "Fail: Access Denied",
+			mockError:     awserr.New(AccessDeniedException, "Access Denied", errors.New("Access Denied")),
+			expectedError: ErrAccessDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockctl := gomock.NewController(t)
+			mockEfs := mocks.NewMockEfs(mockctl)
+			c := &cloud{efs: mockEfs}
+
+			ctx := context.Background()
+			mockEfs.EXPECT().CreateMountTargetWithContext(gomock.Eq(ctx), gomock.Any()).Return(tc.mockOutput, tc.mockError)
+			res, err := c.CreateMountTarget(ctx, fsId, subnetId, []string{"sg-1"})
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedError, err)
+			}
+			if err == nil && res.SubnetId != subnetId {
+				t.Fatalf("SubnetId mismatched. Expected: %v, Actual: %v", subnetId, res.SubnetId)
+			}
+			mockctl.Finish()
+		})
+	}
+}
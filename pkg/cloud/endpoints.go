@@ -0,0 +1,74 @@
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// EndpointOptions configure the endpoints the clients of the AWS APIs call.
+type EndpointOptions struct {
+	// UseFIPS makes clients call the FIPS 140-2 validated endpoints of the services
+	UseFIPS bool
+	// UseDualStack makes clients call the endpoints of the services that serve both IPv4 and IPv6
+	UseDualStack bool
+	// EfsEndpoint is the URL of the EFS API, e.g. of a local EFS emulator, overriding the endpoint of the region
+	EfsEndpoint string
+}
+
+var (
+	endpointMu      sync.Mutex
+	endpointOptions EndpointOptions
+)
+
+// SetEndpointOptions sets the endpoints the clouds created afterwards call.
+func SetEndpointOptions(options EndpointOptions) error {
+	if options.EfsEndpoint != "" {
+		u, err := url.Parse(options.EfsEndpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("EFS endpoint must be an absolute URL, got %q", options.EfsEndpoint)
+		}
+	}
+
+	endpointMu.Lock()
+	defer endpointMu.Unlock()
+	endpointOptions = options
+	return nil
+}
+
+func getEndpointOptions() EndpointOptions {
+	endpointMu.Lock()
+	defer endpointMu.Unlock()
+	return endpointOptions
+}
+
+// withEndpoints makes the clients created with config resolve the endpoints of the services as configured.
+func withEndpoints(config *aws.Config) *aws.Config {
+	options := getEndpointOptions()
+	if options.UseFIPS {
+		config.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
+	if options.UseDualStack {
+		config.UseDualStackEndpoint = endpoints.DualStackEndpointStateEnabled
+	}
+	return config
+}
+
+// withRegionEndpoints returns a copy of sess whose clients call the endpoints of the region as configured. Roles are
+// assumed with the STS client of the session, which must call the FIPS endpoint of STS too when FIPS is enabled.
+func withRegionEndpoints(sess *session.Session, region string) *session.Session {
+	return sess.Copy(withEndpoints(aws.NewConfig().WithRegion(region)))
+}
+
+// efsEndpointConfig returns the configuration of the EFS clients, overriding the endpoint of the region if set.
+func efsEndpointConfig() *aws.Config {
+	config := aws.NewConfig()
+	if endpoint := getEndpointOptions().EfsEndpoint; endpoint != "" {
+		config = config.WithEndpoint(endpoint)
+	}
+	return config
+}
@@ -0,0 +1,73 @@
+package cloud
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/backup"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestSetEndpointOptions(t *testing.T) {
+	defer SetEndpointOptions(EndpointOptions{})
+
+	testCases := []struct {
+		name                   string
+		options                EndpointOptions
+		expectedEfsEndpoint    string
+		expectedBackupEndpoint string
+		expectedStsEndpoint    string
+	}{
+		{
+			name:                   "Success: Regional endpoints",
+			expectedEfsEndpoint:    "https://elasticfilesystem.us-east-1.amazonaws.com",
+			expectedBackupEndpoint: "https://backup.us-east-1.amazonaws.com",
+			expectedStsEndpoint:    "https://sts.amazonaws.com",
+		},
+		{
+			name:                   "Success: FIPS endpoints",
+			options:                EndpointOptions{UseFIPS: true},
+			expectedEfsEndpoint:    "https://elasticfilesystem-fips.us-east-1.amazonaws.com",
+			expectedBackupEndpoint: "https://backup-fips.us-east-1.amazonaws.com",
+			expectedStsEndpoint:    "https://sts-fips.us-east-1.amazonaws.com",
+		},
+		{
+			name:                   "Success: Dual-stack endpoints",
+			options:                EndpointOptions{UseDualStack: true},
+			expectedEfsEndpoint:    "https://elasticfilesystem.us-east-1.api.aws",
+			expectedBackupEndpoint: "https://backup.us-east-1.api.aws",
+			expectedStsEndpoint:    "https://sts.us-east-1.api.aws",
+		},
+		{
+			name:                   "Success: Custom EFS endpoint",
+			options:                EndpointOptions{EfsEndpoint: "http://localhost:4566"},
+			expectedEfsEndpoint:    "http://localhost:4566",
+			expectedBackupEndpoint: "https://backup.us-east-1.amazonaws.com",
+			expectedStsEndpoint:    "https://sts.amazonaws.com",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetEndpointOptions(tc.options); err != nil {
+				t.Fatalf("Failed to set endpoint options: %v", err)
+			}
+			c := newCloud(&metadata{"instanceID", "us-east-1", "us-east-1a"}, nil)
+			if endpoint := c.efs.(*efs.EFS).Client.ClientInfo.Endpoint; endpoint != tc.expectedEfsEndpoint {
+				t.Fatalf("Expected EFS endpoint %v, got %v", tc.expectedEfsEndpoint, endpoint)
+			}
+			if endpoint := c.backup.(*backup.Backup).Client.ClientInfo.Endpoint; endpoint != tc.expectedBackupEndpoint {
+				t.Fatalf("Expected Backup endpoint %v, got %v", tc.expectedBackupEndpoint, endpoint)
+			}
+			sess := withRegionEndpoints(session.Must(session.NewSession()), "us-east-1")
+			if endpoint := sts.New(sess).Client.ClientInfo.Endpoint; endpoint != tc.expectedStsEndpoint {
+				t.Fatalf("Expected STS endpoint %v, got %v", tc.expectedStsEndpoint, endpoint)
+			}
+		})
+	}
+
+	if err := SetEndpointOptions(EndpointOptions{EfsEndpoint: "localhost:4566"}); err == nil {
+		t.Fatal("Expected EFS endpoint without scheme to be invalid")
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/backup"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+// Kinds of errors returned by Cloud, to be checked with errors.Is. Some kinds are refinements of others, e.g. an
+// ErrAccessPointLimitExceeded error also is an ErrLimitExceeded error.
+var (
+	ErrNotFound      = errors.New("Resource was not found")
+	ErrAlreadyExists = errors.New("Resource already exists")
+	ErrAccessDenied  = errors.New("Access denied")
+	ErrInvalidToken  = errors.New("Invalid pagination token")
+	// ErrThrottled is returned when the request was rejected because too many requests were made.
+	ErrThrottled = errors.New("Request was throttled")
+	// ErrUnavailable is returned when the request failed for a transient reason and can be retried as is.
+	ErrUnavailable = errors.New("Service is unavailable")
+	// ErrLimitExceeded is returned when the request would exceed a quota of the account or the resource.
+	ErrLimitExceeded                 = errors.New("Limit exceeded")
+	ErrAccessPointLimitExceeded      = errors.New("Access point limit of the file system exceeded")
+	ErrFileSystemLimitExceeded       = errors.New("File system limit of the account exceeded")
+	ErrNetworkInterfaceLimitExceeded = errors.New("Network interface limit of the account exceeded")
+	// ErrIncorrectLifeCycleState is returned when the resource is not in a state the request can be made in, e.g. a
+	// file system still being created.
+	ErrIncorrectLifeCycleState = errors.New("Resource is in an incorrect life cycle state")
+	// ErrFileSystemInUse is returned when deleting a file system that still has mount targets.
+	ErrFileSystemInUse = errors.New("File system is in use")
+	// ErrMountTargetConflict is returned when the file system already has a mount target in the availability zone.
+	ErrMountTargetConflict = errors.New("Mount target already exists in the availability zone")
+)
+
+// errorParents are the more general kinds of each kind of error.
+var errorParents = map[error]error{
+	ErrAccessPointLimitExceeded:      ErrLimitExceeded,
+	ErrFileSystemLimitExceeded:       ErrLimitExceeded,
+	ErrNetworkInterfaceLimitExceeded: ErrLimitExceeded,
+	ErrMountTargetConflict:           ErrAlreadyExists,
+}
+
+// errorKinds are the kinds of the error codes of the AWS APIs. Errors with other codes have no kind.
+var errorKinds = map[string]error{
+	AccessDeniedException:         ErrAccessDenied,
+	"ExpiredToken":                ErrAccessDenied,
+	"ExpiredTokenException":       ErrAccessDenied,
+	"InvalidClientTokenId":        ErrAccessDenied,
+	"UnrecognizedClientException": ErrAccessDenied,
+
+	efs.ErrCodeFileSystemNotFound:           ErrNotFound,
+	efs.ErrCodeAccessPointNotFound:          ErrNotFound,
+	efs.ErrCodeMountTargetNotFound:          ErrNotFound,
+	backup.ErrCodeResourceNotFoundException: ErrNotFound,
+
+	efs.ErrCodeFileSystemAlreadyExists:   ErrAlreadyExists,
+	efs.ErrCodeAccessPointAlreadyExists:  ErrAlreadyExists,
+	backup.ErrCodeAlreadyExistsException: ErrAlreadyExists,
+	efs.ErrCodeMountTargetConflict:       ErrMountTargetConflict,
+
+	efs.ErrCodeIncorrectFileSystemLifeCycleState: ErrIncorrectLifeCycleState,
+	efs.ErrCodeIncorrectMountTargetState:         ErrIncorrectLifeCycleState,
+	backup.ErrCodeInvalidResourceStateException:  ErrIncorrectLifeCycleState,
+	efs.ErrCodeFileSystemInUse:                   ErrFileSystemInUse,
+
+	efs.ErrCodeThrottlingException: ErrThrottled,
+	efs.ErrCodeTooManyRequests:     ErrThrottled,
+
+	efs.ErrCodeInternalServerError:            ErrUnavailable,
+	efs.ErrCodeDependencyTimeout:              ErrUnavailable,
+	backup.ErrCodeServiceUnavailableException: ErrUnavailable,
+	backup.ErrCodeDependencyFailureException:  ErrUnavailable,
+
+	efs.ErrCodeAccessPointLimitExceeded:       ErrAccessPointLimitExceeded,
+	efs.ErrCodeFileSystemLimitExceeded:        ErrFileSystemLimitExceeded,
+	efs.ErrCodeNetworkInterfaceLimitExceeded:  ErrNetworkInterfaceLimitExceeded,
+	efs.ErrCodeNoFreeAddressesInSubnet:        ErrLimitExceeded,
+	efs.ErrCodeSecurityGroupLimitExceeded:     ErrLimitExceeded,
+	efs.ErrCodeThroughputLimitExceeded:        ErrLimitExceeded,
+	efs.ErrCodeInsufficientThroughputCapacity: ErrLimitExceeded,
+	backup.ErrCodeLimitExceededException:      ErrLimitExceeded,
+}
+
+// Error is an error of a call to the AWS APIs. It wraps the original error, which is usually an awserr.Error, and is
+// of the kind of the original error, if any.
+type Error struct {
+	// Kind is the kind of the error, or nil if the error is of no known kind
+	Kind error
+	// Op describes the operation that failed
+	Op  string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of the kind target, or of a refinement of it.
+func (e *Error) Is(target error) bool {
+	for kind := e.Kind; kind != nil; kind = errorParents[kind] {
+		if kind == target {
+			return true
+		}
+	}
+	return false
+}
+
+// newError returns an error of the given kind wrapping err.
+func newError(kind, err error, format string, args ...interface{}) error {
+	return &Error{
+		Kind: kind,
+		Op:   fmt.Sprintf(format, args...),
+		Err:  err,
+	}
+}
+
+// wrapError returns an error wrapping err, of the kind of its AWS error code.
+func wrapError(err error, format string, args ...interface{}) error {
+	return newError(errorKind(err), err, format, args...)
+}
+
+func errorKind(err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return nil
+	}
+	if kind, ok := errorKinds[awsErr.Code()]; ok {
+		return kind
+	}
+	if request.IsErrorThrottle(awsErr) {
+		return ErrThrottled
+	}
+	if request.IsErrorRetryable(awsErr) {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// hasErrorCode reports whether err is an AWS error with the given code.
+func hasErrorCode(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
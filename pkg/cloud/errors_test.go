@@ -0,0 +1,101 @@
+package cloud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/backup"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+func TestWrapError(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedKinds []error
+		excludedKinds []error
+	}{
+		{
+			name:          "Access point limit exceeded is a limit exceeded error",
+			err:           awserr.New(efs.ErrCodeAccessPointLimitExceeded, "Too many access points", nil),
+			expectedKinds: []error{ErrAccessPointLimitExceeded, ErrLimitExceeded},
+			excludedKinds: []error{ErrFileSystemLimitExceeded, ErrThrottled},
+		},
+		{
+			name:          "Network interface limit exceeded is a limit exceeded error",
+			err:           awserr.New(efs.ErrCodeNetworkInterfaceLimitExceeded, "Too many network interfaces", nil),
+			expectedKinds: []error{ErrNetworkInterfaceLimitExceeded, ErrLimitExceeded},
+		},
+		{
+			name:          "Mount target conflict is an already exists error",
+			err:           awserr.New(efs.ErrCodeMountTargetConflict, "Mount target conflict", nil),
+			expectedKinds: []error{ErrMountTargetConflict, ErrAlreadyExists},
+		},
+		{
+			name:          "Incorrect file system life cycle state",
+			err:           awserr.New(efs.ErrCodeIncorrectFileSystemLifeCycleState, "File system is creating", nil),
+			expectedKinds: []error{ErrIncorrectLifeCycleState},
+		},
+		{
+			name:          "Throttling exception",
+			err:           awserr.New(efs.ErrCodeThrottlingException, "Rate exceeded", nil),
+			expectedKinds: []error{ErrThrottled},
+		},
+		{
+			name:          "Throttling code of the SDK",
+			err:           awserr.New("RequestLimitExceeded", "Rate exceeded", nil),
+			expectedKinds: []error{ErrThrottled},
+		},
+		{
+			name:          "Backup service unavailable",
+			err:           awserr.New(backup.ErrCodeServiceUnavailableException, "Unavailable", nil),
+			expectedKinds: []error{ErrUnavailable},
+		},
+		{
+			name:          "Unknown code",
+			err:           awserr.New("SomethingElse", "Something else", nil),
+			excludedKinds: []error{ErrNotFound, ErrLimitExceeded, ErrThrottled, ErrUnavailable},
+		},
+		{
+			name:          "Not an AWS error",
+			err:           errors.New("failed"),
+			excludedKinds: []error{ErrNotFound, ErrAccessDenied},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := wrapError(tc.err, "Failed to %v", "operate")
+			for _, kind := range tc.expectedKinds {
+				if !errors.Is(err, kind) {
+					t.Fatalf("Expected error %v to be %v", err, kind)
+				}
+			}
+			for _, kind := range tc.excludedKinds {
+				if errors.Is(err, kind) {
+					t.Fatalf("Expected error %v not to be %v", err, kind)
+				}
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected error %v to wrap %v", err, tc.err)
+			}
+			if expected := "Failed to operate: " + tc.err.Error(); err.Error() != expected {
+				t.Fatalf("Expected message %q, got %q", expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestWrapError_As(t *testing.T) {
+	err := wrapError(awserr.New(efs.ErrCodeFileSystemNotFound, "File system not found", nil), "Describe File System failed")
+
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != efs.ErrCodeFileSystemNotFound {
+		t.Fatalf("Expected error %v to wrap an AWS error with code %v", err, efs.ErrCodeFileSystemNotFound)
+	}
+	var cloudErr *Error
+	if !errors.As(err, &cloudErr) || cloudErr.Kind != ErrNotFound {
+		t.Fatalf("Expected error %v to be a cloud error of kind %v", err, ErrNotFound)
+	}
+}
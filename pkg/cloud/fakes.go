@@ -4,22 +4,31 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"reflect"
+	"strconv"
 	"time"
 )
 
 type FakeCloudProvider struct {
-	m            *metadata
-	fileSystems  map[string]*FileSystem
-	accessPoints map[string]*AccessPoint
-	mountTargets map[string]*MountTarget
+	m              *metadata
+	fileSystems    map[string]*FileSystem
+	accessPoints   map[string]*AccessPoint
+	mountTargets   map[string]*MountTarget
+	creationTokens map[string]string
+	backupJobs     map[string]*BackupJob
+	backupTokens   map[string]string
+	recoveryPoints []*RecoveryPoint
 }
 
 func NewFakeCloudProvider() *FakeCloudProvider {
 	return &FakeCloudProvider{
-		m:            &metadata{"instanceID", "region", "az"},
-		fileSystems:  make(map[string]*FileSystem),
-		accessPoints: make(map[string]*AccessPoint),
-		mountTargets: make(map[string]*MountTarget),
+		m:              &metadata{"instanceID", "region", "az"},
+		fileSystems:    make(map[string]*FileSystem),
+		accessPoints:   make(map[string]*AccessPoint),
+		mountTargets:   make(map[string]*MountTarget),
+		creationTokens: make(map[string]string),
+		backupJobs:     make(map[string]*BackupJob),
+		backupTokens:   make(map[string]string),
 	}
 }
 
@@ -40,9 +49,15 @@ func (c *FakeCloudProvider) CreateAccessPoint(ctx context.Context, volumeName st
 	apId := fmt.Sprintf("fsap-%d", r.Uint64())
 	fsId := accessPointOpts.FileSystemId
 	ap = &AccessPoint{
-		AccessPointId: apId,
-		FileSystemId:  fsId,
-		CapacityGiB:   accessPointOpts.CapacityGiB,
+		AccessPointId:      apId,
+		FileSystemId:       fsId,
+		AccessPointRootDir: accessPointOpts.DirectoryPath,
+		CapacityGiB:        accessPointOpts.CapacityGiB,
+		PosixUser: &PosixUser{
+			Uid: accessPointOpts.Uid,
+			Gid: accessPointOpts.Gid,
+		},
+		Tags: accessPointOpts.Tags,
 	}
 
 	c.accessPoints[volumeName] = ap
@@ -67,6 +82,45 @@ func (c *FakeCloudProvider) DescribeAccessPoint(ctx context.Context, accessPoint
 	return nil, ErrNotFound
 }
 
+func (c *FakeCloudProvider) TagAccessPoint(ctx context.Context, accessPointId string, tags map[string]string) (err error) {
+	for _, ap := range c.accessPoints {
+		if ap.AccessPointId == accessPointId {
+			apTags := make(map[string]string, len(ap.Tags)+len(tags))
+			for k, v := range ap.Tags {
+				apTags[k] = v
+			}
+			for k, v := range tags {
+				apTags[k] = v
+			}
+			ap.Tags = apTags
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (c *FakeCloudProvider) ListAccessPoints(ctx context.Context, fileSystemId string) (accessPoints []*AccessPoint, err error) {
+	for _, ap := range c.accessPoints {
+		if ap.FileSystemId == fileSystemId {
+			accessPoints = append(accessPoints, ap)
+		}
+	}
+	return accessPoints, nil
+}
+
+// DescribeAccessPoints returns all matching access points in a single page.
+func (c *FakeCloudProvider) DescribeAccessPoints(ctx context.Context, fileSystemId, nextToken string, maxResults int64) (accessPoints []*AccessPoint, next string, err error) {
+	if nextToken != "" {
+		return nil, "", ErrInvalidToken
+	}
+	for _, ap := range c.accessPoints {
+		if fileSystemId == "" || ap.FileSystemId == fileSystemId {
+			accessPoints = append(accessPoints, ap)
+		}
+	}
+	return accessPoints, "", nil
+}
+
 // CreateVolume calls DescribeFileSystem and then CreateAccessPoint.
 // Add file system into the map here to allow CreateVolume sanity tests to succeed.
 func (c *FakeCloudProvider) DescribeFileSystem(ctx context.Context, fileSystemId string) (fileSystem *FileSystem, err error) {
@@ -75,7 +129,9 @@ func (c *FakeCloudProvider) DescribeFileSystem(ctx context.Context, fileSystemId
 	}
 
 	fs := &FileSystem{
-		FileSystemId: fileSystemId,
+		FileSystemId:   fileSystemId,
+		FileSystemArn:  fakeFileSystemArn(fileSystemId),
+		LifeCycleState: "available",
 	}
 	c.fileSystems[fileSystemId] = fs
 
@@ -90,6 +146,13 @@ func (c *FakeCloudProvider) DescribeFileSystem(ctx context.Context, fileSystemId
 	return fs, nil
 }
 
+func (c *FakeCloudProvider) ListFileSystems(ctx context.Context) (fileSystems []*FileSystem, err error) {
+	for _, fs := range c.fileSystems {
+		fileSystems = append(fileSystems, fs)
+	}
+	return fileSystems, nil
+}
+
 func (c *FakeCloudProvider) DescribeMountTargets(ctx context.Context, fileSystemId, az string) (mountTarget *MountTarget, err error) {
 	if mt, ok := c.mountTargets[fileSystemId]; ok {
 		return mt, nil
@@ -97,3 +160,178 @@ func (c *FakeCloudProvider) DescribeMountTargets(ctx context.Context, fileSystem
 
 	return nil, ErrNotFound
 }
+
+func (c *FakeCloudProvider) ListMountTargets(ctx context.Context, fileSystemId string) (mountTargets []*MountTarget, err error) {
+	if _, ok := c.fileSystems[fileSystemId]; !ok {
+		return nil, ErrNotFound
+	}
+	if mt, ok := c.mountTargets[fileSystemId]; ok {
+		mountTargets = append(mountTargets, mt)
+	}
+	return mountTargets, nil
+}
+
+func (c *FakeCloudProvider) CreateFileSystem(ctx context.Context, volumeName string, fileSystemOpts *FileSystemOptions) (fileSystem *FileSystem, err error) {
+	if fsId, ok := c.creationTokens[volumeName]; ok {
+		if fs, ok := c.fileSystems[fsId]; ok {
+			return fs, nil
+		}
+	}
+
+	fsId := fmt.Sprintf("fs-%d", rand.Int31())
+	fileSystem = &FileSystem{
+		FileSystemId:   fsId,
+		FileSystemArn:  fakeFileSystemArn(fsId),
+		LifeCycleState: "available",
+		Tags:           fileSystemOpts.Tags,
+	}
+	c.fileSystems[fsId] = fileSystem
+	c.creationTokens[volumeName] = fsId
+	return fileSystem, nil
+}
+
+func (c *FakeCloudProvider) DeleteFileSystem(ctx context.Context, fileSystemId string) (err error) {
+	if _, ok := c.fileSystems[fileSystemId]; !ok {
+		return ErrNotFound
+	}
+	delete(c.fileSystems, fileSystemId)
+	delete(c.mountTargets, fileSystemId)
+	return nil
+}
+
+func (c *FakeCloudProvider) PutLifecycleConfiguration(ctx context.Context, fileSystemId, transitionToIA string) (err error) {
+	if _, ok := c.fileSystems[fileSystemId]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (c *FakeCloudProvider) CreateMountTarget(ctx context.Context, fileSystemId, subnetId string, securityGroups []string) (mountTarget *MountTarget, err error) {
+	if _, ok := c.fileSystems[fileSystemId]; !ok {
+		return nil, ErrNotFound
+	}
+	if _, ok := c.mountTargets[fileSystemId]; ok {
+		return nil, ErrAlreadyExists
+	}
+
+	mountTarget = &MountTarget{
+		AZName:         "us-east-1a",
+		AZId:           "mock-AZ-id",
+		MountTargetId:  fmt.Sprintf("fsmt-%d", rand.Int31()),
+		IPAddress:      "127.0.0.1",
+		SubnetId:       subnetId,
+		LifeCycleState: "available",
+	}
+	c.mountTargets[fileSystemId] = mountTarget
+	return mountTarget, nil
+}
+
+func (c *FakeCloudProvider) DeleteMountTarget(ctx context.Context, mountTargetId string) (err error) {
+	for fsId, mt := range c.mountTargets {
+		if mt.MountTargetId == mountTargetId {
+			delete(c.mountTargets, fsId)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// StartBackupJob completes backup jobs immediately. Reusing an idempotency token with different parameters fails
+// with ErrAlreadyExists.
+func (c *FakeCloudProvider) StartBackupJob(ctx context.Context, backupJobOpts *BackupJobOptions) (backupJob *BackupJob, err error) {
+	if jobId, ok := c.backupTokens[backupJobOpts.IdempotencyToken]; ok {
+		backupJob = c.backupJobs[jobId]
+		for _, rp := range c.recoveryPoints {
+			if rp.RecoveryPointArn == backupJob.RecoveryPointArn {
+				if rp.BackupVaultName != backupJobOpts.BackupVaultName || rp.ResourceArn != backupJobOpts.ResourceArn || !reflect.DeepEqual(rp.Tags, backupJobOpts.Tags) {
+					return nil, ErrAlreadyExists
+				}
+			}
+		}
+		return backupJob, nil
+	}
+
+	jobId := fmt.Sprintf("%d", rand.Int31())
+	backupJob = &BackupJob{
+		BackupJobId:      jobId,
+		RecoveryPointArn: fmt.Sprintf("arn:aws:backup:us-east-1:123456789012:recovery-point:%s", jobId),
+		ResourceArn:      backupJobOpts.ResourceArn,
+		State:            "COMPLETED",
+		CreationDate:     time.Now(),
+	}
+	tags := make(map[string]string, len(backupJobOpts.Tags))
+	for k, v := range backupJobOpts.Tags {
+		tags[k] = v
+	}
+	c.backupJobs[jobId] = backupJob
+	c.backupTokens[backupJobOpts.IdempotencyToken] = jobId
+	c.recoveryPoints = append(c.recoveryPoints, &RecoveryPoint{
+		RecoveryPointArn: backupJob.RecoveryPointArn,
+		BackupVaultName:  backupJobOpts.BackupVaultName,
+		ResourceArn:      backupJobOpts.ResourceArn,
+		Status:           "COMPLETED",
+		CreationDate:     backupJob.CreationDate,
+		Tags:             tags,
+	})
+	return backupJob, nil
+}
+
+func (c *FakeCloudProvider) DescribeBackupJob(ctx context.Context, backupJobId string) (backupJob *BackupJob, err error) {
+	if job, ok := c.backupJobs[backupJobId]; ok {
+		return job, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (c *FakeCloudProvider) DescribeRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (recoveryPoint *RecoveryPoint, err error) {
+	for _, rp := range c.recoveryPoints {
+		if rp.BackupVaultName == backupVaultName && rp.RecoveryPointArn == recoveryPointArn {
+			return rp, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (c *FakeCloudProvider) DeleteRecoveryPoint(ctx context.Context, backupVaultName, recoveryPointArn string) (err error) {
+	for i, rp := range c.recoveryPoints {
+		if rp.BackupVaultName == backupVaultName && rp.RecoveryPointArn == recoveryPointArn {
+			c.recoveryPoints = append(c.recoveryPoints[:i], c.recoveryPoints[i+1:]...)
+			for token, jobId := range c.backupTokens {
+				if c.backupJobs[jobId].RecoveryPointArn == recoveryPointArn {
+					delete(c.backupTokens, token)
+					delete(c.backupJobs, jobId)
+				}
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListRecoveryPoints uses the index of the first recovery point of the next page as pagination token.
+func (c *FakeCloudProvider) ListRecoveryPoints(ctx context.Context, backupVaultName, resourceArn, nextToken string, maxResults int64) (recoveryPoints []*RecoveryPoint, next string, err error) {
+	var matching []*RecoveryPoint
+	for _, rp := range c.recoveryPoints {
+		if rp.BackupVaultName == backupVaultName && (resourceArn == "" || rp.ResourceArn == resourceArn) {
+			matching = append(matching, rp)
+		}
+	}
+
+	start := 0
+	if nextToken != "" {
+		start, err = strconv.Atoi(nextToken)
+		if err != nil || start < 0 || start > len(matching) {
+			return nil, "", ErrInvalidToken
+		}
+	}
+	end := len(matching)
+	if maxResults > 0 && start+int(maxResults) < end {
+		end = start + int(maxResults)
+		next = strconv.Itoa(end)
+	}
+	return matching[start:end], next, nil
+}
+
+func fakeFileSystemArn(fileSystemId string) string {
+	return fmt.Sprintf("arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/%s", fileSystemId)
+}
@@ -0,0 +1,98 @@
+package cloud
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	apiRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "efs_csi",
+			Subsystem: "aws",
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of the AWS API calls including retries, by service and operation.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"service", "operation"},
+	)
+	apiRequestErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "efs_csi",
+			Subsystem: "aws",
+			Name:      "api_request_errors_total",
+			Help:      "Number of AWS API calls that failed after retries, by service, operation and error code.",
+		},
+		[]string{"service", "operation", "code"},
+	)
+	apiThrottlesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "efs_csi",
+			Subsystem: "aws",
+			Name:      "api_throttles_total",
+			Help:      "Number of attempts of AWS API calls that were throttled, by service and operation.",
+		},
+		[]string{"service", "operation"},
+	)
+	apiRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "efs_csi",
+			Subsystem: "aws",
+			Name:      "api_retries_total",
+			Help:      "Number of retries of AWS API calls, by service and operation.",
+		},
+		[]string{"service", "operation"},
+	)
+	apiRateLimiterWaitDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "efs_csi",
+			Subsystem: "aws",
+			Name:      "api_rate_limiter_wait_seconds",
+			Help:      "Time attempts of AWS API calls waited for the client-side rate limiter, by service and operation.",
+			Buckets:   []float64{.001, .01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"service", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(apiRequestDuration, apiRequestErrorsTotal, apiThrottlesTotal, apiRetriesTotal, apiRateLimiterWaitDuration)
+}
+
+// throttleMetricsHandler counts the throttled attempts of the calls of AWS clients, as throttled attempts are retried.
+var throttleMetricsHandler = request.NamedHandler{
+	Name: "efscsi.metrics.Throttle",
+	Fn: func(r *request.Request) {
+		if isThrottled(r) {
+			apiThrottlesTotal.WithLabelValues(r.ClientInfo.ServiceName, r.Operation.Name).Inc()
+		}
+	},
+}
+
+// completeMetricsHandler records the latency and error of the calls of AWS clients once they complete.
+var completeMetricsHandler = request.NamedHandler{
+	Name: "efscsi.metrics.Complete",
+	Fn: func(r *request.Request) {
+		service, operation := r.ClientInfo.ServiceName, r.Operation.Name
+		apiRequestDuration.WithLabelValues(service, operation).Observe(time.Since(r.Time).Seconds())
+		if r.RetryCount > 0 {
+			apiRetriesTotal.WithLabelValues(service, operation).Add(float64(r.RetryCount))
+		}
+		if r.Error != nil {
+			code := "Unknown"
+			if aerr, ok := r.Error.(awserr.Error); ok {
+				code = aerr.Code()
+			}
+			apiRequestErrorsTotal.WithLabelValues(service, operation, code).Inc()
+		}
+	},
+}
+
+// addMetricsHandlers makes the clients using the handlers record metrics of their calls.
+func addMetricsHandlers(handlers *request.Handlers) {
+	handlers.Retry.PushFrontNamed(throttleMetricsHandler)
+	handlers.Complete.PushBackNamed(completeMetricsHandler)
+}
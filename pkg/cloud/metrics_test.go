@@ -0,0 +1,51 @@
+package cloud
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	clientmetadata "github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsHandlers(t *testing.T) {
+	newRequest := func(err error) *request.Request {
+		handlers := request.Handlers{}
+		addMetricsHandlers(&handlers)
+		r := request.New(aws.Config{}, clientmetadata.ClientInfo{ServiceName: "elasticfilesystem"}, handlers, nil,
+			&request.Operation{Name: "DescribeFileSystems"}, nil, nil)
+		r.Time = time.Now()
+		r.Error = err
+		return r
+	}
+
+	throttles := testutil.ToFloat64(apiThrottlesTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems"))
+	throttleErrors := testutil.ToFloat64(apiRequestErrorsTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems", "ThrottlingException"))
+	unknownErrors := testutil.ToFloat64(apiRequestErrorsTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems", "Unknown"))
+
+	// A throttled attempt is retried, and the call fails once retries are exhausted
+	r := newRequest(awserr.New("ThrottlingException", "Rate exceeded", nil))
+	r.Handlers.Retry.Run(r)
+	r.Handlers.Retry.Run(r)
+	r.Handlers.Complete.Run(r)
+	// A call failing without an AWS error
+	r = newRequest(errors.New("connection reset"))
+	r.Handlers.Complete.Run(r)
+	// A successful call
+	r = newRequest(nil)
+	r.Handlers.Complete.Run(r)
+
+	if actual := testutil.ToFloat64(apiThrottlesTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems")) - throttles; actual != 2 {
+		t.Fatalf("Expected 2 throttles, got %v", actual)
+	}
+	if actual := testutil.ToFloat64(apiRequestErrorsTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems", "ThrottlingException")) - throttleErrors; actual != 1 {
+		t.Fatalf("Expected 1 throttling error, got %v", actual)
+	}
+	if actual := testutil.ToFloat64(apiRequestErrorsTotal.WithLabelValues("elasticfilesystem", "DescribeFileSystems", "Unknown")) - unknownErrors; actual != 1 {
+		t.Fatalf("Expected 1 unknown error, got %v", actual)
+	}
+}
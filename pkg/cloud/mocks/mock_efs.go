@@ -135,3 +135,123 @@ func (mr *MockEfsMockRecorder) DescribeMountTargetsWithContext(arg0, arg1 interf
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeMountTargetsWithContext", reflect.TypeOf((*MockEfs)(nil).DescribeMountTargetsWithContext), varargs...)
 }
+
+// CreateFileSystemWithContext mocks base method.
+func (m *MockEfs) CreateFileSystemWithContext(arg0 context.Context, arg1 *efs.CreateFileSystemInput, arg2 ...request.Option) (*efs.FileSystemDescription, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateFileSystemWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.FileSystemDescription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileSystemWithContext indicates an expected call of CreateFileSystemWithContext.
+func (mr *MockEfsMockRecorder) CreateFileSystemWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileSystemWithContext", reflect.TypeOf((*MockEfs)(nil).CreateFileSystemWithContext), varargs...)
+}
+
+// DeleteFileSystemWithContext mocks base method.
+func (m *MockEfs) DeleteFileSystemWithContext(arg0 context.Context, arg1 *efs.DeleteFileSystemInput, arg2 ...request.Option) (*efs.DeleteFileSystemOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteFileSystemWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.DeleteFileSystemOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFileSystemWithContext indicates an expected call of DeleteFileSystemWithContext.
+func (mr *MockEfsMockRecorder) DeleteFileSystemWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileSystemWithContext", reflect.TypeOf((*MockEfs)(nil).DeleteFileSystemWithContext), varargs...)
+}
+
+// CreateMountTargetWithContext mocks base method.
+func (m *MockEfs) CreateMountTargetWithContext(arg0 context.Context, arg1 *efs.CreateMountTargetInput, arg2 ...request.Option) (*efs.MountTargetDescription, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateMountTargetWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.MountTargetDescription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMountTargetWithContext indicates an expected call of CreateMountTargetWithContext.
+func (mr *MockEfsMockRecorder) CreateMountTargetWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMountTargetWithContext", reflect.TypeOf((*MockEfs)(nil).CreateMountTargetWithContext), varargs...)
+}
+
+// DeleteMountTargetWithContext mocks base method.
+func (m *MockEfs) DeleteMountTargetWithContext(arg0 context.Context, arg1 *efs.DeleteMountTargetInput, arg2 ...request.Option) (*efs.DeleteMountTargetOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMountTargetWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.DeleteMountTargetOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMountTargetWithContext indicates an expected call of DeleteMountTargetWithContext.
+func (mr *MockEfsMockRecorder) DeleteMountTargetWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMountTargetWithContext", reflect.TypeOf((*MockEfs)(nil).DeleteMountTargetWithContext), varargs...)
+}
+
+// PutLifecycleConfigurationWithContext mocks base method.
+func (m *MockEfs) PutLifecycleConfigurationWithContext(arg0 context.Context, arg1 *efs.PutLifecycleConfigurationInput, arg2 ...request.Option) (*efs.PutLifecycleConfigurationOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutLifecycleConfigurationWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.PutLifecycleConfigurationOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutLifecycleConfigurationWithContext indicates an expected call of PutLifecycleConfigurationWithContext.
+func (mr *MockEfsMockRecorder) PutLifecycleConfigurationWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutLifecycleConfigurationWithContext", reflect.TypeOf((*MockEfs)(nil).PutLifecycleConfigurationWithContext), varargs...)
+}
+
+// TagResourceWithContext mocks base method.
+func (m *MockEfs) TagResourceWithContext(arg0 context.Context, arg1 *efs.TagResourceInput, arg2 ...request.Option) (*efs.TagResourceOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TagResourceWithContext", varargs...)
+	ret0, _ := ret[0].(*efs.TagResourceOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TagResourceWithContext indicates an expected call of TagResourceWithContext.
+func (mr *MockEfsMockRecorder) TagResourceWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagResourceWithContext", reflect.TypeOf((*MockEfs)(nil).TagResourceWithContext), varargs...)
+}
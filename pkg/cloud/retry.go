@@ -0,0 +1,164 @@
+package cloud
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"golang.org/x/time/rate"
+)
+
+// RetryOptions configure how the calls to the AWS APIs are retried and rate limited.
+type RetryOptions struct {
+	// MaxRetries is the number of times a throttled or transiently failed call is retried
+	MaxRetries int
+	// MinDelay is the delay before the first retry of a call that failed for a transient reason. It doubles with each
+	// retry.
+	MinDelay time.Duration
+	// MinThrottleDelay is the delay before the first retry of a throttled call. It doubles with each retry.
+	MinThrottleDelay time.Duration
+	// MaxDelay caps the delay between two attempts of a call
+	MaxDelay time.Duration
+	// RateLimit is the number of calls per second made to the AWS APIs of a region, or 0 for no limit. Every attempt of
+	// a call counts against the limit.
+	RateLimit float64
+	// Burst is the number of calls that can be made at once before RateLimit applies, or 0 for RateLimit rounded up
+	Burst int
+}
+
+// DefaultRetryOptions retry calls for about a minute. Calls are not rate limited, operators opt in by setting RateLimit.
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries:       8,
+	MinDelay:         100 * time.Millisecond,
+	MinThrottleDelay: 500 * time.Millisecond,
+	MaxDelay:         30 * time.Second,
+	RateLimit:        0,
+	Burst:            0,
+}
+
+var (
+	retryMu      sync.Mutex
+	retryOptions = DefaultRetryOptions
+	// rateLimiters are the rate limiters of the regions, shared by all the clients calling the region
+	rateLimiters = make(map[string]*rate.Limiter)
+)
+
+// SetRetryOptions sets how the calls of the clouds created afterwards are retried and rate limited.
+func SetRetryOptions(options RetryOptions) error {
+	if options.MaxRetries < 0 {
+		return fmt.Errorf("max retries must not be negative, got %d", options.MaxRetries)
+	}
+	if options.MinDelay <= 0 || options.MinThrottleDelay <= 0 {
+		return fmt.Errorf("retry delays must be positive, got %v and %v", options.MinDelay, options.MinThrottleDelay)
+	}
+	if options.MaxDelay < options.MinDelay || options.MaxDelay < options.MinThrottleDelay {
+		return fmt.Errorf("max retry delay %v must not be less than the min retry delays", options.MaxDelay)
+	}
+	if options.RateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative, got %v", options.RateLimit)
+	}
+	if options.Burst < 0 {
+		return fmt.Errorf("burst must not be negative, got %d", options.Burst)
+	}
+
+	retryMu.Lock()
+	defer retryMu.Unlock()
+	retryOptions = options
+	rateLimiters = make(map[string]*rate.Limiter)
+	return nil
+}
+
+// retrySettings returns the options and the rate limiter clients of the region should use, the rate limiter being nil
+// if calls are not rate limited.
+func retrySettings(region string) (RetryOptions, *rate.Limiter) {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+	if retryOptions.RateLimit == 0 {
+		return retryOptions, nil
+	}
+	limiter, ok := rateLimiters[region]
+	if !ok {
+		burst := retryOptions.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(retryOptions.RateLimit))
+		}
+		limiter = rate.NewLimiter(rate.Limit(retryOptions.RateLimit), burst)
+		rateLimiters[region] = limiter
+	}
+	return retryOptions, limiter
+}
+
+// addRetries makes the clients created from the session retry and rate limit their calls to the region.
+func addRetries(sess *session.Session, region string) {
+	options, limiter := retrySettings(region)
+	request.WithRetryer(sess.Config, &retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: options.MaxRetries},
+		options:        options,
+	})
+	if limiter != nil {
+		// Signing is the first step of every attempt, so waiting before it rate limits retries too
+		sess.Handlers.Sign.PushFrontNamed(newRateLimitHandler(limiter))
+	}
+}
+
+// retryer retries throttled and transiently failed calls with exponential backoff. Unlike the default retryer of the
+// SDK, it also treats the TooManyRequests error of EFS as throttling.
+type retryer struct {
+	client.DefaultRetryer
+	options RetryOptions
+}
+
+func (r *retryer) ShouldRetry(req *request.Request) bool {
+	if r.NumMaxRetries > 0 && req.Retryable == nil && isThrottled(req) {
+		return true
+	}
+	return r.DefaultRetryer.ShouldRetry(req)
+}
+
+func (r *retryer) RetryRules(req *request.Request) time.Duration {
+	minDelay := r.options.MinDelay
+	if isThrottled(req) {
+		minDelay = r.options.MinThrottleDelay
+	}
+	return backoff(minDelay, r.options.MaxDelay, req.RetryCount)
+}
+
+// backoff returns minDelay doubled retryCount times, capped at maxDelay, with jitter so that calls throttled at the
+// same time are not retried at the same time.
+func backoff(minDelay, maxDelay time.Duration, retryCount int) time.Duration {
+	delay := maxDelay
+	if retryCount < 32 {
+		if d := minDelay << uint(retryCount); d > 0 && d < maxDelay {
+			delay = d
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isThrottled reports whether the last attempt of the call was throttled.
+func isThrottled(r *request.Request) bool {
+	return r.IsErrorThrottle() || hasErrorCode(r.Error, efs.ErrCodeTooManyRequests)
+}
+
+// newRateLimitHandler returns a handler waiting for limiter before each attempt of a call. The call fails without
+// being retried if its context is done first.
+func newRateLimitHandler(limiter *rate.Limiter) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "efscsi.RateLimit",
+		Fn: func(r *request.Request) {
+			start := time.Now()
+			if err := limiter.Wait(r.Context()); err != nil {
+				r.Error = awserr.New(request.CanceledErrorCode, "request canceled while waiting for the rate limiter", err)
+				return
+			}
+			apiRateLimiterWaitDuration.WithLabelValues(r.ClientInfo.ServiceName, r.Operation.Name).Observe(time.Since(start).Seconds())
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+// newTestEfsClient returns an EFS client whose attempts fail with the given errors in turn, and succeed once the
+// errors are exhausted. It counts its attempts and does not sleep between them.
+func newTestEfsClient(t *testing.T, region string, attempts *int, errs ...error) *efs.EFS {
+	sess, err := session.NewSession(aws.NewConfig().
+		WithRegion(region).
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+		WithSleepDelay(func(time.Duration) {}))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	addRetries(sess, region)
+
+	client := efs.New(sess)
+	client.Handlers.Send.Clear()
+	client.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}, Body: http.NoBody}
+		if *attempts < len(errs) {
+			r.Error = errs[*attempts]
+		} else {
+			r.HTTPResponse.StatusCode = http.StatusOK
+		}
+		*attempts++
+	})
+	client.Handlers.UnmarshalMeta.Clear()
+	client.Handlers.ValidateResponse.Clear()
+	client.Handlers.UnmarshalError.Clear()
+	client.Handlers.Unmarshal.Clear()
+	return client
+}
+
+func TestRetryer(t *testing.T) {
+	defer SetRetryOptions(DefaultRetryOptions)
+	options := DefaultRetryOptions
+	options.MaxRetries = 3
+	options.RateLimit = 0
+	if err := SetRetryOptions(options); err != nil {
+		t.Fatalf("Failed to set retry options: %v", err)
+	}
+
+	tooManyRequests := awserr.New(efs.ErrCodeTooManyRequests, "Too many requests", nil)
+	testCases := []struct {
+		name             string
+		errs             []error
+		expectedAttempts int
+		expectedError    error
+	}{
+		{
+			name:             "Success: Call succeeds after being throttled",
+			errs:             []error{tooManyRequests, awserr.New(efs.ErrCodeThrottlingException, "Rate exceeded", nil)},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Success: Call succeeds after a transient error",
+			errs:             []error{awserr.New(request.ErrCodeRequestError, "Connection reset", errors.New("connection reset"))},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Fail: Call is throttled until retries are exhausted",
+			errs:             []error{tooManyRequests, tooManyRequests, tooManyRequests, tooManyRequests},
+			expectedAttempts: 4,
+			expectedError:    ErrThrottled,
+		},
+		{
+			name:             "Fail: Call is not retried on a permanent error",
+			errs:             []error{awserr.New(efs.ErrCodeAccessPointNotFound, "Access point not found", nil)},
+			expectedAttempts: 1,
+			expectedError:    ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			client := newTestEfsClient(t, "us-east-1", &attempts, tc.errs...)
+			_, err := client.DescribeAccessPointsWithContext(context.Background(), &efs.DescribeAccessPointsInput{})
+			if tc.expectedError == nil && err != nil {
+				t.Fatalf("Expected call to succeed, got %v", err)
+			}
+			if tc.expectedError != nil && !errors.Is(wrapError(err, "Describe Access Points failed"), tc.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tc.expectedError, err)
+			}
+			if attempts != tc.expectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryer_RateLimit(t *testing.T) {
+	defer SetRetryOptions(DefaultRetryOptions)
+	options := DefaultRetryOptions
+	options.RateLimit = 0.001
+	options.Burst = 1
+	if err := SetRetryOptions(options); err != nil {
+		t.Fatalf("Failed to set retry options: %v", err)
+	}
+
+	// Clients of the same region share the only token
+	attempts := 0
+	client := newTestEfsClient(t, "us-east-1", &attempts)
+	if _, err := client.DescribeAccessPointsWithContext(context.Background(), &efs.DescribeAccessPointsInput{}); err != nil {
+		t.Fatalf("Expected first call to succeed, got %v", err)
+	}
+	client = newTestEfsClient(t, "us-east-1", &attempts)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := client.DescribeAccessPointsWithContext(ctx, &efs.DescribeAccessPointsInput{})
+	if !hasErrorCode(err, request.CanceledErrorCode) {
+		t.Fatalf("Expected call to be canceled while waiting for the rate limiter, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", attempts)
+	}
+
+	// Clients of another region have tokens of their own
+	client = newTestEfsClient(t, "eu-west-1", &attempts)
+	if _, err := client.DescribeAccessPointsWithContext(context.Background(), &efs.DescribeAccessPointsInput{}); err != nil {
+		t.Fatalf("Expected call in another region to succeed, got %v", err)
+	}
+}
+
+func TestSetRetryOptions(t *testing.T) {
+	defer SetRetryOptions(DefaultRetryOptions)
+	invalid := map[string]func(o *RetryOptions){
+		"negative max retries":               func(o *RetryOptions) { o.MaxRetries = -1 },
+		"zero min delay":                     func(o *RetryOptions) { o.MinDelay = 0 },
+		"max delay less than throttle delay": func(o *RetryOptions) { o.MaxDelay = o.MinThrottleDelay / 2 },
+		"negative rate limit":                func(o *RetryOptions) { o.RateLimit = -1 },
+		"negative burst":                     func(o *RetryOptions) { o.Burst = -1 },
+	}
+	for name, modify := range invalid {
+		options := DefaultRetryOptions
+		modify(&options)
+		if err := SetRetryOptions(options); err == nil {
+			t.Fatalf("Expected options with %s to be invalid", name)
+		}
+	}
+
+	if err := SetRetryOptions(DefaultRetryOptions); err != nil {
+		t.Fatalf("Expected default options to be valid, got %v", err)
+	}
+	if _, limiter := retrySettings("us-east-1"); limiter != nil {
+		t.Fatal("Expected calls not to be rate limited by default")
+	}
+
+	options := DefaultRetryOptions
+	options.RateLimit = 2.5
+	if err := SetRetryOptions(options); err != nil {
+		t.Fatalf("Expected rate limit without burst to be valid, got %v", err)
+	}
+	if _, limiter := retrySettings("us-east-1"); limiter == nil || limiter.Burst() != 3 {
+		t.Fatalf("Expected burst to default to the rate limit rounded up, got limiter %+v", limiter)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	var (
+		minDelay = 100 * time.Millisecond
+		maxDelay = 2 * time.Second
+	)
+	for retryCount, expected := range []time.Duration{minDelay, 2 * minDelay, 4 * minDelay, 8 * minDelay, 16 * minDelay, maxDelay, maxDelay} {
+		delay := backoff(minDelay, maxDelay, retryCount)
+		if delay < expected/2 || delay > expected {
+			t.Fatalf("Expected retry %d to be delayed between %v and %v, got %v", retryCount, expected/2, expected, delay)
+		}
+	}
+	if delay := backoff(minDelay, maxDelay, 100); delay < maxDelay/2 || delay > maxDelay {
+		t.Fatalf("Expected delay to be capped at %v, got %v", maxDelay, delay)
+	}
+}
@@ -0,0 +1,253 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/backup"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"k8s.io/klog"
+)
+
+const (
+	// roleIdleTimeout is how long the cloud of a role is kept in the pool without being used
+	roleIdleTimeout = time.Hour
+	// roleExpiryWindow is how long before they expire the credentials of a role are refreshed
+	roleExpiryWindow = time.Minute
+)
+
+// credentialsErrorCodes are the error codes of calls rejected because the credentials they were signed with are not
+// valid anymore.
+var credentialsErrorCodes = map[string]struct{}{
+	"ExpiredToken":                {},
+	"ExpiredTokenException":       {},
+	"InvalidClientTokenId":        {},
+	"UnrecognizedClientException": {},
+}
+
+// RoleOptions are how a role is assumed.
+type RoleOptions struct {
+	RoleArn string
+	// ExternalId is the external ID required by the trust policy of the role, if any
+	ExternalId string
+	// SessionName is the name of the sessions of the role. The SDK generates one when empty.
+	SessionName string
+	// SessionTags are the tags of the sessions of the role
+	SessionTags map[string]string
+}
+
+// key identifies the options in the pool, as sessions assumed with different options have different permissions.
+func (o RoleOptions) key() string {
+	tags := make([]string, 0, len(o.SessionTags))
+	for k, v := range o.SessionTags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return strings.Join([]string{o.RoleArn, o.ExternalId, o.SessionName, strings.Join(tags, ",")}, "\x00")
+}
+
+var defaultRolePool = newRolePool(discoverMetadata, assumeRoleCredentials)
+
+// EvictRole drops the clouds of the role from the pool, so that the role is assumed again the next time it is used.
+// Clouds are evicted on their own when their credentials fail.
+func EvictRole(roleArn string) {
+	defaultRolePool.evictRole(roleArn)
+}
+
+// rolePool pools the clouds of the roles assumed. Clouds share the metadata discovered when the first role was assumed,
+// as it is the same for all roles, and each cloud shares one set of credentials between its clients.
+type rolePool struct {
+	discover       func() (*session.Session, MetadataService, error)
+	newCredentials func(sess *session.Session, opts RoleOptions) *credentials.Credentials
+
+	// discoverMu serializes discoveries of the metadata, which calls the instance metadata service, so that the pool
+	// is not locked meanwhile
+	discoverMu sync.Mutex
+	sess       *session.Session
+	metadata   MetadataService
+
+	mu      sync.Mutex
+	entries map[string]*roleEntry
+}
+
+type roleEntry struct {
+	roleArn string
+	// done is closed once the cloud is created, or failed to be when err is set
+	done     chan struct{}
+	err      error
+	cloud    Cloud
+	creds    *credentials.Credentials
+	lastUsed time.Time
+}
+
+func newRolePool(discover func() (*session.Session, MetadataService, error), newCredentials func(*session.Session, RoleOptions) *credentials.Credentials) *rolePool {
+	return &rolePool{
+		discover:       discover,
+		newCredentials: newCredentials,
+		entries:        make(map[string]*roleEntry),
+	}
+}
+
+// get returns the cloud of the role. Credentials that expired, or are about to, are refreshed before returning, so that
+// a role that cannot be assumed anymore fails here rather than in the middle of an operation.
+func (p *rolePool) get(ctx context.Context, opts RoleOptions) (Cloud, error) {
+	if opts.RoleArn == "" {
+		return nil, errors.New("role ARN is empty")
+	}
+	key := opts.key()
+	entry, err := p.entry(ctx, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	if entry.creds.IsExpired() {
+		if _, err := entry.creds.GetWithContext(ctx); err != nil {
+			if !isCanceled(err) {
+				p.evict(key, entry)
+			}
+			return nil, wrapError(err, "Could not assume role %v", opts.RoleArn)
+		}
+	}
+	return entry.cloud, nil
+}
+
+// entry returns the entry of the options, creating it if it is not pooled yet. Concurrent calls for the same options
+// share the creation of the entry, without holding up the calls for other options.
+func (p *rolePool) entry(ctx context.Context, key string, opts RoleOptions) (*roleEntry, error) {
+	p.mu.Lock()
+	now := time.Now()
+	for k, e := range p.entries {
+		if now.Sub(e.lastUsed) > roleIdleTimeout {
+			delete(p.entries, k)
+		}
+	}
+	entry, ok := p.entries[key]
+	if ok {
+		entry.lastUsed = now
+		p.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return entry, nil
+	}
+	entry = &roleEntry{
+		roleArn:  opts.RoleArn,
+		done:     make(chan struct{}),
+		lastUsed: now,
+	}
+	p.entries[key] = entry
+	p.mu.Unlock()
+
+	defer close(entry.done)
+	sess, metadata, err := p.getMetadata()
+	if err != nil {
+		// The failure is not pooled, so that the next call discovers the metadata again
+		entry.err = err
+		p.mu.Lock()
+		if p.entries[key] == entry {
+			delete(p.entries, key)
+		}
+		p.mu.Unlock()
+		return nil, err
+	}
+	entry.creds = p.newCredentials(sess, opts)
+	c := newCloud(metadata, entry.creds)
+	evictOnFailure := func() { p.evict(key, entry) }
+	addCredentialsHandlers(&c.efs.(*efs.EFS).Handlers, evictOnFailure)
+	addCredentialsHandlers(&c.backup.(*backup.Backup).Handlers, evictOnFailure)
+	entry.cloud = newCachedCloud(c, getCacheTTL())
+	return entry, nil
+}
+
+// getMetadata returns the metadata shared by the clouds of the roles, discovering it if it was not yet. A failed
+// discovery is retried by the next call.
+func (p *rolePool) getMetadata() (*session.Session, MetadataService, error) {
+	p.discoverMu.Lock()
+	defer p.discoverMu.Unlock()
+	if p.metadata == nil {
+		sess, metadata, err := p.discover()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.sess, p.metadata = sess, metadata
+	}
+	return p.sess, p.metadata, nil
+}
+
+// evict drops the entry, unless it was already replaced by another one.
+func (p *rolePool) evict(key string, entry *roleEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entries[key] == entry {
+		klog.Warningf("Credentials of role %v failed, it will be assumed again when next used", entry.roleArn)
+		delete(p.entries, key)
+	}
+}
+
+func (p *rolePool) evictRole(roleArn string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, entry := range p.entries {
+		if entry.roleArn == roleArn {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// assumeRoleCredentials returns credentials assuming the role with the STS client of sess.
+func assumeRoleCredentials(sess *session.Session, opts RoleOptions) *credentials.Credentials {
+	return stscreds.NewCredentials(sess, opts.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+		p.ExpiryWindow = roleExpiryWindow
+		if opts.ExternalId != "" {
+			p.ExternalID = aws.String(opts.ExternalId)
+		}
+		if opts.SessionName != "" {
+			p.RoleSessionName = opts.SessionName
+		}
+		for k, v := range opts.SessionTags {
+			p.Tags = append(p.Tags, &sts.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+	})
+}
+
+// addCredentialsHandlers makes the clients using the handlers call evict when their credentials fail, either because
+// they could not be refreshed or because AWS rejected them.
+func addCredentialsHandlers(handlers *request.Handlers, evict func()) {
+	// Signing only fails when the credentials cannot be retrieved, or when the call was canceled before
+	handlers.Sign.PushBackNamed(request.NamedHandler{
+		Name: "efscsi.credentials.Sign",
+		Fn: func(r *request.Request) {
+			if r.Error != nil && !isCanceled(r.Error) {
+				evict()
+			}
+		},
+	})
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "efscsi.credentials.Complete",
+		Fn: func(r *request.Request) {
+			var awsErr awserr.Error
+			if !errors.As(r.Error, &awsErr) {
+				return
+			}
+			if _, ok := credentialsErrorCodes[awsErr.Code()]; ok {
+				evict()
+			}
+		},
+	})
+}
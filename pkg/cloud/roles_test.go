@@ -0,0 +1,243 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	clientmetadata "github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+// fakeRoleProvider provides the credentials of a role, failing with err if set.
+type fakeRoleProvider struct {
+	retrieved int
+	expired   bool
+	err       error
+}
+
+func (p *fakeRoleProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+	if p.err != nil {
+		return credentials.Value{}, p.err
+	}
+	p.expired = false
+	return credentials.Value{AccessKeyID: "id", SecretAccessKey: "secret"}, nil
+}
+
+func (p *fakeRoleProvider) IsExpired() bool {
+	return p.expired
+}
+
+// fakeRolePool is a pool of roles whose credentials are provided by the providers of the pool.
+type fakeRolePool struct {
+	*rolePool
+	discovered  int
+	discoverErr error
+	// discovering is called while the metadata is discovered, if set
+	discovering func()
+	providers   []*fakeRoleProvider
+}
+
+func newFakeRolePool() *fakeRolePool {
+	p := &fakeRolePool{}
+	p.rolePool = newRolePool(func() (*session.Session, MetadataService, error) {
+		p.discovered++
+		if p.discovering != nil {
+			p.discovering()
+		}
+		if p.discoverErr != nil {
+			return nil, nil, p.discoverErr
+		}
+		return nil, &metadata{"instanceID", "us-east-1", "us-east-1a"}, nil
+	}, func(sess *session.Session, opts RoleOptions) *credentials.Credentials {
+		provider := &fakeRoleProvider{expired: true}
+		p.providers = append(p.providers, provider)
+		return credentials.NewCredentials(provider)
+	})
+	return p
+}
+
+func TestRolePool(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		roleArn = "arn:aws:iam::123456789012:role/EFSCrossAccountAccessRole"
+	)
+
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Role is assumed once for the same options",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				opts := RoleOptions{RoleArn: roleArn, SessionTags: map[string]string{"a": "1", "b": "2"}}
+				first, err := pool.get(ctx, opts)
+				if err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				second, err := pool.get(ctx, RoleOptions{RoleArn: roleArn, SessionTags: map[string]string{"b": "2", "a": "1"}})
+				if err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				if first != second {
+					t.Fatal("Expected the cloud of the role to be reused")
+				}
+				if pool.discovered != 1 || len(pool.providers) != 1 || pool.providers[0].retrieved != 1 {
+					t.Fatalf("Expected metadata to be discovered and role to be assumed once, got %d and %d", pool.discovered, pool.providers[0].retrieved)
+				}
+			},
+		},
+		{
+			name: "Success: Role is assumed again for other options",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				first, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				second, err := pool.get(ctx, RoleOptions{RoleArn: roleArn, ExternalId: "external-id"})
+				if err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				if first == second {
+					t.Fatal("Expected a cloud per options")
+				}
+				if pool.discovered != 1 || len(pool.providers) != 2 {
+					t.Fatalf("Expected metadata to be discovered once and role to be assumed twice, got %d and %d", pool.discovered, len(pool.providers))
+				}
+			},
+		},
+		{
+			name: "Success: Expired credentials are refreshed",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				first, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				pool.providers[0].expired = true
+				second, err := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				if err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				if first != second || pool.providers[0].retrieved != 2 {
+					t.Fatalf("Expected credentials of the same cloud to be refreshed, got %d retrievals", pool.providers[0].retrieved)
+				}
+			},
+		},
+		{
+			name: "Fail: Role that cannot be assumed is evicted",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				pool.providers[0].expired = true
+				pool.providers[0].err = awserr.New(AccessDeniedException, "Not authorized to perform sts:AssumeRole", nil)
+
+				_, err := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Expected ErrAccessDenied, got %v", err)
+				}
+				if _, err := pool.get(ctx, RoleOptions{RoleArn: roleArn}); err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				if len(pool.providers) != 2 {
+					t.Fatalf("Expected role to be assumed again, got %d providers", len(pool.providers))
+				}
+			},
+		},
+		{
+			name: "Fail: Metadata discovery failure is not pooled",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				pool.discoverErr = errors.New("could not get metadata")
+				if _, err := pool.get(ctx, RoleOptions{RoleArn: roleArn}); err == nil {
+					t.Fatal("Expected get to fail")
+				}
+				pool.discoverErr = nil
+				if _, err := pool.get(ctx, RoleOptions{RoleArn: roleArn}); err != nil {
+					t.Fatalf("Failed to get cloud: %v", err)
+				}
+				if pool.discovered != 2 {
+					t.Fatalf("Expected metadata to be discovered again, got %d", pool.discovered)
+				}
+			},
+		},
+		{
+			name: "Success: Concurrent gets share the creation of the cloud",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				discovering, unblock := make(chan struct{}), make(chan struct{})
+				pool.discovering = func() {
+					close(discovering)
+					<-unblock
+				}
+
+				var wg sync.WaitGroup
+				clouds := make([]Cloud, 5)
+				for i := range clouds {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						c, err := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+						if err != nil {
+							t.Errorf("Failed to get cloud: %v", err)
+						}
+						clouds[i] = c
+					}(i)
+				}
+				// The pool is not locked while the metadata is discovered
+				<-discovering
+				pool.evictRole("arn:aws:iam::123456789012:role/OtherRole")
+				close(unblock)
+				wg.Wait()
+
+				for _, c := range clouds {
+					if c != clouds[0] {
+						t.Fatal("Expected the cloud of the role to be shared")
+					}
+				}
+				if pool.discovered != 1 || len(pool.providers) != 1 {
+					t.Fatalf("Expected metadata to be discovered and role to be assumed once, got %d and %d", pool.discovered, len(pool.providers))
+				}
+			},
+		},
+		{
+			name: "Success: Role whose credentials are rejected is evicted",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				c, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+
+				handlers := c.(*cachedCloud).Cloud.(*cloud).efs.(*efs.EFS).Handlers
+				r := request.New(aws.Config{}, clientmetadata.ClientInfo{ServiceName: "elasticfilesystem"}, handlers, nil,
+					&request.Operation{Name: "DescribeFileSystems"}, nil, nil)
+				r.Error = awserr.New("ExpiredTokenException", "The security token included in the request is expired", nil)
+				r.Handlers.Complete.Run(r)
+
+				if other, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn}); other == c {
+					t.Fatal("Expected role to be evicted")
+				}
+			},
+		},
+		{
+			name: "Success: Role is evicted on demand",
+			testFunc: func(t *testing.T) {
+				pool := newFakeRolePool()
+				c, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn})
+				pool.get(ctx, RoleOptions{RoleArn: roleArn, SessionName: "session"})
+				pool.evictRole(roleArn)
+				if other, _ := pool.get(ctx, RoleOptions{RoleArn: roleArn}); other == c {
+					t.Fatal("Expected role to be evicted")
+				}
+				if len(pool.providers) != 3 {
+					t.Fatalf("Expected role to be assumed again, got %d providers", len(pool.providers))
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
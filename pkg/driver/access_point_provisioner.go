@@ -2,7 +2,9 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -18,6 +20,7 @@ type AccessPointProvisioner struct {
 	cloud                    cloud.Cloud
 	deleteAccessPointRootDir bool
 	mounter                  Mounter
+	osClient                 OsClient
 }
 
 func (a AccessPointProvisioner) Provision(ctx context.Context, req *csi.CreateVolumeRequest, uid, gid int) (*csi.Volume, error) {
@@ -38,8 +41,16 @@ func (a AccessPointProvisioner) Provision(ctx context.Context, req *csi.CreateVo
 	volSize := req.GetCapacityRange().GetRequiredBytes()
 
 	accessPointsOptions, err := a.deriveAccessPointOptions(req, uid, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	volContext, err := a.deriveQuotaVolumeContext(volumeParams, volSize)
+	if err != nil {
+		return nil, err
+	}
 
-	localCloud, roleArn, err := getCloud(a.cloud, req.GetSecrets())
+	localCloud, roleArn, err := getCloud(ctx, a.cloud, req.GetSecrets())
 	if err != nil {
 		return nil, err
 	}
@@ -54,28 +65,32 @@ func (a AccessPointProvisioner) Provision(ctx context.Context, req *csi.CreateVo
 	}
 
 	// Check if file system exists. Describe FS handles appropriate error codes
-	if _, err = localCloud.DescribeFileSystem(ctx, accessPointsOptions.FileSystemId); err != nil {
-		if err == cloud.ErrAccessDenied {
-			return nil, status.Errorf(codes.Unauthenticated, "Access Denied. Please ensure you have the right AWS permissions: %v", err)
-		}
-		if err == cloud.ErrNotFound {
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, accessPointsOptions.FileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
 			return nil, status.Errorf(codes.InvalidArgument, "File System does not exist: %v", err)
 		}
-		return nil, status.Errorf(codes.Internal, "Failed to fetch File System info: %v", err)
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
 	}
 
-	accessPointId, err := localCloud.CreateAccessPoint(ctx, volName, accessPointsOptions)
+	accessibleTopology, err := getAccessibleTopology(fileSystem, req.GetAccessibilityRequirements())
 	if err != nil {
-		if err == cloud.ErrAccessDenied {
-			return nil, status.Errorf(codes.Unauthenticated, "Access Denied. Please ensure you have the right AWS permissions: %v", err)
-		}
-		if err == cloud.ErrAlreadyExists {
-			return nil, status.Errorf(codes.AlreadyExists, "Access Point already exists")
-		}
-		return nil, status.Errorf(codes.Internal, "Failed to create Access point in File System %v : %v", accessPointsOptions.FileSystemId, err)
+		return nil, err
 	}
 
-	volContext := map[string]string{}
+	// The access point root directory is populated before the access point exists, so that EFS does not create it
+	// empty on first mount.
+	sourcePath, err := getCloneSourcePath(ctx, localCloud, req, accessPointsOptions.FileSystemId)
+	if err != nil {
+		return nil, err
+	}
+	if sourcePath != "" {
+		err = cloneDirectory(ctx, a.mounter, a.osClient, localCloud, roleArn, accessPointsOptions.FileSystemId,
+			sourcePath, accessPointsOptions.DirectoryPath, uid, gid)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Fetch mount target Ip for cross-account mount
 	if roleArn != "" {
@@ -87,10 +102,23 @@ func (a AccessPointProvisioner) Provision(ctx context.Context, req *csi.CreateVo
 		}
 	}
 
+	// The capacity and volume context are recorded on the access point, so that they can be reported later on
+	for k, v := range getVolumeTags(volSize, volContext) {
+		accessPointsOptions.Tags[k] = v
+	}
+	accessPointId, err := localCloud.CreateAccessPoint(ctx, volName, accessPointsOptions)
+	if err != nil {
+		if errors.Is(err, cloud.ErrAlreadyExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "Access Point already exists")
+		}
+		return nil, cloudErrorToStatus(err, "Failed to create Access point in File System %v", accessPointsOptions.FileSystemId)
+	}
+
 	return &csi.Volume{
-		CapacityBytes: volSize,
-		VolumeId:      accessPointsOptions.FileSystemId + "::" + accessPointId.AccessPointId,
-		VolumeContext: volContext,
+		CapacityBytes:      volSize,
+		VolumeId:           accessPointsOptions.FileSystemId + "::" + accessPointId.AccessPointId,
+		VolumeContext:      volContext,
+		AccessibleTopology: accessibleTopology,
 	}, nil
 }
 
@@ -99,7 +127,7 @@ func (a AccessPointProvisioner) deriveAccessPointOptions(req *csi.CreateVolumeRe
 
 	accessPointsOptions := &cloud.AccessPointOptions{
 		CapacityGiB: req.GetCapacityRange().GetRequiredBytes(),
-		Tags:        a.getTags(),
+		Tags:        getTags(a.tags),
 		Uid:         int64(uid),
 		Gid:         int64(gid),
 	}
@@ -131,23 +159,39 @@ func (a AccessPointProvisioner) deriveAccessPointOptions(req *csi.CreateVolumeRe
 	return accessPointsOptions, nil
 }
 
-func (a AccessPointProvisioner) getTags() map[string]string {
-	// Create tags
-	tags := map[string]string{
-		DefaultTagKey: DefaultTagValue,
+// deriveQuotaVolumeContext returns the volume context telling the node plugin to enforce the requested capacity as a
+// soft quota, or an empty context when the storage class does not enable quota enforcement.
+func (a AccessPointProvisioner) deriveQuotaVolumeContext(volumeParams map[string]string, volSize int64) (map[string]string, error) {
+	volContext := map[string]string{}
+	mode, ok := volumeParams[QuotaEnforcement]
+	if !ok {
+		return volContext, nil
+	}
+	if mode != QuotaEnforcementReport && mode != QuotaEnforcementReadOnly {
+		return nil, status.Errorf(codes.InvalidArgument, "Parameter %v must be %q or %q", QuotaEnforcement, QuotaEnforcementReport, QuotaEnforcementReadOnly)
+	}
+	if volSize <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Parameter %v requires a capacity to be requested", QuotaEnforcement)
 	}
 
-	// Append input tags to default tag
-	if len(a.tags) != 0 {
-		for k, v := range a.tags {
-			tags[k] = v
-		}
+	volContext[QuotaEnforcement] = mode
+	volContext[QuotaLimitBytes] = strconv.FormatInt(volSize, 10)
+	// Set by the external-provisioner when run with --extra-create-metadata, and used to follow the capacity of the PV
+	// and emit events on the PVC
+	if value, ok := volumeParams[PvNameKey]; ok {
+		volContext[PvNameKey] = value
 	}
-	return tags
+	if value, ok := volumeParams[PvcNameKey]; ok {
+		volContext[PvcNameKey] = value
+	}
+	if value, ok := volumeParams[PvcNamespaceKey]; ok {
+		volContext[PvcNamespaceKey] = value
+	}
+	return volContext, nil
 }
 
 func (a AccessPointProvisioner) Delete(ctx context.Context, req *csi.DeleteVolumeRequest) error {
-	localCloud, roleArn, err := getCloud(a.cloud, req.GetSecrets())
+	localCloud, roleArn, err := getCloud(ctx, a.cloud, req.GetSecrets())
 	if err != nil {
 		return err
 	}
@@ -160,14 +204,11 @@ func (a AccessPointProvisioner) Delete(ctx context.Context, req *csi.DeleteVolum
 			// If access point exists, retrieve its root directory and delete it/
 			accessPoint, err := localCloud.DescribeAccessPoint(ctx, accessPointId)
 			if err != nil {
-				if err == cloud.ErrAccessDenied {
-					return status.Errorf(codes.Unauthenticated, "Access Denied. Please ensure you have the right AWS permissions: %v", err)
-				}
-				if err == cloud.ErrNotFound {
+				if errors.Is(err, cloud.ErrNotFound) {
 					klog.V(5).Infof("DeleteVolume: Access Point %v not found, returning success", accessPointId)
 					return nil
 				}
-				return status.Errorf(codes.Internal, "Could not get describe Access Point: %v , error: %v", accessPointId, err)
+				return cloudErrorToStatus(err, "Could not describe Access Point %v", accessPointId)
 			}
 
 			//Mount File System at it root and delete access point root directory
@@ -182,7 +223,8 @@ func (a AccessPointProvisioner) Delete(ctx context.Context, req *csi.DeleteVolum
 				}
 			}
 
-			target := TempMountPathPrefix + "/" + accessPointId
+			target := tempMounts.add(TempMountPathPrefix)
+			defer tempMounts.release(target)
 			if err := a.mounter.MakeDir(target); err != nil {
 				return status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
 			}
@@ -206,14 +248,11 @@ func (a AccessPointProvisioner) Delete(ctx context.Context, req *csi.DeleteVolum
 
 		// Delete access point
 		if err = localCloud.DeleteAccessPoint(ctx, accessPointId); err != nil {
-			if err == cloud.ErrAccessDenied {
-				return status.Errorf(codes.Unauthenticated, "Access Denied. Please ensure you have the right AWS permissions: %v", err)
-			}
-			if err == cloud.ErrNotFound {
+			if errors.Is(err, cloud.ErrNotFound) {
 				klog.V(5).Infof("DeleteVolume: Access Point not found, returning success")
 				return nil
 			}
-			return status.Errorf(codes.Internal, "Failed to Delete volume %v: %v", req.GetVolumeId(), err)
+			return cloudErrorToStatus(err, "Failed to Delete volume %v", req.GetVolumeId())
 		}
 	} else {
 		return status.Errorf(codes.NotFound, "Failed to find access point for volume: %v", req.GetVolumeId())
@@ -221,3 +260,141 @@ func (a AccessPointProvisioner) Delete(ctx context.Context, req *csi.DeleteVolum
 
 	return nil
 }
+
+func (a AccessPointProvisioner) GetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	volId := req.GetVolumeId()
+	fileSystemId, _, accessPointId, _ := parseVolumeId(volId)
+
+	response := &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volId,
+			VolumeContext: map[string]string{},
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{},
+	}
+
+	localCloud, _, err := getFileSystemCloud(ctx, a.cloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	accessPoint, err := localCloud.DescribeAccessPoint(ctx, accessPointId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			response.Status.VolumeCondition = abnormalVolumeCondition("Access Point %v does not exist", accessPointId)
+			return response, nil
+		}
+		return nil, cloudErrorToStatus(err, "Could not describe Access Point %v", accessPointId)
+	}
+	response.Volume.CapacityBytes = getAccessPointCapacity(accessPoint)
+	response.Volume.VolumeContext = getAccessPointVolumeContext(accessPoint)
+
+	volumeCondition, err := getFileSystemCondition(ctx, localCloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+	response.Status.VolumeCondition = volumeCondition
+
+	return response, nil
+}
+
+// List returns the access points provisioned by the driver on the file system of the storage class, i.e. those tagged
+// with DefaultTagKey.
+func (a AccessPointProvisioner) List(ctx context.Context, params map[string]string) ([]*csi.Volume, error) {
+	fileSystemId := params[FsId]
+	if fileSystemId == "" {
+		return nil, nil
+	}
+
+	localCloud, _, err := getFileSystemCloud(ctx, a.cloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	accessPoints, err := localCloud.ListAccessPoints(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, cloudErrorToStatus(err, "Could not list access points of File System %v", fileSystemId)
+	}
+
+	var volumes []*csi.Volume
+	for _, ap := range accessPoints {
+		if ap.Tags[DefaultTagKey] != DefaultTagValue {
+			continue
+		}
+		volumes = append(volumes, &csi.Volume{
+			VolumeId:      ap.FileSystemId + "::" + ap.AccessPointId,
+			CapacityBytes: getAccessPointCapacity(ap),
+			VolumeContext: getAccessPointVolumeContext(ap),
+		})
+	}
+	return volumes, nil
+}
+
+// Expand records the new capacity of the volume as a tag of its access point. EFS file systems are elastic, so there
+// is nothing to resize and the node does not need to be involved.
+func (a AccessPointProvisioner) Expand(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+	localCloud, _, err := getCloud(ctx, a.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, accessPointId, _ := parseVolumeId(req.GetVolumeId())
+	capacity := getExpandCapacity(req.GetCapacityRange())
+	if err := localCloud.TagAccessPoint(ctx, accessPointId, getVolumeTags(capacity, nil)); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Access Point %v does not exist", accessPointId)
+		}
+		return nil, cloudErrorToStatus(err, "Could not record capacity of Access Point %v", accessPointId)
+	}
+
+	return &csi.ControllerExpandVolumeResponse{
+		CapacityBytes:         capacity,
+		NodeExpansionRequired: false,
+	}, nil
+}
+
+// getVolumeTags returns the tags recording the capacity and volume context of a volume on its access point. The quota
+// limit is not recorded, as it follows the capacity of the volume.
+func getVolumeTags(capacity int64, volContext map[string]string) map[string]string {
+	tags := map[string]string{
+		CapacityTagKey: strconv.FormatInt(capacity, 10),
+	}
+	for k, v := range volContext {
+		if k != QuotaLimitBytes {
+			tags[VolumeContextTagKeyPrefix+k] = v
+		}
+	}
+	return tags
+}
+
+// getAccessPointCapacity returns the capacity recorded on the access point when the volume was provisioned or last
+// expanded, or 0 when none was.
+func getAccessPointCapacity(accessPoint *cloud.AccessPoint) int64 {
+	value, ok := accessPoint.Tags[CapacityTagKey]
+	if !ok {
+		return 0
+	}
+	capacity, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		klog.Warningf("Ignoring invalid %v tag %q of Access Point %v: %v", CapacityTagKey, value, accessPoint.AccessPointId, err)
+		return 0
+	}
+	return capacity
+}
+
+// getAccessPointVolumeContext returns the volume context recorded on the access point when the volume was provisioned.
+func getAccessPointVolumeContext(accessPoint *cloud.AccessPoint) map[string]string {
+	volContext := map[string]string{}
+	for k, v := range accessPoint.Tags {
+		if strings.HasPrefix(k, VolumeContextTagKeyPrefix) {
+			volContext[strings.TrimPrefix(k, VolumeContextTagKeyPrefix)] = v
+		}
+	}
+	if _, ok := volContext[QuotaEnforcement]; ok {
+		volContext[QuotaLimitBytes] = strconv.FormatInt(getAccessPointCapacity(accessPoint), 10)
+	}
+	return volContext
+}
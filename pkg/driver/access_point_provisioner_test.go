@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
@@ -156,6 +159,75 @@ func TestAccessPointProvisioner_DeriveAccessPointOptions(t *testing.T) {
 	}
 }
 
+func TestAccessPointProvisioner_DeriveQuotaVolumeContext(t *testing.T) {
+	tests := []struct {
+		name            string
+		params          map[string]string
+		volSize         int64
+		expectedContext map[string]string
+		expectedCode    codes.Code
+	}{
+		{
+			name:            "Success: No quota enforcement",
+			params:          map[string]string{},
+			volSize:         5368709120,
+			expectedContext: map[string]string{},
+		},
+		{
+			name: "Success: Quota enforcement with PV and PVC metadata",
+			params: map[string]string{
+				QuotaEnforcement: QuotaEnforcementReadOnly,
+				PvNameKey:        "pvc-1234",
+				PvcNameKey:       "claim",
+				PvcNamespaceKey:  "default",
+			},
+			volSize: 5368709120,
+			expectedContext: map[string]string{
+				QuotaEnforcement: QuotaEnforcementReadOnly,
+				QuotaLimitBytes:  "5368709120",
+				PvNameKey:        "pvc-1234",
+				PvcNameKey:       "claim",
+				PvcNamespaceKey:  "default",
+			},
+		},
+		{
+			name: "Fail: Invalid quota enforcement mode",
+			params: map[string]string{
+				QuotaEnforcement: "hard",
+			},
+			volSize:      5368709120,
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Fail: Quota enforcement without capacity",
+			params: map[string]string{
+				QuotaEnforcement: QuotaEnforcementReport,
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apProv := AccessPointProvisioner{}
+
+			volContext, err := apProv.deriveQuotaVolumeContext(test.params, test.volSize)
+
+			if test.expectedCode != codes.OK {
+				if status.Code(err) != test.expectedCode {
+					t.Fatalf("Expected error code %v, got %v", test.expectedCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected deriveQuotaVolumeContext to succeed but it failed: %v", err)
+			}
+			if !reflect.DeepEqual(volContext, test.expectedContext) {
+				t.Fatalf("Expected volume context %v, got %v", test.expectedContext, volContext)
+			}
+		})
+	}
+}
+
 func TestAccessPointProvisioner_Provision(t *testing.T) {
 	var (
 		fsId                = "fs-abcd1234"
@@ -174,6 +246,63 @@ func TestAccessPointProvisioner_Provision(t *testing.T) {
 		name     string
 		testFunc func(t *testing.T)
 	}{
+		{
+			name: "Success: Capacity and volume context are recorded as access point tags",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+
+				var tags map[string]string
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, volumeName string, opts *cloud.AccessPointOptions) (*cloud.AccessPoint, error) {
+						tags = opts.Tags
+						return &cloud.AccessPoint{AccessPointId: "fsap-abcd1234", FileSystemId: fsId}, nil
+					})
+
+				req := &csi.CreateVolumeRequest{
+					Name:               volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: capacityRange},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+						QuotaEnforcement: QuotaEnforcementReport,
+						PvNameKey:        "pv",
+					},
+				}
+
+				apProv := AccessPointProvisioner{
+					tags:  map[string]string{},
+					cloud: mockCloud,
+				}
+
+				volume, err := apProv.Provision(ctx, req, 1000, 1000)
+				if err != nil {
+					t.Fatalf("Provision failed: %v", err)
+				}
+
+				expectedTags := map[string]string{
+					DefaultTagKey:  DefaultTagValue,
+					CapacityTagKey: strconv.FormatInt(capacityRange, 10),
+					VolumeContextTagKeyPrefix + QuotaEnforcement: QuotaEnforcementReport,
+					VolumeContextTagKeyPrefix + PvNameKey:        "pv",
+				}
+				if !reflect.DeepEqual(tags, expectedTags) {
+					t.Fatalf("Tags mismatched. Expected: %v, Actual: %v", expectedTags, tags)
+				}
+				accessPoint := &cloud.AccessPoint{Tags: tags}
+				if volContext := getAccessPointVolumeContext(accessPoint); !reflect.DeepEqual(volContext, volume.VolumeContext) {
+					t.Fatalf("Recorded volume context mismatched. Expected: %v, Actual: %v", volume.VolumeContext, volContext)
+				}
+				mockCtl.Finish()
+			},
+		},
 		{
 			name: "Fail: File system does not exist",
 			testFunc: func(t *testing.T) {
@@ -430,6 +559,169 @@ func TestAccessPointProvisioner_Provision(t *testing.T) {
 				mockCtl.Finish()
 			},
 		},
+		{
+			name: "Success: Clone copies the source access point root directory before creating the access point",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+				sourceAccessPoint := &cloud.AccessPoint{
+					AccessPointId:      "fsap-source",
+					FileSystemId:       fsId,
+					AccessPointRootDir: "/source",
+				}
+				accessPoint := &cloud.AccessPoint{
+					AccessPointId: "fsap-clone",
+					FileSystemId:  fsId,
+				}
+
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), "fsap-source").Return(sourceAccessPoint, nil)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(accessPoint, nil)
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					CapacityRange: &csi.CapacityRange{
+						RequiredBytes: capacityRange,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId + "::fsap-source",
+							},
+						},
+					},
+				}
+
+				apProv := AccessPointProvisioner{
+					tags:     map[string]string{},
+					cloud:    mockCloud,
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				volume, err := apProv.Provision(ctx, req, 1000, 1000)
+
+				if err != nil {
+					t.Fatalf("Expected Provision to succeed but it failed: %v", err)
+				}
+				if volume.VolumeId != fsId+"::fsap-clone" {
+					t.Fatalf("Expected volumeId to be %s but was %s", fsId+"::fsap-clone", volume.VolumeId)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Clone source access point does not exist",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), "fsap-source").Return(nil, cloud.ErrNotFound)
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId + "::fsap-source",
+							},
+						},
+					},
+				}
+
+				apProv := AccessPointProvisioner{
+					tags:     map[string]string{},
+					cloud:    mockCloud,
+					osClient: &FakeOsClient{},
+				}
+
+				_, err := apProv.Provision(ctx, req, 1000, 1000)
+
+				if status.Code(err) != codes.NotFound {
+					t.Fatalf("Expected NotFound error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Clone copy fails",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId + ":/static",
+							},
+						},
+					},
+				}
+
+				apProv := AccessPointProvisioner{
+					tags:     map[string]string{},
+					cloud:    mockCloud,
+					mounter:  mockMounter,
+					osClient: &BrokenOsClient{},
+				}
+
+				_, err := apProv.Provision(ctx, req, 1000, 1000)
+
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("Expected Internal error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, test.testFunc)
@@ -780,3 +1072,330 @@ func TestAccessPointProvisioner_Delete(t *testing.T) {
 		t.Run(test.name, test.testFunc)
 	}
 }
+
+func TestAccessPointProvisioner_GetVolume(t *testing.T) {
+	var (
+		fsId     = "fs-abcd1234"
+		apId     = "fsap-abcd1234xyz987"
+		volumeId = fmt.Sprintf("%s::%s", fsId, apId)
+	)
+
+	accessPoint := &cloud.AccessPoint{
+		AccessPointId: apId,
+		FileSystemId:  fsId,
+	}
+	provisionedAccessPoint := &cloud.AccessPoint{
+		AccessPointId: apId,
+		FileSystemId:  fsId,
+		Tags: map[string]string{
+			DefaultTagKey:  DefaultTagValue,
+			CapacityTagKey: "2048",
+			VolumeContextTagKeyPrefix + QuotaEnforcement: QuotaEnforcementReport,
+			VolumeContextTagKeyPrefix + PvNameKey:        "pv",
+		},
+	}
+	availableFileSystem := &cloud.FileSystem{
+		FileSystemId:   fsId,
+		LifeCycleState: FileSystemAvailable,
+	}
+
+	tests := []struct {
+		name             string
+		setup            func(ctx context.Context, mockCloud *mocks.MockCloud)
+		expectAbnormal   bool
+		expectCapacity   int64
+		expectContext    map[string]string
+		expectErrorCode  codes.Code
+		expectSuccessful bool
+	}{
+		{
+			name: "Success: Healthy access point",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(accessPoint, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(availableFileSystem, nil)
+				mockCloud.EXPECT().DescribeMountTargets(gomock.Eq(ctx), fsId, "").Return(&cloud.MountTarget{}, nil)
+			},
+			expectContext:    map[string]string{},
+			expectSuccessful: true,
+		},
+		{
+			name: "Success: Capacity and volume context are read from the tags of the access point",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(provisionedAccessPoint, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(availableFileSystem, nil)
+				mockCloud.EXPECT().DescribeMountTargets(gomock.Eq(ctx), fsId, "").Return(&cloud.MountTarget{}, nil)
+			},
+			expectCapacity: 2048,
+			expectContext: map[string]string{
+				QuotaEnforcement: QuotaEnforcementReport,
+				QuotaLimitBytes:  "2048",
+				PvNameKey:        "pv",
+			},
+			expectSuccessful: true,
+		},
+		{
+			name: "Success: Access point deleted out-of-band is abnormal",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(nil, cloud.ErrNotFound)
+			},
+			expectAbnormal:   true,
+			expectSuccessful: true,
+		},
+		{
+			name: "Success: File system that is not available is abnormal",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(accessPoint, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(&cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "deleting"}, nil)
+			},
+			expectAbnormal:   true,
+			expectSuccessful: true,
+		},
+		{
+			name: "Success: File system without an available mount target is abnormal",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(accessPoint, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(availableFileSystem, nil)
+				mockCloud.EXPECT().DescribeMountTargets(gomock.Eq(ctx), fsId, "").Return(nil, errors.New("No mount target is in available state"))
+			},
+			expectAbnormal:   true,
+			expectSuccessful: true,
+		},
+		{
+			name: "Fail: Access denied describing access point",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(nil, cloud.ErrAccessDenied)
+			},
+			expectErrorCode: codes.Unauthenticated,
+		},
+		{
+			name: "Fail: DescribeFileSystem fails",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), apId).Return(accessPoint, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(nil, errors.New("DescribeFileSystem failed"))
+			},
+			expectErrorCode: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			ctx := context.Background()
+			test.setup(ctx, mockCloud)
+
+			apProv := AccessPointProvisioner{
+				tags:  map[string]string{},
+				cloud: mockCloud,
+			}
+
+			res, err := apProv.GetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volumeId})
+			if !test.expectSuccessful {
+				if status.Code(err) != test.expectErrorCode {
+					t.Fatalf("Expected error code %v but got %v", test.expectErrorCode, err)
+				}
+				mockCtl.Finish()
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected GetVolume to succeed but it failed: %v", err)
+			}
+			if res.Volume.VolumeId != volumeId {
+				t.Fatalf("Volume Id mismatched. Expected: %v, Actual: %v", volumeId, res.Volume.VolumeId)
+			}
+			if res.Status.VolumeCondition.Abnormal != test.expectAbnormal {
+				t.Fatalf("Expected abnormal to be %v, but condition was %+v", test.expectAbnormal, res.Status.VolumeCondition)
+			}
+			if res.Volume.CapacityBytes != test.expectCapacity {
+				t.Fatalf("Capacity mismatched. Expected: %v, Actual: %v", test.expectCapacity, res.Volume.CapacityBytes)
+			}
+			if test.expectContext != nil && !reflect.DeepEqual(res.Volume.VolumeContext, test.expectContext) {
+				t.Fatalf("Volume context mismatched. Expected: %v, Actual: %v", test.expectContext, res.Volume.VolumeContext)
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+func TestAccessPointProvisioner_Expand(t *testing.T) {
+	var (
+		fsId     = "fs-abcd1234"
+		apId     = "fsap-abcd1234xyz987"
+		volumeId = fmt.Sprintf("%s::%s", fsId, apId)
+		capacity = int64(10 * 1024 * 1024 * 1024)
+	)
+
+	tests := []struct {
+		name             string
+		capacityRange    *csi.CapacityRange
+		setup            func(ctx context.Context, mockCloud *mocks.MockCloud)
+		expectErrorCode  codes.Code
+		expectSuccessful bool
+	}{
+		{
+			name:          "Success: Capacity is recorded as an access point tag",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, map[string]string{CapacityTagKey: "10737418240"}).Return(nil)
+			},
+			expectSuccessful: true,
+		},
+		{
+			name:          "Success: Limit bytes are recorded when required bytes are not set",
+			capacityRange: &csi.CapacityRange{LimitBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, map[string]string{CapacityTagKey: "10737418240"}).Return(nil)
+			},
+			expectSuccessful: true,
+		},
+		{
+			name:          "Fail: Access point does not exist",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, gomock.Any()).Return(cloud.ErrNotFound)
+			},
+			expectErrorCode: codes.NotFound,
+		},
+		{
+			name:          "Fail: Access denied tagging access point",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, gomock.Any()).Return(cloud.ErrAccessDenied)
+			},
+			expectErrorCode: codes.Unauthenticated,
+		},
+		{
+			name:          "Fail: TagAccessPoint fails",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, gomock.Any()).Return(errors.New("TagAccessPoint failed"))
+			},
+			expectErrorCode: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			ctx := context.Background()
+			test.setup(ctx, mockCloud)
+
+			apProv := AccessPointProvisioner{
+				tags:  map[string]string{},
+				cloud: mockCloud,
+			}
+
+			res, err := apProv.Expand(ctx, &csi.ControllerExpandVolumeRequest{VolumeId: volumeId, CapacityRange: test.capacityRange})
+			if !test.expectSuccessful {
+				if status.Code(err) != test.expectErrorCode {
+					t.Fatalf("Expected error code %v but got %v", test.expectErrorCode, err)
+				}
+				mockCtl.Finish()
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected Expand to succeed but it failed: %v", err)
+			}
+			if res.CapacityBytes != capacity {
+				t.Fatalf("Capacity mismatched. Expected: %v, Actual: %v", capacity, res.CapacityBytes)
+			}
+			if res.NodeExpansionRequired {
+				t.Fatal("Expected node expansion not to be required")
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+// describedAccessPointCloud describes access points the way EFS does, i.e. without the capacity they were created with.
+type describedAccessPointCloud struct {
+	*cloud.FakeCloudProvider
+}
+
+func (c describedAccessPointCloud) DescribeAccessPoint(ctx context.Context, accessPointId string) (*cloud.AccessPoint, error) {
+	accessPoint, err := c.FakeCloudProvider.DescribeAccessPoint(ctx, accessPointId)
+	if err != nil {
+		return nil, err
+	}
+	described := *accessPoint
+	described.CapacityGiB = 0
+	return &described, nil
+}
+
+func TestAccessPointProvisioner_CapacityIsReportedFromTag(t *testing.T) {
+	var (
+		ctx                 = context.Background()
+		capacity      int64 = 5368709120
+		expandedBytes int64 = 2 * capacity
+	)
+	apProv := AccessPointProvisioner{
+		tags:  map[string]string{},
+		cloud: describedAccessPointCloud{cloud.NewFakeCloudProvider()},
+	}
+	getCapacity := func(volumeId string) int64 {
+		res, err := apProv.GetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volumeId})
+		if err != nil {
+			t.Fatalf("GetVolume failed: %v", err)
+		}
+		return res.Volume.CapacityBytes
+	}
+
+	volume, err := apProv.Provision(ctx, &csi.CreateVolumeRequest{
+		Name:          "volumeName",
+		CapacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+		Parameters:    map[string]string{FsId: "fs-abcd1234", DirectoryPerms: "777"},
+	}, 1000, 1000)
+	if err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	if actual := getCapacity(volume.VolumeId); actual != capacity {
+		t.Fatalf("Provisioned capacity mismatched. Expected: %v, Actual: %v", capacity, actual)
+	}
+
+	_, err = apProv.Expand(ctx, &csi.ControllerExpandVolumeRequest{
+		VolumeId:      volume.VolumeId,
+		CapacityRange: &csi.CapacityRange{RequiredBytes: expandedBytes},
+	})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if actual := getCapacity(volume.VolumeId); actual != expandedBytes {
+		t.Fatalf("Expanded capacity mismatched. Expected: %v, Actual: %v", expandedBytes, actual)
+	}
+}
+
+func TestGetAccessPointCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected int64
+	}{
+		{
+			name:     "Capacity is read from the tag",
+			tags:     map[string]string{CapacityTagKey: "2048"},
+			expected: 2048,
+		},
+		{
+			name:     "No capacity without tag",
+			tags:     map[string]string{},
+			expected: 0,
+		},
+		{
+			name:     "No capacity with invalid tag",
+			tags:     map[string]string{CapacityTagKey: "large"},
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accessPoint := &cloud.AccessPoint{CapacityGiB: 1024, Tags: test.tags}
+			if actual := getAccessPointCapacity(accessPoint); actual != test.expected {
+				t.Fatalf("Capacity mismatched. Expected: %v, Actual: %v", test.expected, actual)
+			}
+		})
+	}
+}
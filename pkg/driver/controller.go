@@ -18,45 +18,105 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
+)
+
+const (
+	AccessPointMode              = "efs-ap"
+	AzName                       = "az"
+	BackupIamRoleArn             = "iamRoleArn"
+	BasePath                     = "basePath"
+	CapacityLimitBytes           = "capacityLimitBytes"
+	CapacityTagKey               = "efs.csi.aws.com/capacity-bytes"
+	DefaultGidMin                = 50000
+	DefaultGidMax                = 7000000
+	DefaultTagKey                = "efs.csi.aws.com/cluster"
+	DefaultTagValue              = "true"
+	DirectoryPerms               = "directoryPerms"
+	DirectoryMode                = "efs-dir"
+	Encrypted                    = "encrypted"
+	FileSystemAvailable          = "available"
+	FileSystemMode               = "efs-fs"
+	FsId                         = "fileSystemId"
+	Gid                          = "gid"
+	GidMin                       = "gidRangeStart"
+	GidMax                       = "gidRangeEnd"
+	KmsKeyId                     = "kmsKeyId"
+	MountTargetIp                = "mounttargetip"
+	PerformanceMode              = "performanceMode"
+	ProvisionedThroughputInMibps = "provisionedThroughputInMibps"
+	ProvisioningMode             = "provisioningMode"
+	PvNameKey                    = "csi.storage.k8s.io/pv/name"
+	PvcNameKey                   = "csi.storage.k8s.io/pvc/name"
+	PvcNamespaceKey              = "csi.storage.k8s.io/pvc/namespace"
+	QuotaEnforcement             = "quotaEnforcement"
+	QuotaEnforcementReadOnly     = "readOnly"
+	QuotaEnforcementReport       = "report"
+	QuotaLimitBytes              = "quotaLimitBytes"
+	RoleArn                      = "awsRoleArn"
+	RoleExternalId               = "awsRoleExternalId"
+	RoleSessionName              = "awsRoleSessionName"
+	RoleSessionTags              = "awsRoleSessionTags"
+	SecurityGroupIds             = "securityGroupIds"
+	SnapshotNameTagKey           = "efs.csi.aws.com/snapshot-name"
+	SourcePathTagKey             = "efs.csi.aws.com/source-path"
+	SourceVolumeIdTagKey         = "efs.csi.aws.com/source-volume-id"
+	SubnetIds                    = "subnetIds"
+	TempMountPathPrefix          = "/var/lib/csi/pv"
+	ThroughputMode               = "throughputMode"
+	TopologyKey                  = "topology.kubernetes.io/zone"
+	TransitionToIA               = "transitionToIA"
+	Uid                          = "uid"
+	VolumeContextTagKeyPrefix    = "efs.csi.aws.com/volume-context/"
 )
 
+// AWS Backup job and recovery point states
 const (
-	AccessPointMode     = "efs-ap"
-	AzName              = "az"
-	BasePath            = "basePath"
-	DefaultGidMin       = 50000
-	DefaultGidMax       = 7000000
-	DefaultTagKey       = "efs.csi.aws.com/cluster"
-	DefaultTagValue     = "true"
-	DirectoryPerms      = "directoryPerms"
-	DirectoryMode       = "efs-dir"
-	FsId                = "fileSystemId"
-	Gid                 = "gid"
-	GidMin              = "gidRangeStart"
-	GidMax              = "gidRangeEnd"
-	MountTargetIp       = "mounttargetip"
-	ProvisioningMode    = "provisioningMode"
-	RoleArn             = "awsRoleArn"
-	TempMountPathPrefix = "/var/lib/csi/pv"
-	Uid                 = "uid"
+	backupJobAborted       = "ABORTED"
+	backupJobCompleted     = "COMPLETED"
+	backupJobExpired       = "EXPIRED"
+	backupJobFailed        = "FAILED"
+	recoveryPointCompleted = "COMPLETED"
 )
 
+// unlimitedCapacity is reported by GetCapacity when no capacity limit applies, as EFS file systems grow elastically
+const unlimitedCapacity = math.MaxInt64
+
 var (
 	// controllerCaps represents the capability of controller service
 	controllerCaps = []csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 	}
 )
 
 func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
 	klog.V(4).Infof("CreateVolume: called with args %+v", *req)
+	if !d.inFlight.Insert(req.GetName()) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, req.GetName())
+	}
+	defer d.inFlight.Delete(req.GetName())
 
 	volCaps := req.GetVolumeCapabilities()
 	if len(volCaps) == 0 {
@@ -76,21 +136,41 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		return nil, status.Errorf(codes.InvalidArgument, "Missing %v parameter", ProvisioningMode)
 	}
 
+	// Snapshots are AWS Backup recovery points, which can only be restored through AWS Backup itself. The request is
+	// valid, but restoring is not implemented, as CREATE_DELETE_SNAPSHOT only covers taking snapshots.
+	if req.GetVolumeContentSource().GetSnapshot() != nil {
+		return nil, status.Error(codes.Unimplemented, "Creating a volume from a snapshot is not supported, restore the recovery point through AWS Backup instead")
+	}
+
 	mode := volumeParams[ProvisioningMode]
 	provisioner := d.provisioners[mode]
 	klog.V(5).Infof("CreateVolume: provisioning mode %s selected. Supported modes are %s", mode,
 		strings.Join(d.GetProvisioningModes(), ","))
 
-	uid, gid, err := d.fsIdentityManager.GetUidAndGid(
-		volumeParams[Uid], volumeParams[Gid], volumeParams[GidMin], volumeParams[GidMax], volumeParams[FsId])
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not assign UID or GID to access point")
+	// Only access points carry a POSIX identity, so other modes must not consume GIDs from the file system's range.
+	uid, gid := -1, -1
+	if mode == AccessPointMode {
+		localCloud, _, err := getCloud(ctx, d.cloud, req.GetSecrets())
+		if err != nil {
+			return nil, err
+		}
+		uid, gid, err = d.fsIdentityManager.GetUidAndGid(ctx, localCloud,
+			volumeParams[Uid], volumeParams[Gid], volumeParams[GidMin], volumeParams[GidMax], volumeParams[FsId])
+		if err != nil {
+			return nil, provisionerErrorToStatus(err, "Could not assign UID or GID to access point")
+		}
 	}
 	volume, err := provisioner.Provision(ctx, req, uid, gid)
 
 	if err != nil {
-		d.fsIdentityManager.ReleaseGid(volumeParams[FsId], gid)
-		return nil, status.Errorf(codes.Internal, "Could not provision underlying storage: %v", err)
+		if mode == AccessPointMode {
+			d.fsIdentityManager.ReleaseGid(volumeParams[FsId], gid)
+		}
+		return nil, provisionerErrorToStatus(err, "Could not provision underlying storage")
+	}
+	volume.ContentSource = req.GetVolumeContentSource()
+	if fileSystemId, _, _, err := parseVolumeId(volume.VolumeId); err == nil {
+		fileSystemRoles.set(fileSystemId, req.GetSecrets())
 	}
 
 	return &csi.CreateVolumeResponse{
@@ -105,27 +185,39 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
 	}
 
-	_, subpath, accessPointId, err := parseVolumeId(volId)
+	if !d.inFlight.Insert(volId) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, volId)
+	}
+	defer d.inFlight.Delete(volId)
+
+	fileSystemId, subpath, accessPointId, err := parseVolumeId(volId)
 	if err != nil {
 		//Returning success for an invalid volume ID. See here - https://github.com/kubernetes-csi/csi-test/blame/5deb83d58fea909b2895731d43e32400380aae3c/pkg/sanity/controller.go#L733
 		klog.V(5).Infof("DeleteVolume: Failed to parse volumeID: %v, err: %v, returning success", volId, err)
 		return &csi.DeleteVolumeResponse{}, nil
 	}
 
-	//TODO: Add Delete File System when FS provisioning is implemented
+	// Statically provisioned volumes may be bare file system IDs, which are never deleted
+	if accessPointId == "" && subpath == "" && !isFileSystemVolumeId(volId) {
+		return nil, status.Errorf(codes.NotFound, "Failed to find identifying information for volume: %v", volId)
+	}
 	if accessPointId != "" {
 		err := d.provisioners[AccessPointMode].Delete(ctx, req)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Failed to Delete volume %v: %v", volId, err)
+			return nil, provisionerErrorToStatus(err, "Failed to Delete volume %v", volId)
 		}
 	} else if subpath != "" {
 		err := d.provisioners[DirectoryMode].Delete(ctx, req)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Failed to Delete volume %v: %v", volId, err)
+			return nil, provisionerErrorToStatus(err, "Failed to Delete volume %v", volId)
 		}
 	} else {
-		return nil, status.Errorf(codes.NotFound, "Failed to find identifying information for volume: %v", volId)
+		err := d.provisioners[FileSystemMode].Delete(ctx, req)
+		if err != nil {
+			return nil, provisionerErrorToStatus(err, "Failed to Delete volume %v", volId)
+		}
 	}
+	fileSystemRoles.set(fileSystemId, req.GetSecrets())
 
 	return &csi.DeleteVolumeResponse{}, nil
 }
@@ -164,18 +256,157 @@ func (d *Driver) ValidateVolumeCapabilities(ctx context.Context, req *csi.Valida
 	}, nil
 }
 
+// ListVolumes lists the volumes provisioned by the driver on the file systems it manages, i.e. those of the storage
+// classes of the driver: the access points tagged with DefaultTagKey on the file system of efs-ap storage classes, the
+// directories under the base path of efs-dir storage classes, and the file systems tagged with DefaultTagKey for efs-fs
+// storage classes. Volumes are sorted by ID, and the starting and next tokens are indexes into them.
 func (d *Driver) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("ListVolumes: called with args %+v", *req)
+	maxEntries := req.GetMaxEntries()
+	if maxEntries < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Max entries cannot be negative: %d", maxEntries)
+	}
+	if d.kubeClient == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Volumes can only be listed when the driver runs in a cluster")
+	}
+
+	storageClasses, err := d.kubeClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "Could not list storage classes: %v", err)
+	}
+
+	volumes := make(map[string]*csi.Volume)
+	listed := make(map[string]bool)
+	for _, storageClass := range storageClasses.Items {
+		if storageClass.Provisioner != driverName {
+			continue
+		}
+		params := storageClass.Parameters
+		provisioner, ok := d.provisioners[params[ProvisioningMode]]
+		if !ok {
+			continue
+		}
+		// Storage classes that only differ by other parameters provision volumes in the same place
+		key := strings.Join([]string{params[ProvisioningMode], params[FsId], params[BasePath]}, ":")
+		if listed[key] {
+			continue
+		}
+		listed[key] = true
+
+		classVolumes, err := provisioner.List(ctx, params)
+		if err != nil {
+			return nil, provisionerErrorToStatus(err, "Failed to list volumes of storage class %v", storageClass.Name)
+		}
+		for _, volume := range classVolumes {
+			volumes[volume.VolumeId] = volume
+		}
+	}
+
+	volumeIds := make([]string, 0, len(volumes))
+	for volumeId := range volumes {
+		volumeIds = append(volumeIds, volumeId)
+	}
+	sort.Strings(volumeIds)
+
+	start := 0
+	if token := req.GetStartingToken(); token != "" {
+		start, err = strconv.Atoi(token)
+		if err != nil || start < 0 || start > len(volumeIds) {
+			return nil, status.Errorf(codes.Aborted, "Invalid starting token %q", token)
+		}
+	}
+	end := len(volumeIds)
+	nextToken := ""
+	if maxEntries > 0 && start+int(maxEntries) < end {
+		end = start + int(maxEntries)
+		nextToken = strconv.Itoa(end)
+	}
+
+	entries := []*csi.ListVolumesResponse_Entry{}
+	for _, volumeId := range volumeIds[start:end] {
+		entries = append(entries, &csi.ListVolumesResponse_Entry{Volume: volumes[volumeId]})
+	}
+	return &csi.ListVolumesResponse{
+		Entries:   entries,
+		NextToken: nextToken,
+	}, nil
 }
 
+// GetCapacity reports the capacity left on the file system given by the storage class, i.e. its capacityLimitBytes
+// parameter minus the metered size of the file system. The capacity is unlimited without that parameter, and for
+// efs-fs volumes which each get a file system of their own. When a zone is given, no capacity is available unless the
+// file system has an available mount target in it, which is never the case outside the zone of a One Zone file system.
 func (d *Driver) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("GetCapacity: called with args %+v", *req)
+	params := req.GetParameters()
+	fileSystemId := params[FsId]
+	if fileSystemId == "" || params[ProvisioningMode] == FileSystemMode {
+		return &csi.GetCapacityResponse{AvailableCapacity: unlimitedCapacity}, nil
+	}
+
+	var limitBytes int64 = unlimitedCapacity
+	if value, ok := params[CapacityLimitBytes]; ok {
+		var err error
+		limitBytes, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || limitBytes < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Parameter %v must be a non-negative integer: %q", CapacityLimitBytes, value)
+		}
+	}
+
+	fileSystem, err := d.cloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "File System does not exist: %v", err)
+		}
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+	if fileSystem.LifeCycleState != FileSystemAvailable {
+		klog.V(4).Infof("GetCapacity: File System %v is in %q state", fileSystemId, fileSystem.LifeCycleState)
+		return &csi.GetCapacityResponse{}, nil
+	}
+
+	if zone := req.GetAccessibleTopology().GetSegments()[TopologyKey]; zone != "" {
+		reachable, err := d.isReachableFromZone(ctx, fileSystem, zone)
+		if err != nil {
+			return nil, err
+		}
+		if !reachable {
+			klog.V(4).Infof("GetCapacity: File System %v has no available mount target in zone %v", fileSystemId, zone)
+			return &csi.GetCapacityResponse{}, nil
+		}
+	}
+
+	if limitBytes == unlimitedCapacity {
+		return &csi.GetCapacityResponse{AvailableCapacity: unlimitedCapacity}, nil
+	}
+	available := limitBytes - fileSystem.SizeInBytes
+	if available < 0 {
+		available = 0
+	}
+	return &csi.GetCapacityResponse{AvailableCapacity: available}, nil
+}
+
+// isReachableFromZone reports whether the file system has an available mount target in the zone.
+func (d *Driver) isReachableFromZone(ctx context.Context, fileSystem *cloud.FileSystem, zone string) (bool, error) {
+	if fileSystem.AvailabilityZoneName != "" && fileSystem.AvailabilityZoneName != zone {
+		return false, nil
+	}
+	mountTargets, err := d.cloud.ListMountTargets(ctx, fileSystem.FileSystemId)
+	if err != nil {
+		return false, cloudErrorToStatus(err, "Failed to list mount targets of File System %v", fileSystem.FileSystemId)
+	}
+	for _, mountTarget := range mountTargets {
+		if mountTarget.AZName == zone && mountTarget.LifeCycleState == FileSystemAvailable {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (d *Driver) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
 	klog.V(4).Infof("ControllerGetCapabilities: called with args %+v", *req)
 	var caps []*csi.ControllerServiceCapability
-	for _, cap := range controllerCaps {
+	for _, cap := range d.controllerCaps {
 		c := &csi.ControllerServiceCapability{
 			Type: &csi.ControllerServiceCapability_Rpc{
 				Rpc: &csi.ControllerServiceCapability_RPC{
@@ -188,23 +419,325 @@ func (d *Driver) ControllerGetCapabilities(ctx context.Context, req *csi.Control
 	return &csi.ControllerGetCapabilitiesResponse{Capabilities: caps}, nil
 }
 
+// CreateSnapshot starts an on-demand AWS Backup job of the volume's file system. The snapshot ID is the ARN of the
+// resulting recovery point, which is tagged with the source volume and the path it is rooted at, and the snapshot
+// is ready to use once the backup job has completed. The snapshot name is used as idempotency token, so repeated
+// calls return the same backup job.
 func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("CreateSnapshot: called with args %+v", *req)
+	snapshotName := req.GetName()
+	if snapshotName == "" {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot name not provided")
+	}
+
+	if !d.inFlight.Insert(snapshotName) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, snapshotName)
+	}
+	defer d.inFlight.Delete(snapshotName)
+
+	sourceVolumeId := req.GetSourceVolumeId()
+	if sourceVolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Source volume ID not provided")
+	}
+
+	fileSystemId, subpath, accessPointId, err := parseVolumeId(sourceVolumeId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Source volume not found, err: %v", err)
+	}
+
+	localCloud, _, err := getCloud(ctx, d.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "File System %v of source volume does not exist", fileSystemId)
+		}
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+
+	sourcePath := "/"
+	if accessPointId != "" {
+		accessPoint, err := localCloud.DescribeAccessPoint(ctx, accessPointId)
+		if err != nil {
+			if errors.Is(err, cloud.ErrNotFound) {
+				return nil, status.Errorf(codes.NotFound, "Access Point %v of source volume does not exist", accessPointId)
+			}
+			return nil, cloudErrorToStatus(err, "Could not describe Access Point %v", accessPointId)
+		}
+		sourcePath = accessPoint.AccessPointRootDir
+	} else if subpath != "" {
+		sourcePath = subpath
+	}
+
+	iamRoleArn := req.GetParameters()[BackupIamRoleArn]
+	if iamRoleArn == "" {
+		iamRoleArn, err = defaultBackupIamRoleArn(fileSystem.FileSystemArn)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Missing %v parameter: %v", BackupIamRoleArn, err)
+		}
+	}
+
+	tags := getTags(d.tags)
+	tags[SnapshotNameTagKey] = snapshotName
+	tags[SourceVolumeIdTagKey] = sourceVolumeId
+	tags[SourcePathTagKey] = sourcePath
+
+	backupJob, err := localCloud.StartBackupJob(ctx, &cloud.BackupJobOptions{
+		BackupVaultName:  d.backupVaultName,
+		IamRoleArn:       iamRoleArn,
+		ResourceArn:      fileSystem.FileSystemArn,
+		IdempotencyToken: snapshotName,
+		Tags:             tags,
+	})
+	if err != nil {
+		if errors.Is(err, cloud.ErrAlreadyExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "Snapshot %v already exists for a different source volume", snapshotName)
+		}
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Backup vault %v does not exist", d.backupVaultName)
+		}
+		return nil, cloudErrorToStatus(err, "Failed to start backup of File System %v", fileSystemId)
+	}
+
+	// The recovery point ARN is returned when the backup job starts, the backup job only reports it once it is created
+	snapshotId := backupJob.RecoveryPointArn
+	backupJob, err = localCloud.DescribeBackupJob(ctx, backupJob.BackupJobId)
+	if err != nil {
+		return nil, cloudErrorToStatus(err, "Failed to describe backup job")
+	}
+
+	switch backupJob.State {
+	case backupJobAborted, backupJobExpired, backupJobFailed:
+		return nil, status.Errorf(codes.Internal, "Backup job %v of File System %v is in %q state", backupJob.BackupJobId, fileSystemId, backupJob.State)
+	}
+
+	if snapshotId == "" {
+		snapshotId = backupJob.RecoveryPointArn
+	}
+	if snapshotId == "" {
+		// The idempotency token returns the same backup job when the call is retried
+		return nil, status.Errorf(codes.Unavailable, "Recovery point of backup job %v is not known yet", backupJob.BackupJobId)
+	}
+
+	return &csi.CreateSnapshotResponse{
+		Snapshot: &csi.Snapshot{
+			SnapshotId:     snapshotId,
+			SourceVolumeId: sourceVolumeId,
+			SizeBytes:      backupJob.BackupSizeInBytes,
+			CreationTime:   timestamppb.New(backupJob.CreationDate),
+			ReadyToUse:     backupJob.State == backupJobCompleted,
+		},
+	}, nil
 }
 
 func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("DeleteSnapshot: called with args %+v", *req)
+	snapshotId := req.GetSnapshotId()
+	if snapshotId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot ID not provided")
+	}
+
+	if !d.inFlight.Insert(snapshotId) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, snapshotId)
+	}
+	defer d.inFlight.Delete(snapshotId)
+
+	localCloud, _, err := getCloud(ctx, d.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = localCloud.DeleteRecoveryPoint(ctx, d.backupVaultName, snapshotId); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(5).Infof("DeleteSnapshot: Recovery Point %v not found, returning success", snapshotId)
+			return &csi.DeleteSnapshotResponse{}, nil
+		}
+		return nil, cloudErrorToStatus(err, "Failed to Delete snapshot %v", snapshotId)
+	}
+
+	return &csi.DeleteSnapshotResponse{}, nil
 }
 
+// ListSnapshots lists the recovery points in the driver's backup vault that were created by CreateSnapshot.
+// Pagination works the same way as in ListVolumes, with AWS Backup pagination tokens.
 func (d *Driver) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("ListSnapshots: called with args %+v", *req)
+	maxEntries := req.GetMaxEntries()
+	if maxEntries < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Max entries cannot be negative: %d", maxEntries)
+	}
+
+	localCloud, _, err := getCloud(ctx, d.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	sourceVolumeId := req.GetSourceVolumeId()
+	if snapshotId := req.GetSnapshotId(); snapshotId != "" {
+		recoveryPoint, err := localCloud.DescribeRecoveryPoint(ctx, d.backupVaultName, snapshotId)
+		if err != nil {
+			if errors.Is(err, cloud.ErrNotFound) {
+				return &csi.ListSnapshotsResponse{}, nil
+			}
+			return nil, cloudErrorToStatus(err, "Failed to describe recovery point %v", snapshotId)
+		}
+
+		entries := []*csi.ListSnapshotsResponse_Entry{}
+		if isSnapshotOf(recoveryPoint, sourceVolumeId) {
+			entries = append(entries, newListSnapshotsEntry(recoveryPoint))
+		}
+		return &csi.ListSnapshotsResponse{Entries: entries}, nil
+	}
+
+	var resourceArn string
+	if sourceVolumeId != "" {
+		fileSystemId, _, _, err := parseVolumeId(sourceVolumeId)
+		if err != nil {
+			return &csi.ListSnapshotsResponse{}, nil
+		}
+		fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+		if err != nil {
+			if errors.Is(err, cloud.ErrNotFound) {
+				return &csi.ListSnapshotsResponse{}, nil
+			}
+			return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+		}
+		resourceArn = fileSystem.FileSystemArn
+	}
+
+	entries := []*csi.ListSnapshotsResponse_Entry{}
+	nextToken := req.GetStartingToken()
+	for {
+		recoveryPoints, next, err := localCloud.ListRecoveryPoints(ctx, d.backupVaultName, resourceArn, nextToken, int64(maxEntries))
+		if err != nil {
+			if errors.Is(err, cloud.ErrInvalidToken) {
+				return nil, status.Errorf(codes.Aborted, "Invalid starting token %q: %v", nextToken, err)
+			}
+			return nil, cloudErrorToStatus(err, "Failed to list recovery points")
+		}
+
+		for _, recoveryPoint := range recoveryPoints {
+			if isSnapshotOf(recoveryPoint, sourceVolumeId) {
+				entries = append(entries, newListSnapshotsEntry(recoveryPoint))
+			}
+		}
+
+		nextToken = next
+		if maxEntries > 0 || nextToken == "" {
+			break
+		}
+	}
+
+	return &csi.ListSnapshotsResponse{
+		Entries:   entries,
+		NextToken: nextToken,
+	}, nil
 }
 
+// ControllerExpandVolume accepts the new capacity of a volume without resizing anything, as EFS is elastic. The
+// capacity is recorded on the access point of access point volumes, and is otherwise only tracked by the PV.
 func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("ControllerExpandVolume: called with args %+v", *req)
+	volId := req.GetVolumeId()
+	if volId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	if !d.inFlight.Insert(volId) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, volId)
+	}
+	defer d.inFlight.Delete(volId)
+
+	capRange := req.GetCapacityRange()
+	if capRange == nil {
+		return nil, status.Error(codes.InvalidArgument, "Capacity range not provided")
+	}
+	if capRange.GetRequiredBytes() < 0 || capRange.GetLimitBytes() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Capacity range cannot be negative")
+	}
+	if capRange.GetRequiredBytes() == 0 && capRange.GetLimitBytes() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Capacity range does not request a capacity")
+	}
+	if capRange.GetLimitBytes() > 0 && capRange.GetRequiredBytes() > capRange.GetLimitBytes() {
+		return nil, status.Errorf(codes.OutOfRange, "Required bytes %d exceed limit bytes %d", capRange.GetRequiredBytes(), capRange.GetLimitBytes())
+	}
+
+	fileSystemId, subpath, accessPointId, err := parseVolumeId(volId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Volume not found, err: %v", err)
+	}
+
+	var provisioner Provisioner
+	if accessPointId != "" {
+		provisioner = d.provisioners[AccessPointMode]
+	} else if subpath != "" {
+		provisioner = d.provisioners[DirectoryMode]
+	} else {
+		provisioner = d.provisioners[FileSystemMode]
+	}
+	resp, err := provisioner.Expand(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fileSystemRoles.set(fileSystemId, req.GetSecrets())
+	return resp, nil
 }
 
 func (d *Driver) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	klog.V(4).Infof("ControllerGetVolume: called with args %+v", *req)
+	volId := req.GetVolumeId()
+	if volId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
 
-	return nil, status.Error(codes.Unimplemented, "")
+	// Volumes are not looked up while they are being deleted, so that a volume is not reported missing mid-deletion
+	if !d.inFlight.Insert(volId) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, volId)
+	}
+	defer d.inFlight.Delete(volId)
+
+	_, subpath, accessPointId, err := parseVolumeId(volId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "Volume not found, err: %v", err)
+	}
+
+	if accessPointId != "" {
+		return d.provisioners[AccessPointMode].GetVolume(ctx, req)
+	} else if subpath != "" {
+		return d.provisioners[DirectoryMode].GetVolume(ctx, req)
+	}
+	return d.provisioners[FileSystemMode].GetVolume(ctx, req)
+}
+
+// isSnapshotOf reports whether the recovery point was created by CreateSnapshot, from sourceVolumeId if it is set.
+func isSnapshotOf(recoveryPoint *cloud.RecoveryPoint, sourceVolumeId string) bool {
+	if recoveryPoint.Tags[DefaultTagKey] != DefaultTagValue || recoveryPoint.Tags[SourceVolumeIdTagKey] == "" {
+		return false
+	}
+	return sourceVolumeId == "" || recoveryPoint.Tags[SourceVolumeIdTagKey] == sourceVolumeId
+}
+
+func newListSnapshotsEntry(recoveryPoint *cloud.RecoveryPoint) *csi.ListSnapshotsResponse_Entry {
+	return &csi.ListSnapshotsResponse_Entry{
+		Snapshot: &csi.Snapshot{
+			SnapshotId:     recoveryPoint.RecoveryPointArn,
+			SourceVolumeId: recoveryPoint.Tags[SourceVolumeIdTagKey],
+			SizeBytes:      recoveryPoint.BackupSizeInBytes,
+			CreationTime:   timestamppb.New(recoveryPoint.CreationDate),
+			ReadyToUse:     recoveryPoint.Status == recoveryPointCompleted,
+		},
+	}
+}
+
+// defaultBackupIamRoleArn returns the ARN of the default AWS Backup service role in the account of the file system.
+func defaultBackupIamRoleArn(fileSystemArn string) (string, error) {
+	// arn:partition:elasticfilesystem:region:account-id:file-system/file-system-id
+	tokens := strings.Split(fileSystemArn, ":")
+	if len(tokens) != 6 || tokens[1] == "" || tokens[4] == "" {
+		return "", fmt.Errorf("could not derive account from File System ARN %q", fileSystemArn)
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/service-role/AWSBackupDefaultServiceRole", tokens[1], tokens[4]), nil
 }
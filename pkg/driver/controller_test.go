@@ -4,10 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
@@ -70,6 +78,7 @@ func TestCreateVolume(t *testing.T) {
 					FileSystemId:  fsId,
 				}
 				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil)
 				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(accessPoint, nil)
 
 				res, err := driver.CreateVolume(ctx, req)
@@ -92,12 +101,13 @@ func TestCreateVolume(t *testing.T) {
 			name: "Success: Normal flow, Directory Provisioning",
 			testFunc: func(t *testing.T) {
 				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
 				mockMounter := mocks.NewMockMounter(mockCtl)
 				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
 				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
 				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
 
-				driver := buildDriver(endpoint, nil, "", mockMounter, false, false)
+				driver := buildDriver(endpoint, mockCloud, "", mockMounter, false, false)
 
 				req := &csi.CreateVolumeRequest{
 					Name: volumeName,
@@ -118,6 +128,11 @@ func TestCreateVolume(t *testing.T) {
 				}
 
 				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId:         fsId,
+					AvailabilityZoneName: "us-east-1a",
+				}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
 
 				res, err := driver.CreateVolume(ctx, req)
 
@@ -132,6 +147,11 @@ func TestCreateVolume(t *testing.T) {
 				if res.Volume.VolumeId != dirProvisioningVolumeId {
 					t.Fatalf("Volume Id mismatched. Expected: %v, Actual: %v", apProvisioningVolumeId, res.Volume.VolumeId)
 				}
+
+				expectedTopology := []*csi.Topology{{Segments: map[string]string{TopologyKey: "us-east-1a"}}}
+				if !reflect.DeepEqual(res.Volume.AccessibleTopology, expectedTopology) {
+					t.Fatalf("Accessible topology mismatched. Expected: %v, Actual: %v", expectedTopology, res.Volume.AccessibleTopology)
+				}
 				mockCtl.Finish()
 			},
 		},
@@ -168,6 +188,7 @@ func TestCreateVolume(t *testing.T) {
 					FileSystemId:  fsId,
 				}
 				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil)
 				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(accessPoint, nil)
 
 				res, err := driver.CreateVolume(ctx, req)
@@ -220,6 +241,7 @@ func TestCreateVolume(t *testing.T) {
 					FileSystemId:  fsId,
 				}
 				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Any()).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil)
 				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(accessPoint, nil)
 
 				res, err := driver.CreateVolume(ctx, req)
@@ -238,6 +260,94 @@ func TestCreateVolume(t *testing.T) {
 				mockCtl.Finish()
 			},
 		},
+		{
+			name: "Success: Clone of a directory volume",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+
+				driver := buildDriver(endpoint, cloud.NewFakeCloudProvider(), "", mockMounter, false, false)
+
+				contentSource := &csi.VolumeContentSource{
+					Type: &csi.VolumeContentSource_Volume{
+						Volume: &csi.VolumeContentSource_VolumeSource{
+							VolumeId: "fs-abcd1234:/dynamic/source",
+						},
+					},
+				}
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					CapacityRange: &csi.CapacityRange{
+						RequiredBytes: capacityRange,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-dir",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+						BasePath:         "/dynamic",
+					},
+					VolumeContentSource: contentSource,
+				}
+
+				ctx := context.Background()
+
+				res, err := driver.CreateVolume(ctx, req)
+
+				if err != nil {
+					t.Fatalf("CreateVolume failed: %v", err)
+				}
+
+				if res.Volume.VolumeId != dirProvisioningVolumeId {
+					t.Fatalf("Volume Id mismatched. Expected: %v, Actual: %v", dirProvisioningVolumeId, res.Volume.VolumeId)
+				}
+
+				if res.Volume.ContentSource != contentSource {
+					t.Fatalf("Content source mismatched. Expected: %v, Actual: %v", contentSource, res.Volume.ContentSource)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Volume content source is a snapshot",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{
+								SnapshotId: "arn:aws:backup:us-east-1:123456789012:recovery-point:abcd",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+				_, err := driver.CreateVolume(ctx, req)
+				if status.Code(err) != codes.Unimplemented {
+					t.Fatalf("Expected Unimplemented error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
 		{
 			name: "Fail: Volume name missing",
 			testFunc: func(t *testing.T) {
@@ -408,7 +518,7 @@ func TestCreateVolume(t *testing.T) {
 						stdVolCap,
 					},
 					Parameters: map[string]string{
-						ProvisioningMode: "efs-fs",
+						ProvisioningMode: "efs-foo",
 						FsId:             fsId,
 						DirectoryPerms:   "777",
 					},
@@ -453,6 +563,144 @@ func TestCreateVolume(t *testing.T) {
 				mockCtl.Finish()
 			},
 		},
+		{
+			name: "Fail: One Zone file system not accessible from the requisite topology",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.CreateVolumeRequest{
+					Name:               volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: capacityRange},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+					AccessibilityRequirements: &csi.TopologyRequirement{
+						Requisite: []*csi.Topology{{Segments: map[string]string{TopologyKey: "us-east-1b"}}},
+					},
+				}
+
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId:         fsId,
+					AvailabilityZoneName: "us-east-1a",
+				}
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil).AnyTimes()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+
+				_, err := driver.CreateVolume(ctx, req)
+				if status.Code(err) != codes.ResourceExhausted {
+					t.Fatalf("Expected code %v, got %v", codes.ResourceExhausted, err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Throttled describe call is reported as unavailable",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.CreateVolumeRequest{
+					Name:               volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: capacityRange},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+				}
+
+				ctx := context.Background()
+				throttled := &cloud.Error{Kind: cloud.ErrThrottled, Op: "DescribeFileSystems", Err: errors.New("Rate exceeded")}
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil).AnyTimes()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, throttled)
+
+				_, err := driver.CreateVolume(ctx, req)
+				if status.Code(err) != codes.Unavailable {
+					t.Fatalf("Expected code %v, got %v", codes.Unavailable, err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Access point limit is reported as resource exhausted",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.CreateVolumeRequest{
+					Name:               volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: capacityRange},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+				}
+
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+				limitExceeded := &cloud.Error{Kind: cloud.ErrAccessPointLimitExceeded, Op: "CreateAccessPoint", Err: errors.New("AccessPointLimitExceeded")}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil).AnyTimes()
+				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, limitExceeded)
+
+				_, err := driver.CreateVolume(ctx, req)
+				if status.Code(err) != codes.ResourceExhausted {
+					t.Fatalf("Expected code %v, got %v", codes.ResourceExhausted, err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: File system deleted while creating the access point is reported as not found",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.CreateVolumeRequest{
+					Name:               volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: capacityRange},
+					Parameters: map[string]string{
+						ProvisioningMode: "efs-ap",
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+					},
+				}
+
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+				}
+				notFound := &cloud.Error{Kind: cloud.ErrNotFound, Op: "CreateAccessPoint", Err: errors.New("FileSystemNotFound")}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), gomock.Any()).Return(nil, nil).AnyTimes()
+				mockCloud.EXPECT().CreateAccessPoint(gomock.Eq(ctx), gomock.Any(), gomock.Any()).Return(nil, notFound)
+
+				_, err := driver.CreateVolume(ctx, req)
+				if status.Code(err) != codes.NotFound {
+					t.Fatalf("Expected code %v, got %v", codes.NotFound, err)
+				}
+				mockCtl.Finish()
+			},
+		},
 		{
 			name: "Fail: Run out of GIDs",
 			testFunc: func(t *testing.T) {
@@ -568,6 +816,48 @@ func TestDeleteVolume(t *testing.T) {
 				mockCtl.Finish()
 			},
 		},
+		{
+			name: "Success: Normal flow, File System Provisioning",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: FileSystemVolumeIdPrefix + fsId,
+				}
+
+				ctx := context.Background()
+				fileSystem := &cloud.FileSystem{
+					FileSystemId: fsId,
+					Tags:         map[string]string{DefaultTagKey: DefaultTagValue},
+				}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, nil).Times(2)
+				mockCloud.EXPECT().DeleteFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil)
+				_, err := driver.DeleteVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Delete Volume failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Statically provisioned file system is not deleted",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				_, err := driver.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: fsId})
+				if status.Code(err) != codes.NotFound {
+					t.Fatalf("Expected NotFound, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
 		{
 			name: "Success: Cannot parse details from volumeId",
 			testFunc: func(t *testing.T) {
@@ -605,6 +895,28 @@ func TestDeleteVolume(t *testing.T) {
 				mockCtl.Finish()
 			},
 		},
+		{
+			name: "Fail: Throttled delete call is reported as unavailable",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				ctx := context.Background()
+				throttled := &cloud.Error{Kind: cloud.ErrThrottled, Op: "DeleteAccessPoint", Err: errors.New("Rate exceeded")}
+				mockCloud.EXPECT().DeleteAccessPoint(gomock.Eq(ctx), gomock.Eq(apId)).Return(throttled)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: apProvisionedVolumeId,
+				}
+
+				_, err := driver.DeleteVolume(ctx, req)
+				if status.Code(err) != codes.Unavailable {
+					t.Fatalf("Expected code %v, got %v", codes.Unavailable, err)
+				}
+				mockCtl.Finish()
+			},
+		},
 		{
 			name: "Fail: volumeId not provided",
 			testFunc: func(t *testing.T) {
@@ -754,30 +1066,956 @@ func TestValidateVolumeCapabilities(t *testing.T) {
 	}
 }
 
-func TestControllerGetCapabilities(t *testing.T) {
-	var endpoint = "endpoint"
-	mockCtl := gomock.NewController(t)
-	mockCloud := mocks.NewMockCloud(mockCtl)
-
-	driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+func TestListVolumes(t *testing.T) {
+	var (
+		endpoint      = "endpoint"
+		fsId          = "fs-abcd1234"
+		taggedAp      = &cloud.AccessPoint{AccessPointId: "fsap-abcd1234xyz987", FileSystemId: fsId, Tags: map[string]string{DefaultTagKey: DefaultTagValue}}
+		otherTaggedAp = &cloud.AccessPoint{AccessPointId: "fsap-efgh5678xyz987", FileSystemId: fsId, Tags: map[string]string{DefaultTagKey: DefaultTagValue, "env": "prod"}}
+		untaggedAp    = &cloud.AccessPoint{AccessPointId: "fsap-ijkl9012xyz987", FileSystemId: fsId, Tags: map[string]string{}}
+		apClass       = &storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "efs-ap"},
+			Provisioner: driverName,
+			Parameters:  map[string]string{ProvisioningMode: AccessPointMode, FsId: fsId},
+		}
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Volumes of every provisioning mode are listed from the storage classes of the driver",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockMounter := mocks.NewMockMounter(mockCtl)
 
-	ctx := context.Background()
-	_, err := driver.ControllerGetCapabilities(ctx, &csi.ControllerGetCapabilitiesRequest{})
-	if err != nil {
-		t.Fatalf("ControllerGetCapabilities failed: %v", err)
-	}
-}
+				dir := t.TempDir()
+				for _, name := range []string{"pvc-2", "pvc-1"} {
+					if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
 
-func buildDriver(endpoint string, cloud cloud.Cloud, tags string, mounter Mounter, deleteAccessPointRootDir bool, deleteProvisionedDir bool) *Driver {
-	parsedTags := parseTagsFromStr(tags)
+				driver := buildDriver(endpoint, mockCloud, "", mockMounter, false, false)
+				driver.provisioners[DirectoryMode] = DirectoryProvisioner{
+					mounter:  mockMounter,
+					cloud:    mockCloud,
+					osClient: &readDirOsClient{dir: dir},
+				}
+				otherApClass := apClass.DeepCopy()
+				otherApClass.Name = "efs-ap-other"
+				otherApClass.Parameters[Uid] = "1000"
+				driver.kubeClient = fake.NewSimpleClientset(
+					apClass,
+					otherApClass,
+					&storagev1.StorageClass{
+						ObjectMeta:  metav1.ObjectMeta{Name: "efs-dir"},
+						Provisioner: driverName,
+						Parameters:  map[string]string{ProvisioningMode: DirectoryMode, FsId: fsId, BasePath: "/dynamic"},
+					},
+					&storagev1.StorageClass{
+						ObjectMeta:  metav1.ObjectMeta{Name: "efs-fs"},
+						Provisioner: driverName,
+						Parameters:  map[string]string{ProvisioningMode: FileSystemMode},
+					},
+					&storagev1.StorageClass{
+						ObjectMeta:  metav1.ObjectMeta{Name: "ebs"},
+						Provisioner: "ebs.csi.aws.com",
+						Parameters:  map[string]string{ProvisioningMode: AccessPointMode, FsId: "fs-other"},
+					},
+				)
 
-	driver := &Driver{
-		endpoint:          endpoint,
+				ctx := context.Background()
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fsId).Return([]*cloud.AccessPoint{taggedAp, untaggedAp}, nil).Times(1)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(&cloud.FileSystem{FileSystemId: fsId}, nil)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+				mockCloud.EXPECT().ListFileSystems(gomock.Eq(ctx)).Return([]*cloud.FileSystem{
+					{FileSystemId: "fs-1234abcd", Tags: map[string]string{DefaultTagKey: DefaultTagValue}},
+					{FileSystemId: "fs-5678efgh", Tags: map[string]string{}},
+				}, nil)
+
+				res, err := driver.ListVolumes(ctx, &csi.ListVolumesRequest{})
+				if err != nil {
+					t.Fatalf("ListVolumes failed: %v", err)
+				}
+
+				var volumeIds []string
+				for _, entry := range res.Entries {
+					volumeIds = append(volumeIds, entry.Volume.VolumeId)
+				}
+				expectedVolumeIds := []string{
+					fsId + ":/dynamic/pvc-1",
+					fsId + ":/dynamic/pvc-2",
+					fsId + "::" + taggedAp.AccessPointId,
+					FileSystemVolumeIdPrefix + "fs-1234abcd",
+				}
+				if !reflect.DeepEqual(volumeIds, expectedVolumeIds) {
+					t.Fatalf("Volume Ids mismatched. Expected: %v, Actual: %v", expectedVolumeIds, volumeIds)
+				}
+				if res.NextToken != "" {
+					t.Fatalf("Expected no next token, got %v", res.NextToken)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Volumes are paged when max entries is set",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+				driver.kubeClient = fake.NewSimpleClientset(apClass)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fsId).Return([]*cloud.AccessPoint{otherTaggedAp, untaggedAp, taggedAp}, nil).Times(2)
+
+				res, err := driver.ListVolumes(ctx, &csi.ListVolumesRequest{MaxEntries: 1})
+				if err != nil {
+					t.Fatalf("ListVolumes failed: %v", err)
+				}
+				if len(res.Entries) != 1 || res.Entries[0].Volume.VolumeId != fsId+"::"+taggedAp.AccessPointId {
+					t.Fatalf("Expected the first access point, got %v", res.Entries)
+				}
+				if res.NextToken != "1" {
+					t.Fatalf("Next token mismatched. Expected: 1, Actual: %v", res.NextToken)
+				}
+
+				res, err = driver.ListVolumes(ctx, &csi.ListVolumesRequest{MaxEntries: 1, StartingToken: res.NextToken})
+				if err != nil {
+					t.Fatalf("ListVolumes failed: %v", err)
+				}
+				if len(res.Entries) != 1 || res.Entries[0].Volume.VolumeId != fsId+"::"+otherTaggedAp.AccessPointId {
+					t.Fatalf("Expected the second access point, got %v", res.Entries)
+				}
+				if res.NextToken != "" {
+					t.Fatalf("Expected no next token, got %v", res.NextToken)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Deleted file systems have no volumes",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+				driver.kubeClient = fake.NewSimpleClientset(apClass)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fsId).Return(nil, cloud.ErrNotFound)
+
+				res, err := driver.ListVolumes(ctx, &csi.ListVolumesRequest{})
+				if err != nil {
+					t.Fatalf("ListVolumes failed: %v", err)
+				}
+				if len(res.Entries) != 0 {
+					t.Fatalf("Expected no entries, got %v", res.Entries)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Negative max entries",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+				driver.kubeClient = fake.NewSimpleClientset(apClass)
+
+				_, err := driver.ListVolumes(context.Background(), &csi.ListVolumesRequest{MaxEntries: -1})
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Invalid starting token",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+				driver.kubeClient = fake.NewSimpleClientset(apClass)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fsId).Return([]*cloud.AccessPoint{taggedAp}, nil)
+
+				_, err := driver.ListVolumes(ctx, &csi.ListVolumesRequest{StartingToken: "invalid"})
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: ListAccessPoints fails",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+				driver.kubeClient = fake.NewSimpleClientset(apClass)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fsId).Return(nil, errors.New("ListAccessPoints failed"))
+
+				_, err := driver.ListVolumes(ctx, &csi.ListVolumesRequest{})
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("Expected Internal, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Volumes cannot be listed outside a cluster",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				_, err := driver.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+				if status.Code(err) != codes.FailedPrecondition {
+					t.Fatalf("Expected FailedPrecondition, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestGetCapacity(t *testing.T) {
+	var (
+		endpoint = "endpoint"
+		fsId     = "fs-abcd1234"
+		zone     = "us-east-1a"
+	)
+	regionalFileSystem := &cloud.FileSystem{FileSystemId: fsId, LifeCycleState: FileSystemAvailable, SizeInBytes: 1000}
+	oneZoneFileSystem := &cloud.FileSystem{FileSystemId: fsId, LifeCycleState: FileSystemAvailable, SizeInBytes: 1000, AvailabilityZoneName: zone}
+	mountTargets := []*cloud.MountTarget{
+		{AZName: zone, LifeCycleState: FileSystemAvailable},
+		{AZName: "us-east-1b", LifeCycleState: "creating"},
+	}
+
+	testCases := []struct {
+		name              string
+		params            map[string]string
+		zone              string
+		setup             func(ctx context.Context, mockCloud *mocks.MockCloud)
+		expectedCode      codes.Code
+		expectedAvailable int64
+	}{
+		{
+			name:              "Success: Unlimited without file system",
+			params:            map[string]string{},
+			expectedAvailable: unlimitedCapacity,
+		},
+		{
+			name: "Success: Unlimited in efs-fs mode",
+			params: map[string]string{
+				ProvisioningMode:   FileSystemMode,
+				CapacityLimitBytes: "5000",
+			},
+			expectedAvailable: unlimitedCapacity,
+		},
+		{
+			name: "Success: Unlimited without capacity limit",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+			},
+			expectedAvailable: unlimitedCapacity,
+		},
+		{
+			name: "Success: Capacity limit minus metered size",
+			params: map[string]string{
+				ProvisioningMode:   AccessPointMode,
+				FsId:               fsId,
+				CapacityLimitBytes: "5000",
+			},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+			},
+			expectedAvailable: 4000,
+		},
+		{
+			name: "Success: No capacity left above the limit",
+			params: map[string]string{
+				ProvisioningMode:   DirectoryMode,
+				FsId:               fsId,
+				CapacityLimitBytes: "500",
+			},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+			},
+			expectedAvailable: 0,
+		},
+		{
+			name: "Success: Zone with an available mount target",
+			params: map[string]string{
+				ProvisioningMode:   AccessPointMode,
+				FsId:               fsId,
+				CapacityLimitBytes: "5000",
+			},
+			zone: zone,
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), fsId).Return(mountTargets, nil)
+			},
+			expectedAvailable: 4000,
+		},
+		{
+			name: "Success: No capacity in zone without an available mount target",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			zone: "us-east-1b",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), fsId).Return(mountTargets, nil)
+			},
+			expectedAvailable: 0,
+		},
+		{
+			name: "Success: No capacity outside the zone of a One Zone file system",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			zone: "us-east-1b",
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(oneZoneFileSystem, nil)
+			},
+			expectedAvailable: 0,
+		},
+		{
+			name: "Success: No capacity on a file system that is not available",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(&cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "deleting"}, nil)
+			},
+			expectedAvailable: 0,
+		},
+		{
+			name: "Fail: Invalid capacity limit",
+			params: map[string]string{
+				ProvisioningMode:   AccessPointMode,
+				FsId:               fsId,
+				CapacityLimitBytes: "1Ti",
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Fail: File system does not exist",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(nil, cloud.ErrNotFound)
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Fail: Access denied listing mount targets",
+			params: map[string]string{
+				ProvisioningMode: AccessPointMode,
+				FsId:             fsId,
+			},
+			zone: zone,
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(regionalFileSystem, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), fsId).Return(nil, cloud.ErrAccessDenied)
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+
+			driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+			ctx := context.Background()
+			if tc.setup != nil {
+				tc.setup(ctx, mockCloud)
+			}
+			req := &csi.GetCapacityRequest{Parameters: tc.params}
+			if tc.zone != "" {
+				req.AccessibleTopology = &csi.Topology{Segments: map[string]string{TopologyKey: tc.zone}}
+			}
+			res, err := driver.GetCapacity(ctx, req)
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("Expected error code %v but got %v", tc.expectedCode, err)
+			}
+			if err == nil && res.AvailableCapacity != tc.expectedAvailable {
+				t.Fatalf("Available capacity mismatched. Expected: %v, Actual: %v", tc.expectedAvailable, res.AvailableCapacity)
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+func TestControllerGetVolume(t *testing.T) {
+	var endpoint = "endpoint"
+	testCases := []struct {
+		name         string
+		volumeId     string
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Fail: Volume Id is missing",
+			volumeId:     "",
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Fail: Volume Id cannot be parsed",
+			volumeId:     "invalid",
+			expectedCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+
+			driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+			_, err := driver.ControllerGetVolume(context.Background(), &csi.ControllerGetVolumeRequest{VolumeId: tc.volumeId})
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("Expected error code %v but got %v", tc.expectedCode, err)
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	var (
+		endpoint         = "endpoint"
+		fsId             = "fs-abcd1234"
+		apId             = "fsap-abcd1234xyz987"
+		volumeId         = fsId + "::" + apId
+		snapshotName     = "snapshot-1"
+		fsArn            = "arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/" + fsId
+		recoveryPointArn = "arn:aws:backup:us-east-1:123456789012:recovery-point:abcd"
+		fileSystem       = &cloud.FileSystem{FileSystemId: fsId, FileSystemArn: fsArn}
+		accessPoint      = &cloud.AccessPoint{AccessPointId: apId, FileSystemId: fsId, AccessPointRootDir: "/dynamic/volume"}
+		startedJob       = &cloud.BackupJob{BackupJobId: "job", RecoveryPointArn: recoveryPointArn, ResourceArn: fsArn, State: "CREATED"}
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Backup job of the access point's file system is started",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().DescribeAccessPoint(gomock.Eq(ctx), gomock.Eq(apId)).Return(accessPoint, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, opts *cloud.BackupJobOptions) (*cloud.BackupJob, error) {
+						expectedRoleArn := "arn:aws:iam::123456789012:role/service-role/AWSBackupDefaultServiceRole"
+						if opts.IamRoleArn != expectedRoleArn {
+							t.Fatalf("IamRoleArn mismatched. Expected: %v, Actual: %v", expectedRoleArn, opts.IamRoleArn)
+						}
+						if opts.ResourceArn != fsArn || opts.IdempotencyToken != snapshotName || opts.BackupVaultName != driver.backupVaultName {
+							t.Fatalf("Unexpected backup job options: %+v", opts)
+						}
+						if opts.Tags[SourcePathTagKey] != accessPoint.AccessPointRootDir || opts.Tags[SourceVolumeIdTagKey] != volumeId {
+							t.Fatalf("Unexpected recovery point tags: %v", opts.Tags)
+						}
+						return startedJob, nil
+					})
+				// The backup job does not report the recovery point until it is created
+				mockCloud.EXPECT().DescribeBackupJob(gomock.Eq(ctx), gomock.Eq("job")).Return(&cloud.BackupJob{BackupJobId: "job", State: "RUNNING"}, nil)
+
+				res, err := driver.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: volumeId})
+				if err != nil {
+					t.Fatalf("CreateSnapshot failed: %v", err)
+				}
+				if res.Snapshot.SnapshotId != recoveryPointArn {
+					t.Fatalf("SnapshotId mismatched. Expected: %v, Actual: %v", recoveryPointArn, res.Snapshot.SnapshotId)
+				}
+				if res.Snapshot.ReadyToUse {
+					t.Fatal("Expected snapshot not to be ready to use while the backup job is running")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Snapshot is ready to use once the backup job completed",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).Return(startedJob, nil)
+				mockCloud.EXPECT().DescribeBackupJob(gomock.Eq(ctx), gomock.Eq("job")).Return(&cloud.BackupJob{BackupJobId: "job", RecoveryPointArn: recoveryPointArn, State: "COMPLETED"}, nil)
+
+				req := &csi.CreateSnapshotRequest{
+					Name:           snapshotName,
+					SourceVolumeId: fsId + ":/dynamic/dir",
+					Parameters:     map[string]string{BackupIamRoleArn: "arn:aws:iam::123456789012:role/backup"},
+				}
+				res, err := driver.CreateSnapshot(ctx, req)
+				if err != nil {
+					t.Fatalf("CreateSnapshot failed: %v", err)
+				}
+				if !res.Snapshot.ReadyToUse {
+					t.Fatal("Expected snapshot to be ready to use")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Recovery point is taken from the backup job when the start did not return it",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).Return(&cloud.BackupJob{BackupJobId: "job", ResourceArn: fsArn, State: "CREATED"}, nil)
+				mockCloud.EXPECT().DescribeBackupJob(gomock.Eq(ctx), gomock.Eq("job")).Return(&cloud.BackupJob{BackupJobId: "job", RecoveryPointArn: recoveryPointArn, State: "RUNNING"}, nil)
+
+				res, err := driver.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: fsId})
+				if err != nil {
+					t.Fatalf("CreateSnapshot failed: %v", err)
+				}
+				if res.Snapshot.SnapshotId != recoveryPointArn {
+					t.Fatalf("SnapshotId mismatched. Expected: %v, Actual: %v", recoveryPointArn, res.Snapshot.SnapshotId)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Recovery point is not known yet",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).Return(&cloud.BackupJob{BackupJobId: "job", ResourceArn: fsArn, State: "CREATED"}, nil)
+				mockCloud.EXPECT().DescribeBackupJob(gomock.Eq(ctx), gomock.Eq("job")).Return(&cloud.BackupJob{BackupJobId: "job", State: "CREATED"}, nil)
+
+				_, err := driver.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: fsId})
+				if status.Code(err) != codes.Unavailable {
+					t.Fatalf("Expected Unavailable, got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Snapshot name is missing",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				_, err := driver.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{SourceVolumeId: volumeId})
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Snapshot name is used by a different source volume",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).Return(nil, cloud.ErrAlreadyExists)
+
+				_, err := driver.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: fsId})
+				if status.Code(err) != codes.AlreadyExists {
+					t.Fatalf("Expected AlreadyExists but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Backup job failed",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(fileSystem, nil)
+				mockCloud.EXPECT().StartBackupJob(gomock.Eq(ctx), gomock.Any()).Return(startedJob, nil)
+				mockCloud.EXPECT().DescribeBackupJob(gomock.Eq(ctx), gomock.Eq("job")).Return(&cloud.BackupJob{BackupJobId: "job", State: "FAILED"}, nil)
+
+				_, err := driver.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: snapshotName, SourceVolumeId: fsId})
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("Expected Internal but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	var (
+		endpoint         = "endpoint"
+		recoveryPointArn = "arn:aws:backup:us-east-1:123456789012:recovery-point:abcd"
+	)
+	testCases := []struct {
+		name         string
+		snapshotId   string
+		mockError    error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Success",
+			snapshotId:   recoveryPointArn,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Success: Recovery point not found",
+			snapshotId:   recoveryPointArn,
+			mockError:    cloud.ErrNotFound,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Fail: Snapshot Id is missing",
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Fail: DeleteRecoveryPoint fails",
+			snapshotId:   recoveryPointArn,
+			mockError:    errors.New("recovery point is being created"),
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+
+			driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+			ctx := context.Background()
+			if tc.snapshotId != "" {
+				mockCloud.EXPECT().DeleteRecoveryPoint(gomock.Eq(ctx), gomock.Eq(driver.backupVaultName), gomock.Eq(tc.snapshotId)).Return(tc.mockError)
+			}
+
+			_, err := driver.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: tc.snapshotId})
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("Expected error code %v but got %v", tc.expectedCode, err)
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	var (
+		endpoint    = "endpoint"
+		fsId        = "fs-abcd1234"
+		volumeId    = fsId + "::fsap-abcd1234xyz987"
+		fsArn       = "arn:aws:elasticfilesystem:us-east-1:123456789012:file-system/" + fsId
+		nextToken   = "next"
+		snapshotOne = &cloud.RecoveryPoint{
+			RecoveryPointArn: "arn:aws:backup:us-east-1:123456789012:recovery-point:1",
+			Status:           "COMPLETED",
+			Tags:             map[string]string{DefaultTagKey: DefaultTagValue, SourceVolumeIdTagKey: volumeId},
+		}
+		snapshotTwo = &cloud.RecoveryPoint{
+			RecoveryPointArn: "arn:aws:backup:us-east-1:123456789012:recovery-point:2",
+			Tags:             map[string]string{DefaultTagKey: DefaultTagValue, SourceVolumeIdTagKey: fsId + ":/dir"},
+		}
+		untagged = &cloud.RecoveryPoint{
+			RecoveryPointArn: "arn:aws:backup:us-east-1:123456789012:recovery-point:3",
+			Tags:             map[string]string{},
+		}
+	)
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: All pages are read when max entries is not set",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				gomock.InOrder(
+					mockCloud.EXPECT().ListRecoveryPoints(gomock.Eq(ctx), driver.backupVaultName, "", "", int64(0)).Return([]*cloud.RecoveryPoint{snapshotOne, untagged}, nextToken, nil),
+					mockCloud.EXPECT().ListRecoveryPoints(gomock.Eq(ctx), driver.backupVaultName, "", nextToken, int64(0)).Return([]*cloud.RecoveryPoint{snapshotTwo}, "", nil),
+				)
+
+				res, err := driver.ListSnapshots(ctx, &csi.ListSnapshotsRequest{})
+				if err != nil {
+					t.Fatalf("ListSnapshots failed: %v", err)
+				}
+				if len(res.Entries) != 2 {
+					t.Fatalf("Expected 2 entries, got %d", len(res.Entries))
+				}
+				if !res.Entries[0].Snapshot.ReadyToUse || res.Entries[1].Snapshot.ReadyToUse {
+					t.Fatalf("Only the completed recovery point should be ready to use: %v", res.Entries)
+				}
+				if res.Entries[0].Snapshot.SourceVolumeId != volumeId {
+					t.Fatalf("SourceVolumeId mismatched. Expected: %v, Actual: %v", volumeId, res.Entries[0].Snapshot.SourceVolumeId)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Snapshots are filtered by source volume",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(&cloud.FileSystem{FileSystemId: fsId, FileSystemArn: fsArn}, nil)
+				mockCloud.EXPECT().ListRecoveryPoints(gomock.Eq(ctx), driver.backupVaultName, fsArn, "", int64(1)).Return([]*cloud.RecoveryPoint{snapshotTwo}, nextToken, nil)
+
+				res, err := driver.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SourceVolumeId: volumeId, MaxEntries: 1})
+				if err != nil {
+					t.Fatalf("ListSnapshots failed: %v", err)
+				}
+				if len(res.Entries) != 0 {
+					t.Fatalf("Expected no entries, got %d", len(res.Entries))
+				}
+				if res.NextToken != nextToken {
+					t.Fatalf("Next token mismatched. Expected: %v, Actual: %v", nextToken, res.NextToken)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Snapshot Id is not found",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeRecoveryPoint(gomock.Eq(ctx), driver.backupVaultName, "missing").Return(nil, cloud.ErrNotFound)
+
+				res, err := driver.ListSnapshots(ctx, &csi.ListSnapshotsRequest{SnapshotId: "missing"})
+				if err != nil {
+					t.Fatalf("ListSnapshots failed: %v", err)
+				}
+				if len(res.Entries) != 0 {
+					t.Fatalf("Expected no entries, got %d", len(res.Entries))
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Invalid starting token",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().ListRecoveryPoints(gomock.Eq(ctx), driver.backupVaultName, "", "invalid", int64(0)).Return(nil, "", cloud.ErrInvalidToken)
+
+				_, err := driver.ListSnapshots(ctx, &csi.ListSnapshotsRequest{StartingToken: "invalid"})
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestControllerExpandVolume(t *testing.T) {
+	var (
+		endpoint = "endpoint"
+		fsId     = "fs-abcd1234"
+		apId     = "fsap-abcd1234xyz987"
+		capacity = int64(5 * 1024 * 1024 * 1024)
+	)
+	testCases := []struct {
+		name          string
+		volumeId      string
+		capacityRange *csi.CapacityRange
+		setup         func(ctx context.Context, mockCloud *mocks.MockCloud)
+		expectedCode  codes.Code
+	}{
+		{
+			name:          "Success: Access point volume",
+			volumeId:      fsId + "::" + apId,
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			setup: func(ctx context.Context, mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagAccessPoint(gomock.Eq(ctx), apId, map[string]string{CapacityTagKey: "5368709120"}).Return(nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:          "Success: Directory volume",
+			volumeId:      fsId + ":/dynamic/volume",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			expectedCode:  codes.OK,
+		},
+		{
+			name:          "Success: File system volume",
+			volumeId:      fsId,
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity, LimitBytes: capacity},
+			expectedCode:  codes.OK,
+		},
+		{
+			name:          "Fail: Volume Id is missing",
+			volumeId:      "",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			expectedCode:  codes.InvalidArgument,
+		},
+		{
+			name:         "Fail: Capacity range is missing",
+			volumeId:     fsId + "::" + apId,
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:          "Fail: Capacity range is empty",
+			volumeId:      fsId + "::" + apId,
+			capacityRange: &csi.CapacityRange{},
+			expectedCode:  codes.InvalidArgument,
+		},
+		{
+			name:          "Fail: Required bytes exceed limit bytes",
+			volumeId:      fsId + "::" + apId,
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity, LimitBytes: capacity - 1},
+			expectedCode:  codes.OutOfRange,
+		},
+		{
+			name:          "Fail: Volume Id cannot be parsed",
+			volumeId:      "invalid",
+			capacityRange: &csi.CapacityRange{RequiredBytes: capacity},
+			expectedCode:  codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			mockCloud := mocks.NewMockCloud(mockCtl)
+
+			driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+			ctx := context.Background()
+			if tc.setup != nil {
+				tc.setup(ctx, mockCloud)
+			}
+			res, err := driver.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{VolumeId: tc.volumeId, CapacityRange: tc.capacityRange})
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("Expected error code %v but got %v", tc.expectedCode, err)
+			}
+			if err == nil {
+				if res.CapacityBytes != capacity {
+					t.Fatalf("Capacity mismatched. Expected: %v, Actual: %v", capacity, res.CapacityBytes)
+				}
+				if res.NodeExpansionRequired {
+					t.Fatal("Expected node expansion not to be required")
+				}
+			}
+			mockCtl.Finish()
+		})
+	}
+}
+
+func TestControllerGetCapabilities(t *testing.T) {
+	var endpoint = "endpoint"
+	mockCtl := gomock.NewController(t)
+	mockCloud := mocks.NewMockCloud(mockCtl)
+
+	driver := buildDriver(endpoint, mockCloud, "", nil, false, false)
+
+	ctx := context.Background()
+	_, err := driver.ControllerGetCapabilities(ctx, &csi.ControllerGetCapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("ControllerGetCapabilities failed: %v", err)
+	}
+}
+
+func buildDriver(endpoint string, cloud cloud.Cloud, tags string, mounter Mounter, deleteAccessPointRootDir bool, deleteProvisionedDir bool) *Driver {
+	parsedTags := parseTagsFromStr(tags)
+
+	driver := &Driver{
+		endpoint:          endpoint,
 		cloud:             cloud,
+		controllerCaps:    controllerCaps,
 		provisioners:      getProvisioners(parsedTags, cloud, deleteAccessPointRootDir, mounter, &FakeOsClient{}, deleteProvisionedDir),
 		tags:              parsedTags,
 		mounter:           mounter,
+		backupVaultName:   "Default",
 		fsIdentityManager: NewFileSystemIdentityManager(),
+		inFlight:          NewInFlight(),
 	}
 	return driver
 }
+
+// readDirOsClient behaves like FakeOsClient except that every directory is read from dir.
+type readDirOsClient struct {
+	FakeOsClient
+	dir string
+}
+
+func (o *readDirOsClient) ReadDir(_ string) ([]os.DirEntry, error) {
+	return os.ReadDir(o.dir)
+}
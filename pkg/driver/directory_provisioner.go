@@ -2,13 +2,13 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
 	"strconv"
 	"strings"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
-	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/klog"
@@ -23,7 +23,7 @@ type DirectoryProvisioner struct {
 	deleteProvisionedDir bool
 }
 
-func (d DirectoryProvisioner) Provision(ctx context.Context, req *csi.CreateVolumeRequest, uid, gid int) (*csi.Volume, error) {
+func (d DirectoryProvisioner) Provision(ctx context.Context, req *csi.CreateVolumeRequest, uid, gid int) (v *csi.Volume, e error) {
 	var provisionedPath string
 
 	var fileSystemId string
@@ -38,7 +38,25 @@ func (d DirectoryProvisioner) Provision(ctx context.Context, req *csi.CreateVolu
 	}
 	klog.V(5).Infof("Provisioning directory on FileSystem %s...", fileSystemId)
 
-	localCloud, roleArn, err := getCloud(d.cloud, req.GetSecrets())
+	localCloud, roleArn, err := getCloud(ctx, d.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "File System does not exist: %v", err)
+		}
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+
+	accessibleTopology, err := getAccessibleTopology(fileSystem, req.GetAccessibilityRequirements())
+	if err != nil {
+		return nil, err
+	}
+
+	sourcePath, err := getCloneSourcePath(ctx, localCloud, req, fileSystemId)
 	if err != nil {
 		return nil, err
 	}
@@ -47,55 +65,68 @@ func (d DirectoryProvisioner) Provision(ctx context.Context, req *csi.CreateVolu
 	if err != nil {
 		return nil, err
 	}
-	target := TempMountPathPrefix + "/" + uuid.New().String()
-	if err := d.mounter.MakeDir(target); err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	// Extract the basePath
+	var basePath string
+	if value, ok := volumeParams[BasePath]; ok {
+		basePath = value
 	}
-	if err := d.mounter.Mount(fileSystemId, target, "efs", mountOptions); err == nil {
-		// Extract the basePath
-		var basePath string
-		if value, ok := volumeParams[BasePath]; ok {
-			basePath = value
-		}
 
-		rootDirName := req.Name
-		provisionedPath = basePath + "/" + rootDirName
+	rootDirName := req.Name
+	provisionedPath = basePath + "/" + rootDirName
 
-		klog.V(5).Infof("Provisioning directory at path %s", provisionedPath)
+	if sourcePath != "" {
+		if err := checkCloneTarget(sourcePath, provisionedPath); err != nil {
+			return nil, err
+		}
+	}
 
-		// Grab the required permissions
-		perms := os.FileMode(0777)
-		if value, ok := volumeParams[DirectoryPerms]; ok {
-			parsedPerms, err := strconv.ParseUint(value, 8, 32)
-			if err == nil {
-				perms = os.FileMode(parsedPerms)
-			}
+	target := tempMounts.add(TempMountPathPrefix)
+	defer tempMounts.release(target)
+	if err := d.mounter.MakeDir(target); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	}
+	if err := d.mounter.Mount(fileSystemId, target, "efs", mountOptions); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", fileSystemId, target, err)
+	}
+
+	defer func() {
+		if err := d.mounter.Unmount(target); err != nil {
+			v, e = nil, status.Errorf(codes.Internal, "Could not unmount %q: %v", target, err)
+		} else if err := d.osClient.RemoveAll(target); err != nil {
+			v, e = nil, status.Errorf(codes.Internal, "Could not delete %q: %v", target, err)
 		}
+	}()
 
-		klog.V(5).Infof("Provisioning directory with permissions %s", perms)
+	klog.V(5).Infof("Provisioning directory at path %s", provisionedPath)
 
-		provisionedDirectory := path.Join(target, provisionedPath)
-		err := d.osClient.MkDirAllWithPermsNoOwnership(provisionedDirectory, perms)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Could not provision directory: %v", err)
+	// Grab the required permissions
+	perms := os.FileMode(0777)
+	if value, ok := volumeParams[DirectoryPerms]; ok {
+		parsedPerms, err := strconv.ParseUint(value, 8, 32)
+		if err == nil {
+			perms = os.FileMode(parsedPerms)
 		}
-	} else {
-		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", fileSystemId, target, err)
 	}
 
-	err = d.mounter.Unmount(target)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not unmount %q: %v", target, err)
+	klog.V(5).Infof("Provisioning directory with permissions %s", perms)
+
+	provisionedDirectory := path.Join(target, provisionedPath)
+	if err := d.osClient.MkDirAllWithPermsNoOwnership(provisionedDirectory, perms); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not provision directory: %v", err)
 	}
-	err = d.osClient.RemoveAll(target)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not delete %q: %v", target, err)
+
+	if sourcePath != "" {
+		klog.V(5).Infof("Cloning %s into %s", sourcePath, provisionedPath)
+		if err := d.osClient.CopyTree(ctx, path.Join(target, sourcePath), provisionedDirectory, -1, -1); err != nil {
+			return nil, copyErrorToStatus(err, sourcePath, provisionedPath)
+		}
 	}
 
 	return &csi.Volume{
-		CapacityBytes: req.GetCapacityRange().GetRequiredBytes(),
-		VolumeId:      fileSystemId + ":" + provisionedPath,
-		VolumeContext: map[string]string{},
+		CapacityBytes:      req.GetCapacityRange().GetRequiredBytes(),
+		VolumeId:           fileSystemId + ":" + provisionedPath,
+		VolumeContext:      map[string]string{},
+		AccessibleTopology: accessibleTopology,
 	}, nil
 }
 
@@ -105,7 +136,7 @@ func (d DirectoryProvisioner) Delete(ctx context.Context, req *csi.DeleteVolumeR
 	}
 	fileSystemId, subpath, _, _ := parseVolumeId(req.GetVolumeId())
 
-	localCloud, roleArn, err := getCloud(d.cloud, req.GetSecrets())
+	localCloud, roleArn, err := getCloud(ctx, d.cloud, req.GetSecrets())
 	if err != nil {
 		return err
 	}
@@ -115,7 +146,8 @@ func (d DirectoryProvisioner) Delete(ctx context.Context, req *csi.DeleteVolumeR
 		return err
 	}
 
-	target := TempMountPathPrefix + "/" + uuid.New().String()
+	target := tempMounts.add(TempMountPathPrefix)
+	defer tempMounts.release(target)
 	if err := d.mounter.MakeDir(target); err != nil {
 		return status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
 	}
@@ -144,3 +176,100 @@ func (d DirectoryProvisioner) Delete(ctx context.Context, req *csi.DeleteVolumeR
 
 	return nil
 }
+
+// GetVolume reports the condition of the file system of the volume. The directory itself is not checked, as that would
+// take mounting the whole file system on every poll.
+func (d DirectoryProvisioner) GetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	volId := req.GetVolumeId()
+	fileSystemId, _, _, _ := parseVolumeId(volId)
+
+	localCloud, _, err := getFileSystemCloud(ctx, d.cloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeCondition, err := getFileSystemCondition(ctx, localCloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volId,
+			VolumeContext: map[string]string{},
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			VolumeCondition: volumeCondition,
+		},
+	}, nil
+}
+
+// List returns the directories under the base path of the storage class, which is where its volumes are provisioned.
+// Directories are not AWS resources, so the file system is mounted to read them.
+func (d DirectoryProvisioner) List(ctx context.Context, params map[string]string) (volumes []*csi.Volume, e error) {
+	fileSystemId := params[FsId]
+	if fileSystemId == "" {
+		return nil, nil
+	}
+	basePath := params[BasePath]
+
+	localCloud, roleArn, err := getFileSystemCloud(ctx, d.cloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := localCloud.DescribeFileSystem(ctx, fileSystemId); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+
+	mountOptions, err := getMountOptions(ctx, localCloud, fileSystemId, roleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	target := tempMounts.add(TempMountPathPrefix)
+	defer tempMounts.release(target)
+	if err := d.mounter.MakeDir(target); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	}
+	if err := d.mounter.Mount(fileSystemId, target, "efs", mountOptions); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", fileSystemId, target, err)
+	}
+
+	defer func() {
+		if err := d.mounter.Unmount(target); err != nil {
+			volumes, e = nil, status.Errorf(codes.Internal, "Could not unmount %q: %v", target, err)
+		} else if err := d.osClient.RemoveAll(target); err != nil {
+			volumes, e = nil, status.Errorf(codes.Internal, "Could not delete %q: %v", target, err)
+		}
+	}()
+
+	entries, err := d.osClient.ReadDir(path.Join(target, basePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, status.Errorf(codes.Internal, "Could not read directory %q: %v", basePath, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		volumes = append(volumes, &csi.Volume{
+			VolumeId:      fileSystemId + ":" + basePath + "/" + entry.Name(),
+			VolumeContext: map[string]string{},
+		})
+	}
+	return volumes, nil
+}
+
+// Expand accepts any capacity, as directories have no size and nothing to record it on besides the PV.
+func (d DirectoryProvisioner) Expand(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+	return &csi.ControllerExpandVolumeResponse{
+		CapacityBytes:         getExpandCapacity(req.GetCapacityRange()),
+		NodeExpansionRequired: false,
+	}, nil
+}
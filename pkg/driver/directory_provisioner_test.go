@@ -14,6 +14,7 @@ import (
 	"google.golang.org/grpc/status"
 	"k8s.io/mount-utils"
 
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
 )
 
@@ -65,7 +66,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:    nil,
+					cloud:    cloud.NewFakeCloudProvider(),
 					mounter:  mockMounter,
 					osClient: &FakeOsClient{},
 				}
@@ -112,7 +113,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:   nil,
+					cloud:   cloud.NewFakeCloudProvider(),
 					mounter: mockMounter,
 				}
 
@@ -151,7 +152,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:   nil,
+					cloud:   cloud.NewFakeCloudProvider(),
 					mounter: mockMounter,
 				}
 
@@ -193,7 +194,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:   nil,
+					cloud:   cloud.NewFakeCloudProvider(),
 					mounter: mockMounter,
 				}
 
@@ -236,7 +237,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:   nil,
+					cloud:   cloud.NewFakeCloudProvider(),
 					mounter: mockMounter,
 				}
 
@@ -257,6 +258,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				mockMounter := mocks.NewMockMounter(mockCtl)
 				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
 				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
 
 				ctx := context.Background()
 
@@ -279,7 +281,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:    nil,
+					cloud:    cloud.NewFakeCloudProvider(),
 					mounter:  mockMounter,
 					osClient: &BrokenOsClient{},
 				}
@@ -324,7 +326,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:    nil,
+					cloud:    cloud.NewFakeCloudProvider(),
 					mounter:  mockMounter,
 					osClient: &FakeOsClient{},
 				}
@@ -346,6 +348,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				mockMounter := mocks.NewMockMounter(mockCtl)
 				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
 				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
 
 				ctx := context.Background()
 
@@ -368,7 +371,7 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 
 				dProv := DirectoryProvisioner{
-					cloud:    nil,
+					cloud:    cloud.NewFakeCloudProvider(),
 					mounter:  mockMounter,
 					osClient: &BrokenOsClient{},
 				}
@@ -383,6 +386,184 @@ func TestDirectoryProvisioner_Provision(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Success: Clone copies the source directory into the provisioned directory",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+
+				ctx := context.Background()
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					CapacityRange: &csi.CapacityRange{
+						RequiredBytes: capacityRange,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: DirectoryMode,
+						FsId:             fsId,
+						DirectoryPerms:   "777",
+						BasePath:         "/dynamic",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId + ":/dynamic/source",
+							},
+						},
+					},
+				}
+
+				dProv := DirectoryProvisioner{
+					cloud:    cloud.NewFakeCloudProvider(),
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				volume, err := dProv.Provision(ctx, req, -1, -1)
+
+				if err != nil {
+					t.Fatalf("Expected provision call to succeed but failed: %v", err)
+				}
+
+				expectedVolumeId := fmt.Sprintf("%s:/dynamic/%s", fsId, req.Name)
+				if volume.VolumeId != expectedVolumeId {
+					t.Fatalf("Expected volumeId to be %s but was %s", expectedVolumeId, volume.VolumeId)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Clone source is on another file system",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				ctx := context.Background()
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: DirectoryMode,
+						FsId:             fsId,
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: "fs-efgh5678:/dynamic/source",
+							},
+						},
+					},
+				}
+
+				dProv := DirectoryProvisioner{
+					cloud:    cloud.NewFakeCloudProvider(),
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				_, err := dProv.Provision(ctx, req, -1, -1)
+
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Clone into a subdirectory of the source",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				ctx := context.Background()
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: DirectoryMode,
+						FsId:             fsId,
+						BasePath:         "/dynamic",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId,
+							},
+						},
+					},
+				}
+
+				dProv := DirectoryProvisioner{
+					cloud:    cloud.NewFakeCloudProvider(),
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				_, err := dProv.Provision(ctx, req, -1, -1)
+
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Failed clone unmounts the file system",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Mount(fsId, gomock.Any(), "efs", gomock.Any()).Return(nil)
+				mockMounter.EXPECT().Unmount(gomock.Any()).Return(nil)
+
+				ctx := context.Background()
+
+				req := &csi.CreateVolumeRequest{
+					Name: volumeName,
+					VolumeCapabilities: []*csi.VolumeCapability{
+						stdVolCap,
+					},
+					Parameters: map[string]string{
+						ProvisioningMode: DirectoryMode,
+						FsId:             fsId,
+						BasePath:         "/dynamic",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: fsId + ":/dynamic/source",
+							},
+						},
+					},
+				}
+
+				dProv := DirectoryProvisioner{
+					cloud:    cloud.NewFakeCloudProvider(),
+					mounter:  mockMounter,
+					osClient: &copyFailingOsClient{},
+				}
+
+				_, err := dProv.Provision(ctx, req, -1, -1)
+
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("Expected Internal error but instead got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, test.testFunc)
@@ -574,3 +755,83 @@ func TestDirectoryProvisioner_Delete(t *testing.T) {
 		t.Run(test.name, test.testFunc)
 	}
 }
+
+func TestDirectoryProvisioner_GetVolume(t *testing.T) {
+	var (
+		fsId     = "fs-abcd1234"
+		volumeId = fmt.Sprintf("%s:%s", fsId, "/dynamic/newDir")
+	)
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Available file system is reported as normal without mounting",
+			testFunc: func(t *testing.T) {
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(&cloud.FileSystem{FileSystemId: fsId, LifeCycleState: FileSystemAvailable}, nil)
+				mockCloud.EXPECT().DescribeMountTargets(gomock.Eq(ctx), fsId, "").Return(&cloud.MountTarget{}, nil)
+
+				dProv := DirectoryProvisioner{
+					cloud:    mockCloud,
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				res, err := dProv.GetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volumeId})
+				if err != nil {
+					t.Fatalf("Expected success but found %v", err)
+				}
+				if res.Volume.VolumeId != volumeId {
+					t.Fatalf("Volume Id mismatched. Expected: %v, Actual: %v", volumeId, res.Volume.VolumeId)
+				}
+				if res.Status.VolumeCondition.Abnormal {
+					t.Fatalf("Expected volume condition to be normal but was %v", res.Status.VolumeCondition.Message)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: File system that is not available is reported as abnormal without mounting",
+			testFunc: func(t *testing.T) {
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), fsId).Return(&cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "deleting"}, nil)
+
+				dProv := DirectoryProvisioner{
+					cloud:    mockCloud,
+					mounter:  mockMounter,
+					osClient: &FakeOsClient{},
+				}
+
+				res, err := dProv.GetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volumeId})
+				if err != nil {
+					t.Fatalf("Expected success but found %v", err)
+				}
+				if !res.Status.VolumeCondition.Abnormal {
+					t.Fatal("Expected volume condition to be abnormal")
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
+
+// copyFailingOsClient behaves like FakeOsClient except that every copy fails.
+type copyFailingOsClient struct {
+	FakeOsClient
+}
+
+func (o *copyFailingOsClient) CopyTree(_ context.Context, _, _ string, _, _ int) error {
+	return &os.PathError{Op: "open", Path: "source", Err: os.ErrPermission}
+}
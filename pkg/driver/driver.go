@@ -18,11 +18,14 @@ package driver
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"path/filepath"
 	"strings"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
@@ -33,50 +36,97 @@ const (
 	driverName = "efs.csi.aws.com"
 )
 
+// Mode is the service the driver is deployed for. Both services are always registered, but the background tasks of a
+// service only run in the modes it is deployed for.
+type Mode string
+
+const (
+	ControllerMode Mode = "controller"
+	NodeMode       Mode = "node"
+	AllMode        Mode = "all"
+)
+
+// ParseMode returns the mode named s.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ControllerMode, NodeMode, AllMode:
+		return mode, nil
+	}
+	return "", fmt.Errorf("mode must be %q, %q or %q, got %q", ControllerMode, NodeMode, AllMode, s)
+}
+
 type Driver struct {
 	endpoint                 string
+	mode                     Mode
 	nodeID                   string
+	availabilityZone         string
 	srv                      *grpc.Server
 	mounter                  Mounter
 	efsWatchdog              Watchdog
 	provisioners             map[string]Provisioner
 	cloud                    cloud.Cloud
+	pluginCaps               []*csi.PluginCapability
+	controllerCaps           []csi.ControllerServiceCapability_RPC_Type
 	nodeCaps                 []csi.NodeServiceCapability_RPC_Type
 	volMetricsOptIn          bool
+	nodeStageOptIn           bool
+	sharedMounts             *SharedMountManager
+	publishTracker           *PublishTracker
+	inFlight                 *InFlight
 	volMetricsRefreshPeriod  float64
 	volMetricsFsRateLimit    int
 	volStatter               VolStatter
+	quotaEnforcer            *QuotaEnforcer
+	kubeClient               kubernetes.Interface
 	fsIdentityManager        FileSystemIdentityManager
 	deleteAccessPointRootDir bool
 	tags                     map[string]string
+	backupVaultName          string
 }
 
-func NewDriver(endpoint, efsUtilsCfgPath, efsUtilsStaticFilesPath, tags string, volMetricsOptIn bool, volMetricsRefreshPeriod float64, volMetricsFsRateLimit int, deleteAccessPointRootDir bool, deleteProvisionedDir bool) *Driver {
+func NewDriver(endpoint, efsUtilsCfgPath, efsUtilsStaticFilesPath, tags string, volMetricsOptIn bool, volMetricsRefreshPeriod float64, volMetricsFsRateLimit int, nodeStageOptIn, sharedMountsOptIn bool, deleteAccessPointRootDir bool, deleteProvisionedDir bool, backupVaultName string, mode Mode) *Driver {
 	cloud, err := cloud.NewCloud()
 	if err != nil {
 		klog.Fatalln(err)
 	}
 
-	nodeCaps := SetNodeCapOptInFeatures(volMetricsOptIn)
+	nodeCaps := SetNodeCapOptInFeatures(volMetricsOptIn, nodeStageOptIn)
 	watchdog := newExecWatchdog(efsUtilsCfgPath, efsUtilsStaticFilesPath, "amazon-efs-mount-watchdog")
 	parsedTags := parseTagsFromStr(strings.TrimSpace(tags))
 	mounter := newNodeMounter()
 	provisioners := getProvisioners(parsedTags, cloud, deleteAccessPointRootDir, mounter, &RealOsClient{}, deleteProvisionedDir)
+	volStatter := NewVolStatter(filepath.Join(efsUtilsCfgPath, usageCheckpointDirName))
+	kubeClient := newKubernetesClient()
+	var sharedMounts *SharedMountManager
+	if sharedMountsOptIn {
+		sharedMounts = NewSharedMountManager(mounter, sharedMountsDir)
+	}
 
 	return &Driver{
 		endpoint:                endpoint,
+		mode:                    mode,
 		nodeID:                  cloud.GetMetadata().GetInstanceID(),
+		availabilityZone:        cloud.GetMetadata().GetAvailabilityZone(),
 		mounter:                 mounter,
 		efsWatchdog:             watchdog,
 		provisioners:            provisioners,
 		cloud:                   cloud,
+		pluginCaps:              pluginCaps,
+		controllerCaps:          controllerCaps,
 		nodeCaps:                nodeCaps,
-		volStatter:              NewVolStatter(),
+		volStatter:              volStatter,
+		quotaEnforcer:           NewQuotaEnforcer(mounter, volStatter, kubeClient, newEventRecorder(kubeClient), volMetricsRefreshPeriod, volMetricsFsRateLimit),
+		kubeClient:              kubeClient,
 		volMetricsOptIn:         volMetricsOptIn,
 		volMetricsRefreshPeriod: volMetricsRefreshPeriod,
 		volMetricsFsRateLimit:   volMetricsFsRateLimit,
+		nodeStageOptIn:          nodeStageOptIn,
+		sharedMounts:            sharedMounts,
+		publishTracker:          NewPublishTracker(mounter, filepath.Join(efsUtilsCfgPath, publishStateFileName)),
 		tags:                    parsedTags,
+		backupVaultName:         backupVaultName,
 		fsIdentityManager:       NewFileSystemIdentityManager(),
+		inFlight:                NewInFlight(),
 	}
 }
 
@@ -99,7 +149,7 @@ func (d *Driver) Run() error {
 		return resp, err
 	}
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(logErr),
+		grpc.ChainUnaryInterceptor(logErr, recordRPCMetrics),
 	}
 	d.srv = grpc.NewServer(opts...)
 
@@ -118,6 +168,32 @@ func (d *Driver) Run() error {
 	klog.Info("Starting reaper")
 	reaper.start()
 
+	klog.Info("Restoring published volumes")
+	if err := d.publishTracker.load(); err != nil {
+		klog.Errorf("Could not restore published volumes: %v", err)
+	}
+	// The PVCs of restored volumes are not known until they are published again
+	for _, volumeId := range d.publishTracker.volumeIds() {
+		volumeUsage.publish(volumeId, "", "")
+	}
+
+	if d.sharedMounts != nil {
+		klog.Info("Restoring shared file system mounts")
+		if err := d.sharedMounts.restore(); err != nil {
+			klog.Errorf("Could not restore shared file system mounts: %v", err)
+		}
+	}
+
+	// Temporary mounts are only made by the controller service
+	if d.mode != NodeMode {
+		klog.Info("Starting temporary mount janitor")
+		NewTempMountJanitor(d.mounter, TempMountPathPrefix).start()
+	}
+
+	klog.Info("Starting quota enforcer")
+	volumeQuota.setEnforcer(d.quotaEnforcer)
+	d.quotaEnforcer.start()
+
 	klog.Infof("Listening for connections on address: %#v", listener.Addr())
 	return d.srv.Serve(listener)
 }
@@ -130,14 +206,18 @@ func (d *Driver) GetProvisioningModes() []string {
 	return keys
 }
 
-func SetNodeCapOptInFeatures(volMetricsOptIn bool) []csi.NodeServiceCapability_RPC_Type {
+func SetNodeCapOptInFeatures(volMetricsOptIn, nodeStageOptIn bool) []csi.NodeServiceCapability_RPC_Type {
 	var nCaps = []csi.NodeServiceCapability_RPC_Type{}
 	if volMetricsOptIn {
 		klog.V(4).Infof("Enabling Node Service capability for Get Volume Stats")
-		nCaps = append(nCaps, csi.NodeServiceCapability_RPC_GET_VOLUME_STATS)
+		nCaps = append(nCaps, csi.NodeServiceCapability_RPC_GET_VOLUME_STATS, csi.NodeServiceCapability_RPC_VOLUME_CONDITION)
 	} else {
 		klog.V(4).Infof("Node Service capability for Get Volume Stats Not enabled")
 	}
+	if nodeStageOptIn {
+		klog.V(4).Infof("Enabling Node Service capability for Stage Unstage Volume")
+		nCaps = append(nCaps, csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME)
+	}
 	return nCaps
 }
 
@@ -284,6 +284,7 @@ func (w *execWatchdog) runLoop(stopCh <-chan struct{}) {
 			if err != nil {
 				klog.Errorf("Process %s exits %s", w.execCmd, err)
 			}
+			watchdogRestartsTotal.Inc()
 		}
 	}
 }
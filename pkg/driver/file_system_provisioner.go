@@ -0,0 +1,340 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
+)
+
+const (
+	lifeCycleStateError = "error"
+	// FileSystemVolumeIdPrefix prefixes the IDs of the volumes provisioned in efs-fs mode, which would otherwise be
+	// bare file system IDs like those of statically provisioned volumes.
+	FileSystemVolumeIdPrefix = "fs:"
+)
+
+type FileSystemProvisioner struct {
+	tags  map[string]string
+	cloud cloud.Cloud
+}
+
+func (f FileSystemProvisioner) Provision(ctx context.Context, req *csi.CreateVolumeRequest, uid, gid int) (*csi.Volume, error) {
+	volumeParams := req.GetParameters()
+	volName := req.GetName()
+	if volName == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume name not provided")
+	}
+	if req.GetVolumeContentSource() != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Volume content source is not supported in %v mode", FileSystemMode)
+	}
+
+	// Volume size is required to match PV to PVC by k8s.
+	// Volume size is not consumed by EFS for any purposes.
+	volSize := req.GetCapacityRange().GetRequiredBytes()
+
+	fileSystemOpts, err := f.deriveFileSystemOptions(volumeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetIds := splitParameter(volumeParams[SubnetIds])
+	if len(subnetIds) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Missing %v parameter", SubnetIds)
+	}
+	securityGroupIds := splitParameter(volumeParams[SecurityGroupIds])
+
+	localCloud, roleArn, err := getCloud(ctx, f.cloud, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
+	fileSystem, err := localCloud.CreateFileSystem(ctx, volName, fileSystemOpts)
+	if err != nil {
+		if errors.Is(err, cloud.ErrAlreadyExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "File System already exists")
+		}
+		return nil, cloudErrorToStatus(err, "Failed to create File System")
+	}
+	fileSystemId := fileSystem.FileSystemId
+	klog.V(5).Infof("CreateVolume: created File System %v for volume %v", fileSystemId, volName)
+
+	// File systems and mount targets take a while to be created. Rather than blocking CreateVolume until they are,
+	// their state is checked once and the call is aborted until they are available: retries of CreateVolume find
+	// the file system by its creation token and pick up where the previous call stopped.
+	if err = checkFileSystemAvailable(ctx, localCloud, fileSystemId); err != nil {
+		return nil, err
+	}
+
+	if value, ok := volumeParams[TransitionToIA]; ok {
+		if err = localCloud.PutLifecycleConfiguration(ctx, fileSystemId, value); err != nil {
+			return nil, cloudErrorToStatus(err, "Failed to set lifecycle policy of File System %v", fileSystemId)
+		}
+	}
+
+	if err = createMountTargets(ctx, localCloud, fileSystemId, subnetIds, securityGroupIds); err != nil {
+		return nil, err
+	}
+
+	if err = checkMountTargetsAvailable(ctx, localCloud, fileSystemId); err != nil {
+		return nil, err
+	}
+
+	volContext := map[string]string{}
+
+	// Fetch mount target Ip for cross-account mount
+	if roleArn != "" {
+		mountTarget, err := localCloud.DescribeMountTargets(ctx, fileSystemId, volumeParams[AzName])
+		if err != nil {
+			klog.Warningf("Failed to describe mount targets for file system %v. Skip using `mounttargetip` mount option: %v", fileSystemId, err)
+		} else {
+			volContext[MountTargetIp] = mountTarget.IPAddress
+		}
+	}
+
+	return &csi.Volume{
+		CapacityBytes: volSize,
+		VolumeId:      FileSystemVolumeIdPrefix + fileSystemId,
+		VolumeContext: volContext,
+	}, nil
+}
+
+func (f FileSystemProvisioner) deriveFileSystemOptions(volumeParams map[string]string) (*cloud.FileSystemOptions, error) {
+	fileSystemOpts := &cloud.FileSystemOptions{
+		Encrypted: true,
+		Tags:      getTags(f.tags),
+	}
+
+	if value, ok := volumeParams[PerformanceMode]; ok {
+		fileSystemOpts.PerformanceMode = value
+	}
+
+	if value, ok := volumeParams[ThroughputMode]; ok {
+		fileSystemOpts.ThroughputMode = value
+	}
+
+	if value, ok := volumeParams[ProvisionedThroughputInMibps]; ok {
+		throughput, err := strconv.ParseFloat(value, 64)
+		if err != nil || throughput <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to parse invalid %v: %v", ProvisionedThroughputInMibps, value)
+		}
+		fileSystemOpts.ProvisionedThroughputInMibps = throughput
+	}
+
+	if value, ok := volumeParams[Encrypted]; ok {
+		encrypted, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Failed to parse invalid %v: %v", Encrypted, value)
+		}
+		fileSystemOpts.Encrypted = encrypted
+	}
+
+	if value, ok := volumeParams[KmsKeyId]; ok {
+		if !fileSystemOpts.Encrypted {
+			return nil, status.Errorf(codes.InvalidArgument, "%v cannot be set on an unencrypted File System", KmsKeyId)
+		}
+		fileSystemOpts.KmsKeyId = value
+	}
+
+	return fileSystemOpts, nil
+}
+
+func (f FileSystemProvisioner) Delete(ctx context.Context, req *csi.DeleteVolumeRequest) error {
+	localCloud, _, err := getCloud(ctx, f.cloud, req.GetSecrets())
+	if err != nil {
+		return err
+	}
+
+	fileSystemId, _, _, _ := parseVolumeId(req.GetVolumeId())
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(5).Infof("DeleteVolume: File System %v not found, returning success", fileSystemId)
+			return nil
+		}
+		return cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+
+	// Never delete a file system that was not created by the driver, even if its volume ID was forged
+	if fileSystem.Tags[DefaultTagKey] != DefaultTagValue {
+		klog.Warningf("DeleteVolume: File System %v was not provisioned by the driver, skipping deletion", fileSystemId)
+		return nil
+	}
+
+	mountTargets, err := localCloud.ListMountTargets(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(5).Infof("DeleteVolume: File System %v not found, returning success", fileSystemId)
+			return nil
+		}
+		return cloudErrorToStatus(err, "Failed to list mount targets of File System %v", fileSystemId)
+	}
+
+	for _, mountTarget := range mountTargets {
+		if err = localCloud.DeleteMountTarget(ctx, mountTarget.MountTargetId); err != nil && !errors.Is(err, cloud.ErrNotFound) {
+			return cloudErrorToStatus(err, "Failed to delete mount target %v", mountTarget.MountTargetId)
+		}
+	}
+
+	// A file system cannot be deleted while it still has mount targets. The call is aborted until they are deleted,
+	// and retries of DeleteVolume delete the file system once they are.
+	if err = checkMountTargetsDeleted(ctx, localCloud, fileSystemId); err != nil {
+		return err
+	}
+
+	if err = localCloud.DeleteFileSystem(ctx, fileSystemId); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(5).Infof("DeleteVolume: File System %v not found, returning success", fileSystemId)
+			return nil
+		}
+		return cloudErrorToStatus(err, "Failed to Delete volume %v", req.GetVolumeId())
+	}
+
+	return nil
+}
+
+func (f FileSystemProvisioner) GetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	volId := req.GetVolumeId()
+	fileSystemId, _, _, _ := parseVolumeId(volId)
+
+	localCloud, _, err := getFileSystemCloud(ctx, f.cloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeCondition, err := getFileSystemCondition(ctx, localCloud, fileSystemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      volId,
+			VolumeContext: map[string]string{},
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			VolumeCondition: volumeCondition,
+		},
+	}, nil
+}
+
+// List returns the file systems created by the driver, i.e. those tagged with DefaultTagKey. The parameters of the
+// storage class do not matter, as the file systems of every efs-fs storage class are listed.
+func (f FileSystemProvisioner) List(ctx context.Context, params map[string]string) ([]*csi.Volume, error) {
+	fileSystems, err := f.cloud.ListFileSystems(ctx)
+	if err != nil {
+		return nil, cloudErrorToStatus(err, "Could not list File Systems")
+	}
+
+	var volumes []*csi.Volume
+	for _, fileSystem := range fileSystems {
+		if fileSystem.Tags[DefaultTagKey] != DefaultTagValue {
+			continue
+		}
+		volumes = append(volumes, &csi.Volume{
+			VolumeId:      FileSystemVolumeIdPrefix + fileSystem.FileSystemId,
+			VolumeContext: map[string]string{},
+		})
+	}
+	return volumes, nil
+}
+
+// Expand accepts any capacity, as EFS file systems are elastic and have no provisioned size.
+func (f FileSystemProvisioner) Expand(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+	return &csi.ControllerExpandVolumeResponse{
+		CapacityBytes:         getExpandCapacity(req.GetCapacityRange()),
+		NodeExpansionRequired: false,
+	}, nil
+}
+
+// createMountTargets creates a mount target in every subnet which does not have one yet, so that a retried
+// CreateVolume call picks up where the previous one stopped.
+func createMountTargets(ctx context.Context, localCloud cloud.Cloud, fileSystemId string, subnetIds, securityGroupIds []string) error {
+	existing, err := localCloud.ListMountTargets(ctx, fileSystemId)
+	if err != nil {
+		return cloudErrorToStatus(err, "Failed to list mount targets of File System %v", fileSystemId)
+	}
+	hasMountTarget := map[string]bool{}
+	for _, mountTarget := range existing {
+		hasMountTarget[mountTarget.SubnetId] = true
+	}
+
+	for _, subnetId := range subnetIds {
+		if hasMountTarget[subnetId] {
+			continue
+		}
+		_, err := localCloud.CreateMountTarget(ctx, fileSystemId, subnetId, securityGroupIds)
+		if err != nil {
+			if errors.Is(err, cloud.ErrAlreadyExists) {
+				// A file system can only have one mount target per availability zone.
+				klog.Warningf("File System %v already has a mount target in the availability zone of subnet %v", fileSystemId, subnetId)
+				continue
+			}
+			return cloudErrorToStatus(err, "Failed to create mount target for File System %v", fileSystemId)
+		}
+	}
+
+	return nil
+}
+
+func checkFileSystemAvailable(ctx context.Context, localCloud cloud.Cloud, fileSystemId string) error {
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		return cloudErrorToStatus(err, "Failed to fetch File System %v info", fileSystemId)
+	}
+	switch fileSystem.LifeCycleState {
+	case FileSystemAvailable:
+		return nil
+	case lifeCycleStateError:
+		return status.Errorf(codes.Internal, "File System %v is in %q state", fileSystemId, fileSystem.LifeCycleState)
+	}
+	return status.Errorf(codes.Aborted, "File System %v is in %q state, waiting for it to become available", fileSystemId, fileSystem.LifeCycleState)
+}
+
+func checkMountTargetsAvailable(ctx context.Context, localCloud cloud.Cloud, fileSystemId string) error {
+	mountTargets, err := localCloud.ListMountTargets(ctx, fileSystemId)
+	if err != nil {
+		return cloudErrorToStatus(err, "Failed to list mount targets of File System %v", fileSystemId)
+	}
+	for _, mountTarget := range mountTargets {
+		if mountTarget.LifeCycleState == lifeCycleStateError {
+			return status.Errorf(codes.Internal, "Mount target %v of File System %v is in %q state", mountTarget.MountTargetId, fileSystemId, mountTarget.LifeCycleState)
+		}
+		if mountTarget.LifeCycleState != FileSystemAvailable {
+			return status.Errorf(codes.Aborted, "Mount target %v of File System %v is in %q state, waiting for it to become available",
+				mountTarget.MountTargetId, fileSystemId, mountTarget.LifeCycleState)
+		}
+	}
+	return nil
+}
+
+func checkMountTargetsDeleted(ctx context.Context, localCloud cloud.Cloud, fileSystemId string) error {
+	mountTargets, err := localCloud.ListMountTargets(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil
+		}
+		return cloudErrorToStatus(err, "Failed to list mount targets of File System %v", fileSystemId)
+	}
+	if len(mountTargets) > 0 {
+		return status.Errorf(codes.Aborted, "File System %v still has %d mount targets, waiting for them to be deleted", fileSystemId, len(mountTargets))
+	}
+	return nil
+}
+
+func splitParameter(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
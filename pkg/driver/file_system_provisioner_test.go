@@ -0,0 +1,461 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
+
+func TestFileSystemProvisioner_Provision(t *testing.T) {
+	var (
+		fsId                = "fs-abcd1234"
+		volumeName          = "volumeName"
+		capacityRange int64 = 5368709120
+		stdVolCap           = &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{},
+			},
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			},
+		}
+		available = &cloud.FileSystem{
+			FileSystemId:   fsId,
+			LifeCycleState: FileSystemAvailable,
+		}
+	)
+
+	newRequest := func(params map[string]string) *csi.CreateVolumeRequest {
+		return &csi.CreateVolumeRequest{
+			Name: volumeName,
+			VolumeCapabilities: []*csi.VolumeCapability{
+				stdVolCap,
+			},
+			CapacityRange: &csi.CapacityRange{
+				RequiredBytes: capacityRange,
+			},
+			Parameters: params,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: File system and mount targets are created",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					PerformanceMode:  "maxIO",
+					ThroughputMode:   "bursting",
+					KmsKeyId:         "key",
+					TransitionToIA:   "AFTER_30_DAYS",
+					SubnetIds:        "subnet-1, subnet-2",
+					SecurityGroupIds: "sg-1",
+				})
+
+				ctx := context.Background()
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, volumeName string, opts *cloud.FileSystemOptions) (*cloud.FileSystem, error) {
+						if !opts.Encrypted || opts.KmsKeyId != "key" || opts.PerformanceMode != "maxIO" || opts.ThroughputMode != "bursting" {
+							t.Fatalf("Unexpected file system options: %+v", opts)
+						}
+						if opts.Tags[DefaultTagKey] != DefaultTagValue {
+							t.Fatalf("Expected file system to be tagged with %v", DefaultTagKey)
+						}
+						return &cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "creating"}, nil
+					})
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(available, nil)
+				mockCloud.EXPECT().PutLifecycleConfiguration(gomock.Eq(ctx), gomock.Eq(fsId), gomock.Eq("AFTER_30_DAYS")).Return(nil)
+				gomock.InOrder(
+					mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, nil),
+					mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return([]*cloud.MountTarget{
+						{MountTargetId: "fsmt-1", SubnetId: "subnet-1", LifeCycleState: FileSystemAvailable},
+						{MountTargetId: "fsmt-2", SubnetId: "subnet-2", LifeCycleState: FileSystemAvailable},
+					}, nil),
+				)
+				mockCloud.EXPECT().CreateMountTarget(gomock.Eq(ctx), gomock.Eq(fsId), gomock.Eq("subnet-1"), gomock.Eq([]string{"sg-1"})).Return(&cloud.MountTarget{}, nil)
+				mockCloud.EXPECT().CreateMountTarget(gomock.Eq(ctx), gomock.Eq(fsId), gomock.Eq("subnet-2"), gomock.Eq([]string{"sg-1"})).Return(&cloud.MountTarget{}, nil)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				volume, err := fsProv.Provision(ctx, req, -1, -1)
+				if err != nil {
+					t.Fatalf("Expected Provision to succeed but it failed: %v", err)
+				}
+				if volume.VolumeId != FileSystemVolumeIdPrefix+fsId {
+					t.Fatalf("Expected volume id %v but got %v", FileSystemVolumeIdPrefix+fsId, volume.VolumeId)
+				}
+				if volume.CapacityBytes != capacityRange {
+					t.Fatalf("Expected capacity %v but got %v", capacityRange, volume.CapacityBytes)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Subnets which already have a mount target are skipped",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					SubnetIds:        "subnet-1,subnet-2",
+				})
+
+				ctx := context.Background()
+				mountTargets := []*cloud.MountTarget{
+					{MountTargetId: "fsmt-1", SubnetId: "subnet-1", LifeCycleState: FileSystemAvailable},
+				}
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).Return(available, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(available, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(mountTargets, nil).Times(2)
+				mockCloud.EXPECT().CreateMountTarget(gomock.Eq(ctx), gomock.Eq(fsId), gomock.Eq("subnet-2"), gomock.Any()).Return(nil, cloud.ErrAlreadyExists)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(ctx, req, -1, -1)
+				if err != nil {
+					t.Fatalf("Expected Provision to succeed but it failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: File system still being created is reported as aborted",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					SubnetIds:        "subnet-1",
+				})
+
+				ctx := context.Background()
+				creating := &cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "creating"}
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).Return(creating, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(creating, nil)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(ctx, req, -1, -1)
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Mount targets still being created are reported as aborted",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					SubnetIds:        "subnet-1",
+				})
+
+				ctx := context.Background()
+				mountTargets := []*cloud.MountTarget{
+					{MountTargetId: "fsmt-1", SubnetId: "subnet-1", LifeCycleState: "creating"},
+				}
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).Return(available, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(available, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(mountTargets, nil).Times(2)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(ctx, req, -1, -1)
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Missing subnet ids",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+				})
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(context.Background(), req, -1, -1)
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: KMS key set on unencrypted file system",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					Encrypted:        "false",
+					KmsKeyId:         "key",
+					SubnetIds:        "subnet-1",
+				})
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(context.Background(), req, -1, -1)
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Invalid provisioned throughput",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode:             FileSystemMode,
+					ProvisionedThroughputInMibps: "fast",
+					SubnetIds:                    "subnet-1",
+				})
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(context.Background(), req, -1, -1)
+				if status.Code(err) != codes.InvalidArgument {
+					t.Fatalf("Expected InvalidArgument but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: CreateFileSystem returns access denied",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					SubnetIds:        "subnet-1",
+				})
+
+				ctx := context.Background()
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).Return(nil, cloud.ErrAccessDenied)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(ctx, req, -1, -1)
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("Expected Unauthenticated but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: File system ends up in error state",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				req := newRequest(map[string]string{
+					ProvisioningMode: FileSystemMode,
+					SubnetIds:        "subnet-1",
+				})
+
+				ctx := context.Background()
+				mockCloud.EXPECT().CreateFileSystem(gomock.Eq(ctx), gomock.Eq(volumeName), gomock.Any()).Return(available, nil)
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(&cloud.FileSystem{FileSystemId: fsId, LifeCycleState: "error"}, nil)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				_, err := fsProv.Provision(ctx, req, -1, -1)
+				if err == nil {
+					t.Fatal("Expected Provision to fail but it succeeded")
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
+
+func TestFileSystemProvisioner_Delete(t *testing.T) {
+	var (
+		fsId    = "fs-abcd1234"
+		volId   = FileSystemVolumeIdPrefix + fsId
+		managed = &cloud.FileSystem{
+			FileSystemId:   fsId,
+			LifeCycleState: FileSystemAvailable,
+			Tags:           map[string]string{DefaultTagKey: DefaultTagValue},
+		}
+	)
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Mount targets are deleted before the file system",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				ctx := context.Background()
+				mountTargets := []*cloud.MountTarget{
+					{MountTargetId: "fsmt-1"},
+					{MountTargetId: "fsmt-2"},
+				}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(managed, nil)
+				gomock.InOrder(
+					mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(mountTargets, nil),
+					mockCloud.EXPECT().DeleteMountTarget(gomock.Eq(ctx), gomock.Eq("fsmt-1")).Return(nil),
+					mockCloud.EXPECT().DeleteMountTarget(gomock.Eq(ctx), gomock.Eq("fsmt-2")).Return(cloud.ErrNotFound),
+					mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, nil),
+					mockCloud.EXPECT().DeleteFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil),
+				)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				err := fsProv.Delete(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				if err != nil {
+					t.Fatalf("Expected Delete to succeed but it failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Mount targets still being deleted are reported as aborted",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				ctx := context.Background()
+				mountTargets := []*cloud.MountTarget{
+					{MountTargetId: "fsmt-1", LifeCycleState: "deleting"},
+				}
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(managed, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(mountTargets, nil).Times(2)
+				mockCloud.EXPECT().DeleteMountTarget(gomock.Eq(ctx), gomock.Eq("fsmt-1")).Return(nil)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				err := fsProv.Delete(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted but got %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: File system not provisioned by the driver is left alone",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(&cloud.FileSystem{FileSystemId: fsId}, nil)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				err := fsProv.Delete(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				if err != nil {
+					t.Fatalf("Expected Delete to succeed but it failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: File system not found",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, cloud.ErrNotFound)
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				err := fsProv.Delete(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				if err != nil {
+					t.Fatalf("Expected Delete to succeed but it failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: DeleteFileSystem fails",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				ctx := context.Background()
+				mockCloud.EXPECT().DescribeFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(managed, nil)
+				mockCloud.EXPECT().ListMountTargets(gomock.Eq(ctx), gomock.Eq(fsId)).Return(nil, nil).Times(2)
+				mockCloud.EXPECT().DeleteFileSystem(gomock.Eq(ctx), gomock.Eq(fsId)).Return(errors.New("file system in use"))
+
+				fsProv := FileSystemProvisioner{
+					cloud: mockCloud,
+				}
+
+				err := fsProv.Delete(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				if err == nil {
+					t.Fatal("Expected Delete to fail but it succeeded")
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
@@ -1,13 +1,15 @@
 package driver
 
 import (
-	"container/heap"
+	"context"
 	"strconv"
 	"sync"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/klog"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 )
 
 type FileSystemIdentityManager struct {
@@ -18,8 +20,10 @@ func NewFileSystemIdentityManager() FileSystemIdentityManager {
 	return FileSystemIdentityManager{NewGidAllocator()}
 }
 
-func (f *FileSystemIdentityManager) GetUidAndGid(rawUid string, rawGid string, rawGidMin string, rawGidMax string,
-	fsId string) (int, int, error) {
+// GetUidAndGid returns the UID and GID for a new access point on fsId. When no GID is given, one is allocated
+// from the GID range; localCloud is used to discover the GIDs already owned by the file system's access points.
+func (f *FileSystemIdentityManager) GetUidAndGid(ctx context.Context, localCloud cloud.Cloud, rawUid string, rawGid string,
+	rawGidMin string, rawGidMax string, fsId string) (int, int, error) {
 
 	var (
 		uid int
@@ -37,7 +41,7 @@ func (f *FileSystemIdentityManager) GetUidAndGid(rawUid string, rawGid string, r
 		if err != nil {
 			return -1, -1, err
 		}
-		allocatedGid, err := f.gidAllocator.getNextGid(fsId, int(gidMin), int(gidMax))
+		allocatedGid, err := f.gidAllocator.getNextGid(ctx, localCloud, fsId, int(gidMin), int(gidMax))
 		if err != nil {
 			return -1, -1, err
 		}
@@ -99,35 +103,61 @@ func (f *FileSystemIdentityManager) extractId(rawId string) (int, error) {
 }
 
 type GidAllocator struct {
-	fsIdGidMap map[string]*IntHeap
+	fsIdGidMap map[string]*GidFreeList
 	mu         sync.Mutex
 }
 
 func NewGidAllocator() GidAllocator {
 	return GidAllocator{
-		fsIdGidMap: make(map[string]*IntHeap),
+		fsIdGidMap: make(map[string]*GidFreeList),
 	}
 }
 
-// Retrieves the next available GID
-func (g *GidAllocator) getNextGid(fsId string, gidMin, gidMax int) (int, error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
+// Retrieves the next available GID.
+// The first time a file system is seen, its state is rebuilt from the GIDs of the existing access points so that
+// a controller restart or leader failover never hands out a GID that is already in use. The access points are listed
+// without holding the lock, so that allocations on other file systems are not held up by the AWS calls.
+func (g *GidAllocator) getNextGid(ctx context.Context, localCloud cloud.Cloud, fsId string, gidMin, gidMax int) (int, error) {
 	klog.V(5).Infof("Recieved getNextGid for fsId: %v, min: %v, max: %v", fsId, gidMin, gidMax)
 
-	if _, ok := g.fsIdGidMap[fsId]; !ok {
+	for {
+		if gid, initialized, err := g.allocate(fsId); initialized {
+			return gid, err
+		}
+
 		klog.V(5).Infof("FS Id doesn't exist, initializing...")
-		g.initFsId(fsId, gidMin, gidMax)
+		freeList, err := loadGidFreeList(ctx, localCloud, fsId, gidMin, gidMax)
+		if err != nil {
+			return 0, err
+		}
+		g.install(fsId, freeList)
 	}
+}
 
-	gidHeap := g.fsIdGidMap[fsId]
+// allocate removes the lowest free GID of fsId, unless the file system has not been initialized yet.
+func (g *GidAllocator) allocate(fsId string) (gid int, initialized bool, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	if gidHeap.Len() > 0 {
-		return heap.Pop(gidHeap).(int), nil
-	} else {
-		return 0, status.Errorf(codes.Internal, "Failed to locate a free GID for given the file system: %v. "+
-			"Please create a new storage class with a new file-system", fsId)
+	freeList, ok := g.fsIdGidMap[fsId]
+	if !ok {
+		return 0, false, nil
+	}
+	if gid, ok := freeList.Allocate(); ok {
+		return gid, true, nil
+	}
+	return 0, true, status.Errorf(codes.Internal, "Failed to locate a free GID for given the file system: %v. "+
+		"Please create a new storage class with a new file-system", fsId)
+}
+
+// install sets the free GIDs of fsId, unless another call initialized the file system meanwhile. Its state is kept,
+// as GIDs may have been allocated from it since.
+func (g *GidAllocator) install(fsId string, freeList *GidFreeList) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.fsIdGidMap[fsId]; !ok {
+		g.fsIdGidMap[fsId] = freeList
 	}
 }
 
@@ -135,15 +165,31 @@ func (g *GidAllocator) releaseGid(fsId string, gid int) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	gidHeap := g.fsIdGidMap[fsId]
-	gidHeap.Push(gid)
+	// Nothing to release into if the file system was never initialized, e.g. the GID was set explicitly.
+	// Its state will be rebuilt from the access points on first use.
+	freeList, ok := g.fsIdGidMap[fsId]
+	if !ok {
+		return
+	}
+	freeList.Release(gid)
 }
 
-// Creates an entry fsIdGidMap if fsId does not exist.
-func (g *GidAllocator) initFsId(fsId string, gidMin, gidMax int) {
-	h := initHeap(gidMin, gidMax)
-	heap.Init(h)
-	g.fsIdGidMap[fsId] = h
+// loadGidFreeList returns the free GIDs of fsId, where GIDs already owned by access points of the file system are
+// marked as used.
+func loadGidFreeList(ctx context.Context, localCloud cloud.Cloud, fsId string, gidMin, gidMax int) (*GidFreeList, error) {
+	accessPoints, err := localCloud.ListAccessPoints(ctx, fsId)
+	if err != nil {
+		return nil, cloudErrorToStatus(err, "Failed to list access points of file system %v", fsId)
+	}
+
+	freeList := NewGidFreeList(gidMin, gidMax)
+	for _, ap := range accessPoints {
+		if ap.PosixUser != nil {
+			freeList.MarkUsed(int(ap.PosixUser.Gid))
+		}
+	}
+	klog.V(5).Infof("Found %d GIDs in use by access points of file system %v", gidMax-gidMin+1-freeList.Len(), fsId)
+	return freeList, nil
 }
 
 func (g *GidAllocator) removeFsId(fsId string) {
@@ -152,37 +198,122 @@ func (g *GidAllocator) removeFsId(fsId string) {
 	delete(g.fsIdGidMap, fsId)
 }
 
-type IntHeap []int
+// GidFreeList keeps track of the free GIDs of an inclusive range with a sparse segment tree, where a missing node
+// stands for a subrange whose GIDs are all free. Its size grows with the number of used GIDs rather than with the size
+// of the range, and every operation walks a single path of the tree, i.e. takes O(log n) in the size of the range.
+// It is not safe for concurrent use.
+type GidFreeList struct {
+	min  int
+	max  int
+	root *gidNode
+}
 
-func (h IntHeap) Len() int {
-	return len(h)
+// gidNode counts the used GIDs of a subrange, whose lower and upper halves are covered by its children.
+type gidNode struct {
+	used  int
+	lower *gidNode
+	upper *gidNode
 }
-func (h IntHeap) Less(i, j int) bool {
-	return h[i] < h[j]
+
+func (n *gidNode) count() int {
+	if n == nil {
+		return 0
+	}
+	return n.used
 }
-func (h IntHeap) Swap(i, j int) {
-	h[i], h[j] = h[j], h[i]
+
+func NewGidFreeList(min, max int) *GidFreeList {
+	return &GidFreeList{
+		min: min,
+		max: max,
+	}
 }
 
-func (h *IntHeap) Push(x interface{}) {
-	*h = append(*h, x.(int))
+// Len returns the number of free GIDs.
+func (l *GidFreeList) Len() int {
+	return l.max - l.min + 1 - l.root.count()
 }
 
-func (h *IntHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
+// Allocate removes and returns the lowest free GID.
+func (l *GidFreeList) Allocate() (int, bool) {
+	if l.Len() == 0 {
+		return 0, false
+	}
+	// Follow the lowest subrange that is not fully used down to a free one
+	node, start, end := l.root, l.min, l.max
+	for node != nil {
+		middle := start + (end-start)/2
+		if node.lower.count() < middle-start+1 {
+			node, end = node.lower, middle
+		} else {
+			node, start = node.upper, middle+1
+		}
+	}
+	l.MarkUsed(start)
+	return start, true
 }
 
-// Initializes a heap inclusive of min & max
-func initHeap(min, max int) *IntHeap {
-	h := make(IntHeap, max-min+1)
-	val := min
-	for i := range h {
-		h[i] = val
-		val += 1
+// MarkUsed removes gid from the free GIDs. GIDs that are out of range or already used are ignored.
+func (l *GidFreeList) MarkUsed(gid int) {
+	if !l.isFree(gid) {
+		return
+	}
+	node, start, end := &l.root, l.min, l.max
+	for {
+		if *node == nil {
+			*node = &gidNode{}
+		}
+		(*node).used++
+		if start == end {
+			return
+		}
+		middle := start + (end-start)/2
+		if gid <= middle {
+			node, end = &(*node).lower, middle
+		} else {
+			node, start = &(*node).upper, middle+1
+		}
+	}
+}
+
+// Release returns gid to the free GIDs. GIDs that are out of range or already free are ignored.
+func (l *GidFreeList) Release(gid int) {
+	if gid < l.min || gid > l.max || l.isFree(gid) {
+		return
+	}
+	node, start, end := &l.root, l.min, l.max
+	for {
+		(*node).used--
+		if (*node).used == 0 {
+			// The whole subrange is free again
+			*node = nil
+			return
+		}
+		middle := start + (end-start)/2
+		if gid <= middle {
+			node, end = &(*node).lower, middle
+		} else {
+			node, start = &(*node).upper, middle+1
+		}
+	}
+}
+
+// isFree reports whether gid is in range and free.
+func (l *GidFreeList) isFree(gid int) bool {
+	if gid < l.min || gid > l.max {
+		return false
+	}
+	node, start, end := l.root, l.min, l.max
+	for node != nil {
+		if node.used == end-start+1 {
+			return false
+		}
+		middle := start + (end-start)/2
+		if gid <= middle {
+			node, end = node.lower, middle
+		} else {
+			node, start = node.upper, middle+1
+		}
 	}
-	return &h
+	return true
 }
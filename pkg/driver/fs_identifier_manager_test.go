@@ -1,6 +1,15 @@
 package driver
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
 
 const fileSystemId = "fs-123456789"
 
@@ -169,7 +178,7 @@ func TestGetUidAndGid(t *testing.T) {
 	for _, test := range tests {
 		fsIdManager := NewFileSystemIdentityManager()
 		t.Run(test.name, func(t *testing.T) {
-			uid, gid, err := fsIdManager.GetUidAndGid(test.rawUid, test.rawGid, test.rawGidMin, test.rawGidMax, fileSystemId)
+			uid, gid, err := fsIdManager.GetUidAndGid(context.Background(), cloud.NewFakeCloudProvider(), test.rawUid, test.rawGid, test.rawGidMin, test.rawGidMax, fileSystemId)
 			if test.expectError {
 				if err == nil {
 					t.Fatalf("Expected error but completed successfully")
@@ -188,3 +197,233 @@ func TestGetUidAndGid(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNextGidSkipsGidsOfExistingAccessPoints(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	ctx := context.Background()
+
+	accessPoints := []*cloud.AccessPoint{
+		{AccessPointId: "fsap-1", FileSystemId: fileSystemId, PosixUser: &cloud.PosixUser{Uid: 1000, Gid: 1000}},
+		{AccessPointId: "fsap-2", FileSystemId: fileSystemId, PosixUser: &cloud.PosixUser{Uid: 1001, Gid: 1001}},
+		{AccessPointId: "fsap-3", FileSystemId: fileSystemId, PosixUser: &cloud.PosixUser{Uid: 0, Gid: 0}},
+		{AccessPointId: "fsap-4", FileSystemId: fileSystemId},
+	}
+	// Access points are only listed the first time the file system is seen
+	mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fileSystemId).Return(accessPoints, nil).Times(1)
+
+	gidAllocator := NewGidAllocator()
+	for _, expected := range []int{1002, 1003} {
+		gid, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003)
+		if err != nil {
+			t.Fatalf("Didn't expect error but found %v", err)
+		}
+		if gid != expected {
+			t.Fatalf("Expected GID to be %d, but was %d", expected, gid)
+		}
+	}
+
+	if _, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003); err == nil {
+		t.Fatalf("Expected error but completed successfully")
+	}
+
+	gidAllocator.releaseGid(fileSystemId, 1002)
+	gid, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003)
+	if err != nil {
+		t.Fatalf("Didn't expect error but found %v", err)
+	}
+	if gid != 1002 {
+		t.Fatalf("Expected released GID 1002 to be reused, but got %d", gid)
+	}
+	mockCtl.Finish()
+}
+
+func TestGetNextGidRetriesInitializationAfterListFailure(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fileSystemId).Return(nil, errors.New("DescribeAccessPoints failed")),
+		mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fileSystemId).Return(nil, nil),
+	)
+
+	gidAllocator := NewGidAllocator()
+	if _, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003); err == nil {
+		t.Fatalf("Expected error but completed successfully")
+	}
+
+	gid, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003)
+	if err != nil {
+		t.Fatalf("Didn't expect error but found %v", err)
+	}
+	if gid != 1000 {
+		t.Fatalf("Expected GID to be %d, but was %d", 1000, gid)
+	}
+	mockCtl.Finish()
+}
+
+func TestGetNextGidDoesNotWaitForOtherFileSystems(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	ctx := context.Background()
+	otherFileSystemId := "fs-efgh5678"
+
+	listing := make(chan struct{})
+	unblock := make(chan struct{})
+	mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), fileSystemId).DoAndReturn(func(_ context.Context, _ string) ([]*cloud.AccessPoint, error) {
+		close(listing)
+		<-unblock
+		return nil, nil
+	})
+	mockCloud.EXPECT().ListAccessPoints(gomock.Eq(ctx), otherFileSystemId).Return(nil, nil)
+
+	gidAllocator := NewGidAllocator()
+	done := make(chan error)
+	go func() {
+		_, err := gidAllocator.getNextGid(ctx, mockCloud, fileSystemId, 1000, 1003)
+		done <- err
+	}()
+	<-listing
+
+	// The access points of the first file system are still being listed
+	gid, err := gidAllocator.getNextGid(ctx, mockCloud, otherFileSystemId, 1000, 1003)
+	if err != nil {
+		t.Fatalf("Didn't expect error but found %v", err)
+	}
+	if gid != 1000 {
+		t.Fatalf("Expected GID to be %d, but was %d", 1000, gid)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("Didn't expect error but found %v", err)
+	}
+	mockCtl.Finish()
+}
+
+func TestReleaseGidOfUnknownFileSystem(t *testing.T) {
+	gidAllocator := NewGidAllocator()
+	// Must not panic when the file system has not been initialized yet
+	gidAllocator.releaseGid(fileSystemId, 1000)
+}
+
+func TestGidFreeList(t *testing.T) {
+	tests := []struct {
+		name      string
+		min       int
+		max       int
+		used      []int
+		released  []int
+		allocated []int
+		free      int
+	}{
+		{
+			name:      "Allocates lowest free GID first",
+			min:       10,
+			max:       14,
+			allocated: []int{10, 11, 12},
+			free:      2,
+		},
+		{
+			name:      "Skips GIDs marked as used",
+			min:       10,
+			max:       14,
+			used:      []int{10, 12, 12, 9, 15},
+			allocated: []int{11, 13, 14},
+			free:      0,
+		},
+		{
+			name:      "Released GIDs are handed out again lowest first",
+			min:       10,
+			max:       20,
+			used:      []int{10, 11, 12, 13, 14},
+			released:  []int{13, 11, 12, 25, 15},
+			allocated: []int{11, 12, 13, 15},
+			free:      5,
+		},
+		{
+			name:      "Fully used range has no free GIDs",
+			min:       1,
+			max:       3,
+			used:      []int{2, 1, 3},
+			allocated: []int{},
+			free:      0,
+		},
+		{
+			name:      "Default range does not need to be materialised",
+			min:       DefaultGidMin,
+			max:       DefaultGidMax,
+			used:      []int{DefaultGidMin + 1},
+			allocated: []int{DefaultGidMin, DefaultGidMin + 2},
+			free:      DefaultGidMax - DefaultGidMin - 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			freeList := NewGidFreeList(test.min, test.max)
+			for _, gid := range test.used {
+				freeList.MarkUsed(gid)
+			}
+			for _, gid := range test.released {
+				freeList.Release(gid)
+			}
+			for _, expected := range test.allocated {
+				gid, ok := freeList.Allocate()
+				if !ok {
+					t.Fatalf("Expected GID %d but no GID was free", expected)
+				}
+				if gid != expected {
+					t.Fatalf("Expected GID to be %d, but was %d", expected, gid)
+				}
+			}
+			if freeList.Len() != test.free {
+				t.Fatalf("Expected %d free GIDs, but found %d", test.free, freeList.Len())
+			}
+			if test.free == 0 {
+				if gid, ok := freeList.Allocate(); ok {
+					t.Fatalf("Expected no free GID, but got %d", gid)
+				}
+			}
+		})
+	}
+}
+
+func TestGidFreeListAcrossLargeRange(t *testing.T) {
+	const count = 100000
+	freeList := NewGidFreeList(DefaultGidMin, DefaultGidMax)
+
+	for i := 0; i < count; i++ {
+		gid, ok := freeList.Allocate()
+		if !ok || gid != DefaultGidMin+i {
+			t.Fatalf("Expected GID %d, but got %d (free: %v)", DefaultGidMin+i, gid, ok)
+		}
+	}
+	freeList.MarkUsed(DefaultGidMax)
+
+	// Release every other GID, from the top down
+	for i := count - 1; i >= 0; i -= 2 {
+		freeList.Release(DefaultGidMin + i)
+	}
+	if expected := DefaultGidMax - DefaultGidMin + 1 - count/2 - 1; freeList.Len() != expected {
+		t.Fatalf("Expected %d free GIDs, but found %d", expected, freeList.Len())
+	}
+
+	for i := 1; i < count; i += 2 {
+		gid, ok := freeList.Allocate()
+		if !ok || gid != DefaultGidMin+i {
+			t.Fatalf("Expected released GID %d, but got %d (free: %v)", DefaultGidMin+i, gid, ok)
+		}
+	}
+	if gid, _ := freeList.Allocate(); gid != DefaultGidMin+count {
+		t.Fatalf("Expected GID %d, but got %d", DefaultGidMin+count, gid)
+	}
+
+	for gid := DefaultGidMin; gid <= DefaultGidMin+count; gid++ {
+		freeList.Release(gid)
+	}
+	freeList.Release(DefaultGidMax)
+	if freeList.Len() != DefaultGidMax-DefaultGidMin+1 || freeList.root != nil {
+		t.Fatalf("Expected every GID to be free and the tree to be empty, but found %d free GIDs", freeList.Len())
+	}
+}
@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"sync"
+)
+
+// operationAlreadyExistsErrorMsg is returned with codes.Aborted when an operation on a volume is requested while
+// another one on the same volume is still in progress, so that the caller retries it later as recommended by the CSI
+// spec.
+const operationAlreadyExistsErrorMsg = "An operation on %s is already in progress"
+
+// InFlight tracks the operations in progress, keyed by the volume ID, name or target path they operate on, so that
+// retries of an operation cannot race with the operation they retry. Every RPC operating on a volume, snapshot or
+// target path is tracked. ListVolumes, ListSnapshots and GetCapacity are not, as they operate on no single volume and
+// only read from AWS, nor are the RPCs that only validate the request or describe the driver.
+type InFlight struct {
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// Insert marks an operation on key as in progress, and returns false when one already is.
+func (i *InFlight) Insert(key string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.inFlight[key]; ok {
+		return false
+	}
+	i.inFlight[key] = struct{}{}
+	return true
+}
+
+// Delete marks the operation on key as done.
+func (i *InFlight) Delete(key string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.inFlight, key)
+}
@@ -0,0 +1,157 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInFlight(t *testing.T) {
+	inFlight := NewInFlight()
+
+	if !inFlight.Insert(volumeId) {
+		t.Fatal("Expected first operation to be inserted")
+	}
+	if inFlight.Insert(volumeId) {
+		t.Fatal("Expected concurrent operation not to be inserted")
+	}
+	if !inFlight.Insert(targetPath) {
+		t.Fatal("Expected operation on another key to be inserted")
+	}
+	inFlight.Delete(volumeId)
+	if !inFlight.Insert(volumeId) {
+		t.Fatal("Expected operation to be inserted once the previous one is done")
+	}
+}
+
+func TestOperationInProgress(t *testing.T) {
+	var (
+		volId   = "fs-abcd1234::fsap-abcd1234"
+		stdCaps = &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{},
+			},
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			},
+		}
+	)
+
+	tests := []struct {
+		name string
+		key  string
+		call func(ctx context.Context, d *Driver) error
+	}{
+		{
+			name: "CreateVolume",
+			key:  "name",
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.CreateVolume(ctx, &csi.CreateVolumeRequest{Name: "name"})
+				return err
+			},
+		},
+		{
+			name: "DeleteVolume",
+			key:  volId,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volId})
+				return err
+			},
+		},
+		{
+			name: "ControllerExpandVolume",
+			key:  volId,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{VolumeId: volId})
+				return err
+			},
+		},
+		{
+			name: "ControllerGetVolume",
+			key:  volId,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.ControllerGetVolume(ctx, &csi.ControllerGetVolumeRequest{VolumeId: volId})
+				return err
+			},
+		},
+		{
+			name: "CreateSnapshot",
+			key:  "snapshot",
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.CreateSnapshot(ctx, &csi.CreateSnapshotRequest{Name: "snapshot", SourceVolumeId: volId})
+				return err
+			},
+		},
+		{
+			name: "DeleteSnapshot",
+			key:  "snapshot-id",
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: "snapshot-id"})
+				return err
+			},
+		},
+		{
+			name: "NodeStageVolume",
+			key:  volId,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{VolumeId: volId, StagingTargetPath: stagingTargetPath, VolumeCapability: stdCaps})
+				return err
+			},
+		},
+		{
+			name: "NodeUnstageVolume",
+			key:  volId,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{VolumeId: volId, StagingTargetPath: stagingTargetPath})
+				return err
+			},
+		},
+		{
+			name: "NodePublishVolume",
+			key:  targetPath,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{VolumeId: volId, TargetPath: targetPath, VolumeCapability: stdCaps})
+				return err
+			},
+		},
+		{
+			name: "NodeUnpublishVolume",
+			key:  targetPath,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: volId, TargetPath: targetPath})
+				return err
+			},
+		},
+		{
+			name: "NodeGetVolumeStats",
+			key:  targetPath,
+			call: func(ctx context.Context, d *Driver) error {
+				_, err := d.NodeGetVolumeStats(ctx, &csi.NodeGetVolumeStatsRequest{VolumeId: volId, VolumePath: targetPath})
+				return err
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			// Neither the cloud nor the mounter is called, as the operation is rejected upfront
+			_, driver, ctx := setup(mockCtl, NewVolStatter(""), false)
+			driver.inFlight.Insert(test.key)
+
+			err := test.call(ctx, driver)
+			if status.Code(err) != codes.Aborted {
+				t.Fatalf("Expected Aborted error, got %v", err)
+			}
+
+			// The operation is not marked as done by the rejected call
+			if driver.inFlight.Insert(test.key) {
+				t.Fatal("Expected operation to still be in progress")
+			}
+		})
+	}
+}
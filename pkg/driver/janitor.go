@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+// Resources cleaned up by the janitor, as reported by its metrics
+const (
+	janitorMountResource     = "mount"
+	janitorDirectoryResource = "directory"
+)
+
+// janitorInterval is how often the janitor looks for orphaned temporary mounts. It also runs on startup.
+var janitorInterval = 10 * time.Minute
+
+// tempMounts are the temporary mounts in use by the controller operations of the process.
+var tempMounts = newTempMountRegistry()
+
+// tempMountRegistry tracks the temporary mounts in use, so that the janitor never cleans up a mount an operation still
+// uses. Paths are registered before their directory is created and released once it is removed, so that a path found
+// under the directory that is not registered is orphaned. Paths are named after unique IDs and never reused.
+type tempMountRegistry struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+func newTempMountRegistry() *tempMountRegistry {
+	return &tempMountRegistry{
+		paths: make(map[string]struct{}),
+	}
+}
+
+// add returns a new path under dir registered as in use, which must be released once its directory is removed.
+func (r *tempMountRegistry) add(dir string) string {
+	path := dir + "/" + uuid.New().String()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[path] = struct{}{}
+	return path
+}
+
+func (r *tempMountRegistry) release(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.paths, path)
+}
+
+func (r *tempMountRegistry) inUse(path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.paths[path]
+	return ok
+}
+
+// TempMountJanitor cleans up the temporary mounts and directories left under TempMountPathPrefix by controller
+// operations that failed or crashed between mounting and unmounting a file system.
+type TempMountJanitor struct {
+	mounter Mounter
+	mounts  *tempMountRegistry
+	dir     string
+}
+
+func NewTempMountJanitor(mounter Mounter, dir string) *TempMountJanitor {
+	return &TempMountJanitor{
+		mounter: mounter,
+		mounts:  tempMounts,
+		dir:     dir,
+	}
+}
+
+func (j *TempMountJanitor) start() {
+	go wait.Forever(j.cleanup, janitorInterval)
+}
+
+func (j *TempMountJanitor) cleanup() {
+	paths, err := j.list()
+	if err != nil {
+		klog.Errorf("Janitor: could not list %s: %v", j.dir, err)
+		return
+	}
+	var orphans []string
+	for _, path := range paths {
+		if j.mounts.inUse(path) {
+			klog.V(4).Infof("Janitor: temporary mount %s is in use, skipping it", path)
+			continue
+		}
+		orphans = append(orphans, path)
+	}
+	if len(orphans) == 0 {
+		return
+	}
+
+	mountPoints, err := j.mounter.List()
+	if err != nil {
+		klog.Errorf("Janitor: could not list mounts: %v", err)
+		return
+	}
+	mounted := make(map[string]struct{})
+	for _, mp := range mountPoints {
+		mounted[mp.Path] = struct{}{}
+	}
+
+	for _, orphan := range orphans {
+		if _, ok := mounted[orphan]; ok {
+			klog.Infof("Janitor: unmounting orphaned temporary mount %s", orphan)
+			if err := j.mounter.Unmount(orphan); err != nil {
+				klog.Errorf("Janitor: could not unmount %s: %v", orphan, err)
+				janitorErrorsTotal.WithLabelValues(janitorMountResource).Inc()
+				continue
+			}
+			janitorCleanedTotal.WithLabelValues(janitorMountResource).Inc()
+		}
+
+		// The directory is removed without its content, which would be that of the file system were it still mounted
+		klog.Infof("Janitor: removing orphaned temporary directory %s", orphan)
+		if err := os.Remove(orphan); err != nil {
+			// The operation that used it removed it since it was listed
+			if os.IsNotExist(err) {
+				continue
+			}
+			klog.Errorf("Janitor: could not remove %s: %v", orphan, err)
+			janitorErrorsTotal.WithLabelValues(janitorDirectoryResource).Inc()
+			continue
+		}
+		janitorCleanedTotal.WithLabelValues(janitorDirectoryResource).Inc()
+	}
+}
+
+// list returns the paths under the directory. They are not stat'ed, which could hang on the mount of an unreachable
+// file system.
+func (j *TempMountJanitor) list() ([]string, error) {
+	dir, err := os.Open(j.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, filepath.Join(j.dir, name))
+	}
+	return paths, nil
+}
@@ -0,0 +1,131 @@
+package driver
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/mount-utils"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
+
+func TestTempMountJanitor(t *testing.T) {
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T, dir string)
+	}{
+		{
+			name: "Success: Orphaned mounts are unmounted and their directories removed",
+			testFunc: func(t *testing.T, dir string) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mounted := filepath.Join(dir, "mounted")
+				unmounted := filepath.Join(dir, "unmounted")
+				for _, path := range []string{mounted, unmounted} {
+					if err := os.Mkdir(path, 0755); err != nil {
+						t.Fatalf("Failed to create directory: %v", err)
+					}
+				}
+
+				cleanedMounts := testutil.ToFloat64(janitorCleanedTotal.WithLabelValues(janitorMountResource))
+				cleanedDirs := testutil.ToFloat64(janitorCleanedTotal.WithLabelValues(janitorDirectoryResource))
+
+				mockMounter.EXPECT().List().Return([]mount.MountPoint{{Path: mounted}, {Path: "/other"}}, nil)
+				mockMounter.EXPECT().Unmount(mounted).Return(nil)
+
+				NewTempMountJanitor(mockMounter, dir).cleanup()
+
+				for _, path := range []string{mounted, unmounted} {
+					if _, err := os.Stat(path); !os.IsNotExist(err) {
+						t.Fatalf("Expected %s to be removed, got %v", path, err)
+					}
+				}
+				if cleaned := testutil.ToFloat64(janitorCleanedTotal.WithLabelValues(janitorMountResource)) - cleanedMounts; cleaned != 1 {
+					t.Fatalf("Expected 1 mount to be cleaned up, got %v", cleaned)
+				}
+				if cleaned := testutil.ToFloat64(janitorCleanedTotal.WithLabelValues(janitorDirectoryResource)) - cleanedDirs; cleaned != 2 {
+					t.Fatalf("Expected 2 directories to be cleaned up, got %v", cleaned)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Temporary mounts in use are kept",
+			testFunc: func(t *testing.T, dir string) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				inUse := tempMounts.add(dir)
+				defer tempMounts.release(inUse)
+				orphan := filepath.Join(dir, "orphan")
+				for _, path := range []string{inUse, orphan} {
+					if err := os.Mkdir(path, 0755); err != nil {
+						t.Fatalf("Failed to create directory: %v", err)
+					}
+				}
+
+				mockMounter.EXPECT().List().Return([]mount.MountPoint{{Path: inUse}}, nil)
+
+				NewTempMountJanitor(mockMounter, dir).cleanup()
+
+				if _, err := os.Stat(inUse); err != nil {
+					t.Fatalf("Expected %s to be kept, got %v", inUse, err)
+				}
+				if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+					t.Fatalf("Expected %s to be removed, got %v", orphan, err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Directory of a mount that cannot be unmounted is kept",
+			testFunc: func(t *testing.T, dir string) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				mounted := filepath.Join(dir, "mounted")
+				if err := os.Mkdir(mounted, 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+
+				failures := testutil.ToFloat64(janitorErrorsTotal.WithLabelValues(janitorMountResource))
+
+				mockMounter.EXPECT().List().Return([]mount.MountPoint{{Path: mounted}}, nil)
+				mockMounter.EXPECT().Unmount(mounted).Return(errors.New("device is busy"))
+
+				NewTempMountJanitor(mockMounter, dir).cleanup()
+
+				if _, err := os.Stat(mounted); err != nil {
+					t.Fatalf("Expected %s to be kept, got %v", mounted, err)
+				}
+				if failed := testutil.ToFloat64(janitorErrorsTotal.WithLabelValues(janitorMountResource)) - failures; failed != 1 {
+					t.Fatalf("Expected 1 mount to fail to be cleaned up, got %v", failed)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Missing directory is ignored",
+			testFunc: func(t *testing.T, dir string) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				NewTempMountJanitor(mockMounter, filepath.Join(dir, "missing")).cleanup()
+				mockCtl.Finish()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "janitor")
+			if err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			test.testFunc(t, dir)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package driver
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog"
+)
+
+// metricsNamespace prefixes the name of the metrics of the driver
+const metricsNamespace = "efs_csi"
+
+var (
+	rpcDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "rpc",
+			Name:      "duration_seconds",
+			Help:      "Latency of the CSI RPCs, by method.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"method"},
+	)
+	rpcErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "rpc",
+			Name:      "errors_total",
+			Help:      "Number of CSI RPCs that failed, by method and gRPC status code.",
+		},
+		[]string{"method", "code"},
+	)
+	mountDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "mounter",
+			Name:      "duration_seconds",
+			Help:      "Duration of mount and unmount operations, by operation.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"operation"},
+	)
+	mountErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "mounter",
+			Name:      "errors_total",
+			Help:      "Number of mount and unmount operations that failed, by operation.",
+		},
+		[]string{"operation"},
+	)
+	watchdogRestartsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "watchdog",
+			Name:      "restarts_total",
+			Help:      "Number of times the efs-utils watchdog exited and was restarted.",
+		},
+	)
+	reaperReapedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "reaper",
+			Name:      "reaped_total",
+			Help:      "Number of zombie stunnel processes reaped.",
+		},
+	)
+	volStatsCacheSize = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "volume_stats",
+			Name:      "cache_size",
+			Help:      "Number of volumes whose usage is cached.",
+		},
+		func() float64 {
+			mu.RLock()
+			defer mu.RUnlock()
+			return float64(len(volUsageCache))
+		},
+	)
+	volStatsJobs = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "volume_stats",
+			Name:      "jobs",
+			Help:      "Number of volumes whose usage is being computed.",
+		},
+		func() float64 {
+			mu.RLock()
+			defer mu.RUnlock()
+			return float64(len(volStatterJobTracker))
+		},
+	)
+	janitorCleanedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "janitor",
+			Name:      "cleaned_total",
+			Help:      "Number of orphaned temporary mounts and directories cleaned up, by resource.",
+		},
+		[]string{"resource"},
+	)
+	janitorErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "janitor",
+			Name:      "errors_total",
+			Help:      "Number of orphaned temporary mounts and directories that could not be cleaned up, by resource.",
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		rpcDuration,
+		rpcErrorsTotal,
+		mountDuration,
+		mountErrorsTotal,
+		watchdogRestartsTotal,
+		reaperReapedTotal,
+		volStatsCacheSize,
+		volStatsJobs,
+		janitorCleanedTotal,
+		janitorErrorsTotal,
+		volumeUsage,
+		volumeQuota,
+	)
+}
+
+// ServeMetrics serves the metrics of the driver in the Prometheus format at /metrics on address, in the background.
+func ServeMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		klog.Infof("Serving metrics on %s", address)
+		if err := http.ListenAndServe(address, mux); err != nil {
+			klog.Fatalf("Could not serve metrics on %s: %v", address, err)
+		}
+	}()
+}
+
+// recordRPCMetrics is a gRPC interceptor recording the latency and errors of the RPCs.
+func recordRPCMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcErrorsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	}
+	return resp, err
+}
+
+// recordMountMetrics records the duration of a mount operation that started at start, and whether it failed.
+func recordMountMetrics(operation string, start time.Time, err error) {
+	mountDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		mountErrorsTotal.WithLabelValues(operation).Inc()
+	}
+}
+
+// volumeUsageLabels are the labels of the per-volume usage metrics. The PVC labels are empty when the volume was not
+// provisioned with --extra-create-metadata.
+var volumeUsageLabels = []string{"volume_id", "file_system_id", "access_point_id", "pvc_namespace", "pvc_name"}
+
+var (
+	volumeUsedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume", "used_bytes"),
+		"Number of bytes used by the volume, as last computed by the volume stats.",
+		volumeUsageLabels, nil,
+	)
+	volumeUsedInodesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume", "used_inodes"),
+		"Number of inodes used by the volume, as last computed by the volume stats.",
+		volumeUsageLabels, nil,
+	)
+	volumeUsageAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume", "usage_age_seconds"),
+		"Time since the usage of the volume was last computed.",
+		volumeUsageLabels, nil,
+	)
+)
+
+// volumeUsage publishes the usage of volumes cached by the VolStatter, so that it can be followed without scraping
+// kubelet, which only asks for it every so often.
+var volumeUsage = newVolumeUsageCollector()
+
+type pvcRef struct {
+	namespace, name string
+}
+
+// volumeUsageCollector collects the usage metrics of the volumes published on the node when scraped, from the cache of
+// the VolStatter. Volumes that are not published anymore are left out even while their usage is still cached.
+type volumeUsageCollector struct {
+	mu sync.Mutex
+	// published are the PVCs of the published volumes, keyed by volume ID. The PVC is empty when it is not known.
+	published map[string]pvcRef
+}
+
+func newVolumeUsageCollector() *volumeUsageCollector {
+	return &volumeUsageCollector{
+		published: make(map[string]pvcRef),
+	}
+}
+
+// publish reports the usage of the volume, labeled with its PVC.
+func (c *volumeUsageCollector) publish(volumeId, pvcNamespace, pvcName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published[volumeId] = pvcRef{namespace: pvcNamespace, name: pvcName}
+}
+
+func (c *volumeUsageCollector) unpublish(volumeId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.published, volumeId)
+}
+
+func (c *volumeUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumeUsedBytesDesc
+	ch <- volumeUsedInodesDesc
+	ch <- volumeUsageAgeDesc
+}
+
+func (c *volumeUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for volId, claim := range c.published {
+		metrics, ok := volUsageCache[volId]
+		if !ok {
+			continue
+		}
+		fsId, _, apId, err := parseVolumeId(volId)
+		if err != nil {
+			continue
+		}
+		labels := []string{volId, fsId, apId, claim.namespace, claim.name}
+
+		for _, usage := range metrics.volUsage {
+			switch usage.GetUnit() {
+			case csi.VolumeUsage_BYTES:
+				ch <- prometheus.MustNewConstMetric(volumeUsedBytesDesc, prometheus.GaugeValue, float64(usage.GetUsed()), labels...)
+			case csi.VolumeUsage_INODES:
+				ch <- prometheus.MustNewConstMetric(volumeUsedInodesDesc, prometheus.GaugeValue, float64(usage.GetUsed()), labels...)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(volumeUsageAgeDesc, prometheus.GaugeValue, time.Since(metrics.timeStamp).Seconds(), labels...)
+	}
+}
+
+// volumeQuotaLabels are the labels of the per-volume quota metrics, mode being the quota enforcement mode.
+var volumeQuotaLabels = append(append([]string{}, volumeUsageLabels...), "mode")
+
+var (
+	volumeQuotaLimitBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume_quota", "limit_bytes"),
+		"Quota of the volume, which is the capacity requested by its PVC.",
+		volumeQuotaLabels, nil,
+	)
+	volumeQuotaUsedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume_quota", "used_bytes"),
+		"Number of bytes used by the volume when its quota was last checked.",
+		volumeQuotaLabels, nil,
+	)
+	volumeQuotaExceededDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume_quota", "exceeded"),
+		"Whether the usage of the volume exceeded its quota when last checked.",
+		volumeQuotaLabels, nil,
+	)
+	volumeQuotaReadOnlyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "volume_quota", "read_only"),
+		"Whether the volume is remounted read-only for exceeding its quota.",
+		volumeQuotaLabels, nil,
+	)
+)
+
+// volumeQuota publishes the quotas of the volumes tracked by the QuotaEnforcer of the driver.
+var volumeQuota = &volumeQuotaCollector{}
+
+// volumeQuotaCollector collects the quota metrics of the volumes tracked by a QuotaEnforcer when scraped. A volume
+// published at several targets is reported once per enforcement mode, as exceeded or read-only at any of them.
+type volumeQuotaCollector struct {
+	mu       sync.Mutex
+	enforcer *QuotaEnforcer
+}
+
+func (c *volumeQuotaCollector) setEnforcer(enforcer *QuotaEnforcer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enforcer = enforcer
+}
+
+func (c *volumeQuotaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumeQuotaLimitBytesDesc
+	ch <- volumeQuotaUsedBytesDesc
+	ch <- volumeQuotaExceededDesc
+	ch <- volumeQuotaReadOnlyDesc
+}
+
+func (c *volumeQuotaCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	enforcer := c.enforcer
+	c.mu.Unlock()
+	if enforcer == nil {
+		return
+	}
+
+	type quotaKey struct {
+		volumeId, mode string
+	}
+	merged := make(map[quotaKey]quotaVolume)
+	for _, volume := range enforcer.quotas() {
+		key := quotaKey{volumeId: volume.volumeId, mode: volume.mode}
+		if other, ok := merged[key]; ok {
+			volume.exceeded = volume.exceeded || other.exceeded
+			volume.readOnly = volume.readOnly || other.readOnly
+			if !volume.measured {
+				volume.usedBytes, volume.measured = other.usedBytes, other.measured
+			}
+		}
+		merged[key] = volume
+	}
+
+	for _, volume := range merged {
+		fsId, _, apId, err := parseVolumeId(volume.volumeId)
+		if err != nil {
+			continue
+		}
+		var claim pvcRef
+		if volume.pvc != nil {
+			claim = pvcRef{namespace: volume.pvc.Namespace, name: volume.pvc.Name}
+		}
+		labels := []string{volume.volumeId, fsId, apId, claim.namespace, claim.name, volume.mode}
+
+		ch <- prometheus.MustNewConstMetric(volumeQuotaLimitBytesDesc, prometheus.GaugeValue, float64(volume.limitBytes), labels...)
+		if volume.measured {
+			ch <- prometheus.MustNewConstMetric(volumeQuotaUsedBytesDesc, prometheus.GaugeValue, float64(volume.usedBytes), labels...)
+		}
+		ch <- prometheus.MustNewConstMetric(volumeQuotaExceededDesc, prometheus.GaugeValue, boolToFloat64(volume.exceeded), labels...)
+		ch <- prometheus.MustNewConstMetric(volumeQuotaReadOnlyDesc, prometheus.GaugeValue, boolToFloat64(volume.readOnly), labels...)
+	}
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
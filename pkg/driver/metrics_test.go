@@ -0,0 +1,159 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
+
+func TestRecordRPCMetrics(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodePublishVolume"}
+	failures := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues("NodePublishVolume", codes.Internal.String()))
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Internal, "mount failed")
+	}
+	if _, err := recordRPCMetrics(context.Background(), nil, info, handler); status.Code(err) != codes.Internal {
+		t.Fatalf("Expected the error of the handler, got %v", err)
+	}
+	handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+	if resp, err := recordRPCMetrics(context.Background(), nil, info, handler); err != nil || resp != "response" {
+		t.Fatalf("Expected the response of the handler, got %v, %v", resp, err)
+	}
+
+	if actual := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues("NodePublishVolume", codes.Internal.String())) - failures; actual != 1 {
+		t.Fatalf("Expected 1 error, got %v", actual)
+	}
+	if count := testutil.CollectAndCount(rpcDuration); count == 0 {
+		t.Fatal("Expected RPC latency to be recorded")
+	}
+}
+
+func TestRecordMountMetrics(t *testing.T) {
+	failures := testutil.ToFloat64(mountErrorsTotal.WithLabelValues("unmount"))
+
+	recordMountMetrics("unmount", time.Now(), errors.New("device is busy"))
+	recordMountMetrics("unmount", time.Now(), nil)
+
+	if actual := testutil.ToFloat64(mountErrorsTotal.WithLabelValues("unmount")) - failures; actual != 1 {
+		t.Fatalf("Expected 1 error, got %v", actual)
+	}
+}
+
+func TestVolumeUsageCollector(t *testing.T) {
+	var (
+		apVolumeId          = "fs-abcd1234::fsap-abcd1234"
+		dirVolumeId         = "fs-abcd1234:/dir"
+		unpublishedVolumeId = "fs-abcd1234:/unpublished"
+	)
+	mu.Lock()
+	volUsageCache[apVolumeId] = &volMetrics{
+		timeStamp: time.Now(),
+		volUsage:  []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Used: 1024}},
+	}
+	volUsageCache[dirVolumeId] = &volMetrics{
+		timeStamp: time.Now(),
+		volUsage:  []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Used: 2048}},
+	}
+	volUsageCache[unpublishedVolumeId] = &volMetrics{
+		timeStamp: time.Now(),
+		volUsage:  []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Used: 4096}},
+	}
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		delete(volUsageCache, apVolumeId)
+		delete(volUsageCache, dirVolumeId)
+		delete(volUsageCache, unpublishedVolumeId)
+		mu.Unlock()
+	}()
+
+	collector := newVolumeUsageCollector()
+	collector.publish(apVolumeId, "default", "claim")
+	collector.publish(dirVolumeId, "", "")
+
+	expected := `
+# HELP efs_csi_volume_used_bytes Number of bytes used by the volume, as last computed by the volume stats.
+# TYPE efs_csi_volume_used_bytes gauge
+efs_csi_volume_used_bytes{access_point_id="",file_system_id="fs-abcd1234",pvc_name="",pvc_namespace="",volume_id="fs-abcd1234:/dir"} 2048
+efs_csi_volume_used_bytes{access_point_id="fsap-abcd1234",file_system_id="fs-abcd1234",pvc_name="claim",pvc_namespace="default",volume_id="fs-abcd1234::fsap-abcd1234"} 1024
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "efs_csi_volume_used_bytes"); err != nil {
+		t.Fatalf("Unexpected metrics: %v", err)
+	}
+	if count := testutil.CollectAndCount(collector, "efs_csi_volume_usage_age_seconds"); count != 2 {
+		t.Fatalf("Expected the age of 2 samples, got %d", count)
+	}
+
+	collector.unpublish(apVolumeId)
+	if count := testutil.CollectAndCount(collector, "efs_csi_volume_used_bytes"); count != 1 {
+		t.Fatalf("Expected the usage of 1 volume once the other is unpublished, got %d", count)
+	}
+}
+
+func TestVolumeQuotaCollector(t *testing.T) {
+	var (
+		reportVolumeId   = "fs-abcd1234::fsap-abcd1234"
+		readOnlyVolumeId = "fs-abcd1234:/dir"
+	)
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mockMounter := mocks.NewMockMounter(mockCtl)
+	mockMounter.EXPECT().Mount("", "/target/read-only", "", []string{"remount", "ro"}).Return(nil)
+
+	enforcer := NewQuotaEnforcer(mockMounter, &fakeVolStatter{usage: bytesUsage(2000)}, nil, nil, 240, 5)
+	enforcer.track(reportVolumeId, "/target/report", 4000, QuotaEnforcementReport, "", "default", "claim")
+	enforcer.track(readOnlyVolumeId, "/target/read-only", 1000, QuotaEnforcementReadOnly, "", "", "")
+
+	collector := &volumeQuotaCollector{}
+	if count := testutil.CollectAndCount(collector); count != 0 {
+		t.Fatalf("Expected no metrics without an enforcer, got %d", count)
+	}
+	collector.setEnforcer(enforcer)
+	if count := testutil.CollectAndCount(collector, "efs_csi_volume_quota_used_bytes"); count != 0 {
+		t.Fatalf("Expected no usage before the quotas are checked, got %d", count)
+	}
+
+	enforcer.enforce()
+
+	expected := `
+# HELP efs_csi_volume_quota_exceeded Whether the usage of the volume exceeded its quota when last checked.
+# TYPE efs_csi_volume_quota_exceeded gauge
+efs_csi_volume_quota_exceeded{access_point_id="",file_system_id="fs-abcd1234",mode="readOnly",pvc_name="",pvc_namespace="",volume_id="fs-abcd1234:/dir"} 1
+efs_csi_volume_quota_exceeded{access_point_id="fsap-abcd1234",file_system_id="fs-abcd1234",mode="report",pvc_name="claim",pvc_namespace="default",volume_id="fs-abcd1234::fsap-abcd1234"} 0
+# HELP efs_csi_volume_quota_limit_bytes Quota of the volume, which is the capacity requested by its PVC.
+# TYPE efs_csi_volume_quota_limit_bytes gauge
+efs_csi_volume_quota_limit_bytes{access_point_id="",file_system_id="fs-abcd1234",mode="readOnly",pvc_name="",pvc_namespace="",volume_id="fs-abcd1234:/dir"} 1000
+efs_csi_volume_quota_limit_bytes{access_point_id="fsap-abcd1234",file_system_id="fs-abcd1234",mode="report",pvc_name="claim",pvc_namespace="default",volume_id="fs-abcd1234::fsap-abcd1234"} 4000
+# HELP efs_csi_volume_quota_read_only Whether the volume is remounted read-only for exceeding its quota.
+# TYPE efs_csi_volume_quota_read_only gauge
+efs_csi_volume_quota_read_only{access_point_id="",file_system_id="fs-abcd1234",mode="readOnly",pvc_name="",pvc_namespace="",volume_id="fs-abcd1234:/dir"} 1
+efs_csi_volume_quota_read_only{access_point_id="fsap-abcd1234",file_system_id="fs-abcd1234",mode="report",pvc_name="claim",pvc_namespace="default",volume_id="fs-abcd1234::fsap-abcd1234"} 0
+# HELP efs_csi_volume_quota_used_bytes Number of bytes used by the volume when its quota was last checked.
+# TYPE efs_csi_volume_quota_used_bytes gauge
+efs_csi_volume_quota_used_bytes{access_point_id="",file_system_id="fs-abcd1234",mode="readOnly",pvc_name="",pvc_namespace="",volume_id="fs-abcd1234:/dir"} 2000
+efs_csi_volume_quota_used_bytes{access_point_id="fsap-abcd1234",file_system_id="fs-abcd1234",mode="report",pvc_name="claim",pvc_namespace="default",volume_id="fs-abcd1234::fsap-abcd1234"} 2000
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Fatalf("Unexpected metrics: %v", err)
+	}
+
+	enforcer.untrack("/target/report")
+	enforcer.untrack("/target/read-only")
+	if count := testutil.CollectAndCount(collector); count != 0 {
+		t.Fatalf("Expected no metrics once the volumes are unpublished, got %d", count)
+	}
+}
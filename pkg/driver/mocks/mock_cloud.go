@@ -79,6 +79,22 @@ func (mr *MockCloudMockRecorder) DescribeAccessPoint(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeAccessPoint", reflect.TypeOf((*MockCloud)(nil).DescribeAccessPoint), arg0, arg1)
 }
 
+// DescribeAccessPoints mocks base method
+func (m *MockCloud) DescribeAccessPoints(arg0 context.Context, arg1, arg2 string, arg3 int64) ([]*cloud.AccessPoint, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeAccessPoints", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*cloud.AccessPoint)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DescribeAccessPoints indicates an expected call of DescribeAccessPoints
+func (mr *MockCloudMockRecorder) DescribeAccessPoints(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeAccessPoints", reflect.TypeOf((*MockCloud)(nil).DescribeAccessPoints), arg0, arg1, arg2, arg3)
+}
+
 // DescribeFileSystem mocks base method
 func (m *MockCloud) DescribeFileSystem(arg0 context.Context, arg1 string) (*cloud.FileSystem, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +125,36 @@ func (mr *MockCloudMockRecorder) DescribeMountTargets(ctx, fileSystemId, az inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeMountTargets", reflect.TypeOf((*MockCloud)(nil).DescribeMountTargets), ctx, fileSystemId, az)
 }
 
+// ListAccessPoints mocks base method
+func (m *MockCloud) ListAccessPoints(arg0 context.Context, arg1 string) ([]*cloud.AccessPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccessPoints", arg0, arg1)
+	ret0, _ := ret[0].([]*cloud.AccessPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccessPoints indicates an expected call of ListAccessPoints
+func (mr *MockCloudMockRecorder) ListAccessPoints(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccessPoints", reflect.TypeOf((*MockCloud)(nil).ListAccessPoints), arg0, arg1)
+}
+
+// ListFileSystems mocks base method
+func (m *MockCloud) ListFileSystems(arg0 context.Context) ([]*cloud.FileSystem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFileSystems", arg0)
+	ret0, _ := ret[0].([]*cloud.FileSystem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFileSystems indicates an expected call of ListFileSystems
+func (mr *MockCloudMockRecorder) ListFileSystems(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFileSystems", reflect.TypeOf((*MockCloud)(nil).ListFileSystems), arg0)
+}
+
 // GetMetadata mocks base method
 func (m *MockCloud) GetMetadata() cloud.MetadataService {
 	m.ctrl.T.Helper()
@@ -122,3 +168,179 @@ func (mr *MockCloudMockRecorder) GetMetadata() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockCloud)(nil).GetMetadata))
 }
+
+// CreateFileSystem mocks base method
+func (m *MockCloud) CreateFileSystem(arg0 context.Context, arg1 string, arg2 *cloud.FileSystemOptions) (*cloud.FileSystem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileSystem", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*cloud.FileSystem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileSystem indicates an expected call of CreateFileSystem
+func (mr *MockCloudMockRecorder) CreateFileSystem(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileSystem", reflect.TypeOf((*MockCloud)(nil).CreateFileSystem), arg0, arg1, arg2)
+}
+
+// CreateMountTarget mocks base method
+func (m *MockCloud) CreateMountTarget(arg0 context.Context, arg1, arg2 string, arg3 []string) (*cloud.MountTarget, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMountTarget", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*cloud.MountTarget)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMountTarget indicates an expected call of CreateMountTarget
+func (mr *MockCloudMockRecorder) CreateMountTarget(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMountTarget", reflect.TypeOf((*MockCloud)(nil).CreateMountTarget), arg0, arg1, arg2, arg3)
+}
+
+// DeleteFileSystem mocks base method
+func (m *MockCloud) DeleteFileSystem(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFileSystem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFileSystem indicates an expected call of DeleteFileSystem
+func (mr *MockCloudMockRecorder) DeleteFileSystem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileSystem", reflect.TypeOf((*MockCloud)(nil).DeleteFileSystem), arg0, arg1)
+}
+
+// DeleteMountTarget mocks base method
+func (m *MockCloud) DeleteMountTarget(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMountTarget", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMountTarget indicates an expected call of DeleteMountTarget
+func (mr *MockCloudMockRecorder) DeleteMountTarget(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMountTarget", reflect.TypeOf((*MockCloud)(nil).DeleteMountTarget), arg0, arg1)
+}
+
+// ListMountTargets mocks base method
+func (m *MockCloud) ListMountTargets(arg0 context.Context, arg1 string) ([]*cloud.MountTarget, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMountTargets", arg0, arg1)
+	ret0, _ := ret[0].([]*cloud.MountTarget)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMountTargets indicates an expected call of ListMountTargets
+func (mr *MockCloudMockRecorder) ListMountTargets(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMountTargets", reflect.TypeOf((*MockCloud)(nil).ListMountTargets), arg0, arg1)
+}
+
+// PutLifecycleConfiguration mocks base method
+func (m *MockCloud) PutLifecycleConfiguration(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutLifecycleConfiguration", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutLifecycleConfiguration indicates an expected call of PutLifecycleConfiguration
+func (mr *MockCloudMockRecorder) PutLifecycleConfiguration(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutLifecycleConfiguration", reflect.TypeOf((*MockCloud)(nil).PutLifecycleConfiguration), arg0, arg1, arg2)
+}
+
+// DeleteRecoveryPoint mocks base method
+func (m *MockCloud) DeleteRecoveryPoint(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecoveryPoint", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecoveryPoint indicates an expected call of DeleteRecoveryPoint
+func (mr *MockCloudMockRecorder) DeleteRecoveryPoint(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecoveryPoint", reflect.TypeOf((*MockCloud)(nil).DeleteRecoveryPoint), arg0, arg1, arg2)
+}
+
+// DescribeBackupJob mocks base method
+func (m *MockCloud) DescribeBackupJob(arg0 context.Context, arg1 string) (*cloud.BackupJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeBackupJob", arg0, arg1)
+	ret0, _ := ret[0].(*cloud.BackupJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeBackupJob indicates an expected call of DescribeBackupJob
+func (mr *MockCloudMockRecorder) DescribeBackupJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeBackupJob", reflect.TypeOf((*MockCloud)(nil).DescribeBackupJob), arg0, arg1)
+}
+
+// DescribeRecoveryPoint mocks base method
+func (m *MockCloud) DescribeRecoveryPoint(arg0 context.Context, arg1, arg2 string) (*cloud.RecoveryPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeRecoveryPoint", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*cloud.RecoveryPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeRecoveryPoint indicates an expected call of DescribeRecoveryPoint
+func (mr *MockCloudMockRecorder) DescribeRecoveryPoint(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeRecoveryPoint", reflect.TypeOf((*MockCloud)(nil).DescribeRecoveryPoint), arg0, arg1, arg2)
+}
+
+// ListRecoveryPoints mocks base method
+func (m *MockCloud) ListRecoveryPoints(arg0 context.Context, arg1, arg2, arg3 string, arg4 int64) ([]*cloud.RecoveryPoint, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecoveryPoints", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*cloud.RecoveryPoint)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListRecoveryPoints indicates an expected call of ListRecoveryPoints
+func (mr *MockCloudMockRecorder) ListRecoveryPoints(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecoveryPoints", reflect.TypeOf((*MockCloud)(nil).ListRecoveryPoints), arg0, arg1, arg2, arg3, arg4)
+}
+
+// StartBackupJob mocks base method
+func (m *MockCloud) StartBackupJob(arg0 context.Context, arg1 *cloud.BackupJobOptions) (*cloud.BackupJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartBackupJob", arg0, arg1)
+	ret0, _ := ret[0].(*cloud.BackupJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartBackupJob indicates an expected call of StartBackupJob
+func (mr *MockCloudMockRecorder) StartBackupJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartBackupJob", reflect.TypeOf((*MockCloud)(nil).StartBackupJob), arg0, arg1)
+}
+
+// TagAccessPoint mocks base method
+func (m *MockCloud) TagAccessPoint(arg0 context.Context, arg1 string, arg2 map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TagAccessPoint", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TagAccessPoint indicates an expected call of TagAccessPoint
+func (mr *MockCloudMockRecorder) TagAccessPoint(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagAccessPoint", reflect.TypeOf((*MockCloud)(nil).TagAccessPoint), arg0, arg1, arg2)
+}
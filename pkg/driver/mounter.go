@@ -15,6 +15,7 @@ package driver
 
 import (
 	"os"
+	"time"
 
 	"k8s.io/mount-utils"
 )
@@ -36,6 +37,16 @@ func newNodeMounter() Mounter {
 	}
 }
 
+func (m *NodeMounter) Mount(source string, target string, fstype string, options []string) (err error) {
+	defer func(start time.Time) { recordMountMetrics("mount", start, err) }(time.Now())
+	return m.Interface.Mount(source, target, fstype, options)
+}
+
+func (m *NodeMounter) Unmount(target string) (err error) {
+	defer func(start time.Time) { recordMountMetrics("unmount", start, err) }(time.Now())
+	return m.Interface.Unmount(target)
+}
+
 func (m *NodeMounter) MakeDir(pathname string) error {
 	err := os.MkdirAll(pathname, os.FileMode(0755))
 	if err != nil {
@@ -36,44 +36,184 @@ var (
 		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
 		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
 	}
-	volumeIdCounter  = make(map[string]int)
 	supportedFSTypes = []string{"efs", ""}
 )
 
 func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("NodeStageVolume: called with args %+v", req)
+
+	volumeId := req.GetVolumeId()
+	if volumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	stagingTarget := req.GetStagingTargetPath()
+	if stagingTarget == "" {
+		return nil, status.Error(codes.InvalidArgument, "Staging target path not provided")
+	}
+
+	if !d.inFlight.Insert(volumeId) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, volumeId)
+	}
+	defer d.inFlight.Delete(volumeId)
+
+	if err := d.validateNodeVolumeCapability(req.GetVolumeCapability()); err != nil {
+		return nil, err
+	}
+
+	// The volume is staged read-write, publishes requesting read-only access bind mount it read-only
+	vm, err := parseVolumeMount(volumeId, req.GetVolumeContext(), req.GetVolumeCapability(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	_, refCount, err := d.mounter.GetDeviceName(stagingTarget)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check if volume is staged: %v", err)
+	}
+	if refCount > 0 {
+		klog.V(5).Infof("NodeStageVolume: %s is already staged at %s", volumeId, stagingTarget)
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+
+	klog.V(5).Infof("NodeStageVolume: creating dir %s", stagingTarget)
+	if err := d.mounter.MakeDir(stagingTarget); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", stagingTarget, err)
+	}
+
+	klog.V(5).Infof("NodeStageVolume: mounting %s at %s with options %v", vm.source, stagingTarget, vm.mountOptions)
+	if err := d.mountVolume(vm, stagingTarget); err != nil {
+		os.Remove(stagingTarget)
+		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", vm.source, stagingTarget, err)
+	}
+	klog.V(5).Infof("NodeStageVolume: %s was mounted", stagingTarget)
+
+	return &csi.NodeStageVolumeResponse{}, nil
 }
 
 func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "")
+	klog.V(4).Infof("NodeUnstageVolume: called with args %+v", req)
+
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	stagingTarget := req.GetStagingTargetPath()
+	if stagingTarget == "" {
+		return nil, status.Error(codes.InvalidArgument, "Staging target path not provided")
+	}
+
+	if !d.inFlight.Insert(req.GetVolumeId()) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, req.GetVolumeId())
+	}
+	defer d.inFlight.Delete(req.GetVolumeId())
+
+	_, refCount, err := d.mounter.GetDeviceName(stagingTarget)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check if volume is staged: %v", err)
+	}
+
+	// From the spec: If the volume corresponding to the volume_id is not staged to the staging_target_path, the
+	// Plugin MUST reply 0 OK.
+	if refCount == 0 {
+		klog.V(5).Infof("NodeUnstageVolume: %s staging target not mounted", stagingTarget)
+		return &csi.NodeUnstageVolumeResponse{}, nil
+	}
+
+	klog.V(5).Infof("NodeUnstageVolume: unmounting %s", stagingTarget)
+	if err := d.unmountVolume(stagingTarget); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not unmount %q: %v", stagingTarget, err)
+	}
+	klog.V(5).Infof("NodeUnstageVolume: %s unmounted", stagingTarget)
+
+	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
 func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
 	klog.V(4).Infof("NodePublishVolume: called with args %+v", req)
-	mountOptions := []string{}
 
 	target := req.GetTargetPath()
 	if len(target) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "Target path not provided")
 	}
 
-	volCap := req.GetVolumeCapability()
-	if volCap == nil {
-		return nil, status.Error(codes.InvalidArgument, "Volume capability not provided")
+	// Different pods may publish the same volume concurrently, so publishes are only exclusive per target path
+	if !d.inFlight.Insert(target) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, target)
 	}
+	defer d.inFlight.Delete(target)
 
-	if err := d.isValidVolumeCapabilities([]*csi.VolumeCapability{volCap}); err != nil {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Volume capability not supported: %s", err))
+	if err := d.validateNodeVolumeCapability(req.GetVolumeCapability()); err != nil {
+		return nil, err
 	}
 
-	if volCap.GetMount() == nil {
-		return nil, status.Error(codes.InvalidArgument, "Volume capability access type must be mount")
+	vm, err := parseVolumeMount(req.GetVolumeId(), req.GetVolumeContext(), req.GetVolumeCapability(), req.GetReadonly())
+	if err != nil {
+		return nil, err
+	}
+
+	klog.V(5).Infof("NodePublishVolume: creating dir %s", target)
+	if err := d.mounter.MakeDir(target); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	}
+
+	// With staging enabled, the volume is mounted once per node at the staging target path and bind mounted from
+	// there, so that publishing it again does not start another mount and TLS tunnel
+	source := vm.source
+	if stagingTarget := req.GetStagingTargetPath(); d.nodeStageOptIn && stagingTarget != "" {
+		source = stagingTarget
+		mountOptions := []string{"bind"}
+		if req.GetReadonly() {
+			mountOptions = append(mountOptions, "ro")
+		}
+		klog.V(5).Infof("NodePublishVolume: mounting %s at %s with options %v", source, target, mountOptions)
+		err = d.mounter.Mount(source, target, "", mountOptions)
+	} else {
+		klog.V(5).Infof("NodePublishVolume: mounting %s at %s with options %v", source, target, vm.mountOptions)
+		err = d.mountVolume(vm, target)
+	}
+	if err != nil {
+		os.Remove(target)
+		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", source, target, err)
+	}
+	klog.V(5).Infof("NodePublishVolume: %s was mounted", target)
+
+	if quotaMode := vm.quotaMode; quotaMode != "" {
+		// Remounting read-only applies to the whole superblock, which NFS shares between mounts of the same file
+		// system unless each goes through its own TLS tunnel. Shared root mounts are a single tunnel for the whole
+		// file system, unless the volume has an access point.
+		sharedSuperblock := !hasOption(vm.mountOptions, "tls") || (d.sharedMounts != nil && vm.apid == "")
+		if quotaMode == QuotaEnforcementReadOnly && (sharedSuperblock || req.GetReadonly()) {
+			klog.Warningf("NodePublishVolume: volume %s will not be remounted read-only when exceeding its quota as it shares its mount with other volumes or is read-only", req.GetVolumeId())
+			quotaMode = QuotaEnforcementReport
+		}
+		d.quotaEnforcer.track(req.GetVolumeId(), target, vm.quotaLimitBytes, quotaMode, vm.pvName, vm.pvcNamespace, vm.pvcName)
 	}
 
+	d.publishTracker.publish(req.GetVolumeId(), target)
+	volumeUsage.publish(req.GetVolumeId(), vm.pvcNamespace, vm.pvcName)
+
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// volumeMount is how a volume is mounted from EFS, as described by its ID, context and capability
+type volumeMount struct {
+	fsid, subpath, apid           string
+	source                        string
+	mountOptions                  []string
+	quotaMode                     string
+	quotaLimitBytes               int64
+	pvName, pvcName, pvcNamespace string
+}
+
+// parseVolumeMount returns how to mount the volume, or an InvalidArgument error when its ID, context or mount flags
+// are invalid. Both NodeStageVolume and NodePublishVolume mount volumes from EFS, so they share this parsing.
+func parseVolumeMount(volumeId string, volContext map[string]string, volCap *csi.VolumeCapability, readOnly bool) (*volumeMount, error) {
+	vm := &volumeMount{mountOptions: []string{}}
+
 	// TODO when CreateVolume is implemented, it must use the same key names
 	subpath := "/"
 	encryptInTransit := true
-	volContext := req.GetVolumeContext()
 	for k, v := range volContext {
 		switch strings.ToLower(k) {
 		//Deprecated
@@ -93,13 +233,34 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 			}
 		case MountTargetIp:
 			ipAddr := volContext[MountTargetIp]
-			mountOptions = append(mountOptions, MountTargetIp+"="+ipAddr)
+			vm.mountOptions = append(vm.mountOptions, MountTargetIp+"="+ipAddr)
+		case strings.ToLower(QuotaEnforcement):
+			if v != QuotaEnforcementReport && v != QuotaEnforcementReadOnly {
+				return nil, status.Errorf(codes.InvalidArgument, "Volume context property %q must be %q or %q", k, QuotaEnforcementReport, QuotaEnforcementReadOnly)
+			}
+			vm.quotaMode = v
+		case strings.ToLower(QuotaLimitBytes):
+			var err error
+			vm.quotaLimitBytes, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Volume context property %q must be an integer: %v", k, err)
+			}
+		case PvNameKey:
+			vm.pvName = v
+		case PvcNameKey:
+			vm.pvcName = v
+		case PvcNamespaceKey:
+			vm.pvcNamespace = v
 		default:
 			return nil, status.Errorf(codes.InvalidArgument, "Volume context property %s not supported", k)
 		}
 	}
 
-	fsid, vpath, apid, err := parseVolumeId(req.GetVolumeId())
+	if vm.quotaMode != "" && vm.quotaLimitBytes <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Volume context property %q requires a positive %q", QuotaEnforcement, QuotaLimitBytes)
+	}
+
+	fsid, vpath, apid, err := parseVolumeId(volumeId)
 	if err != nil {
 		// parseVolumeId returns the appropriate error
 		return nil, err
@@ -109,7 +270,8 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 	if vpath != "" {
 		subpath = vpath
 	}
-	source := fmt.Sprintf("%s:%s", fsid, subpath)
+	vm.fsid, vm.subpath, vm.apid = fsid, subpath, apid
+	vm.source = fmt.Sprintf("%s:%s", fsid, subpath)
 
 	// If an access point was specified, we need to include two things in the mountOptions:
 	// - The access point ID, properly prefixed. (Below, we'll check whether an access point was
@@ -117,19 +279,19 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 	// - The TLS option. Access point mounts won't work without it. (For ease of use, we won't
 	//   require this to be present in the mountOptions already, but we won't complain if it is.)
 	if apid != "" {
-		mountOptions = append(mountOptions, fmt.Sprintf("accesspoint=%s", apid), "tls")
+		vm.mountOptions = append(vm.mountOptions, fmt.Sprintf("accesspoint=%s", apid), "tls")
 	}
 
 	if encryptInTransit {
 		// The TLS option may have been added above if apid was set
 		// TODO: mountOptions should be a set to avoid all this hasOption checking
-		if !hasOption(mountOptions, "tls") {
-			mountOptions = append(mountOptions, "tls")
+		if !hasOption(vm.mountOptions, "tls") {
+			vm.mountOptions = append(vm.mountOptions, "tls")
 		}
 	}
 
-	if req.GetReadonly() {
-		mountOptions = append(mountOptions, "ro")
+	if readOnly {
+		vm.mountOptions = append(vm.mountOptions, "ro")
 	}
 
 	if m := volCap.GetMount(); m != nil {
@@ -170,33 +332,48 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 				continue
 			}
 
-			if !hasOption(mountOptions, f) {
-				mountOptions = append(mountOptions, f)
+			if !hasOption(vm.mountOptions, f) {
+				vm.mountOptions = append(vm.mountOptions, f)
 			}
 		}
 	}
-	klog.V(5).Infof("NodePublishVolume: creating dir %s", target)
-	if err := d.mounter.MakeDir(target); err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	return vm, nil
+}
+
+// rootMountOptions returns the options of the root mount of the file system of the volume, which is shared with
+// volumes published read-write, and whether the volume is read-only.
+func (vm *volumeMount) rootMountOptions() ([]string, bool) {
+	var options []string
+	readOnly := false
+	for _, o := range vm.mountOptions {
+		if o == "ro" {
+			readOnly = true
+			continue
+		}
+		options = append(options, o)
 	}
+	return options, readOnly
+}
 
-	klog.V(5).Infof("NodePublishVolume: mounting %s at %s with options %v", source, target, mountOptions)
-	if err := d.mounter.Mount(source, target, "efs", mountOptions); err != nil {
-		os.Remove(target)
-		return nil, status.Errorf(codes.Internal, "Could not mount %q at %q: %v", source, target, err)
+// mountVolume mounts the volume from EFS at target, through the root mount of its file system when it is shared.
+func (d *Driver) mountVolume(vm *volumeMount, target string) error {
+	if d.sharedMounts != nil {
+		return d.sharedMounts.mount(vm, target)
 	}
-	klog.V(5).Infof("NodePublishVolume: %s was mounted", target)
+	return d.mounter.Mount(vm.source, target, "efs", vm.mountOptions)
+}
 
-	//Increment volume Id counter
-	if d.volMetricsOptIn {
-		if value, ok := volumeIdCounter[req.GetVolumeId()]; ok {
-			volumeIdCounter[req.GetVolumeId()] = value + 1
-		} else {
-			volumeIdCounter[req.GetVolumeId()] = 1
+// unmountVolume unmounts target, and the root mount of its file system if it was the last volume using it.
+func (d *Driver) unmountVolume(target string) error {
+	if err := d.mounter.Unmount(target); err != nil {
+		return err
+	}
+	if d.sharedMounts != nil {
+		if err := d.sharedMounts.release(target); err != nil {
+			klog.Errorf("Could not release shared mount of %s: %v", target, err)
 		}
 	}
-
-	return &csi.NodePublishVolumeResponse{}, nil
+	return nil
 }
 
 func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
@@ -207,6 +384,11 @@ func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublish
 		return nil, status.Error(codes.InvalidArgument, "Target path not provided")
 	}
 
+	if !d.inFlight.Insert(target) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, target)
+	}
+	defer d.inFlight.Delete(target)
+
 	// Check if target directory is a mount point. GetDeviceNameFromMount
 	// given a mnt point, finds the device from /proc/mounts
 	// returns the device name, reference count, and error code
@@ -221,34 +403,39 @@ func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublish
 	// reply 0 OK.
 	if refCount == 0 {
 		klog.V(5).Infof("NodeUnpublishVolume: %s target not mounted", target)
+		d.quotaEnforcer.untrack(target)
+		d.untrackPublish(req.GetVolumeId(), target)
 		return &csi.NodeUnpublishVolumeResponse{}, nil
 	}
 
+	// Stop computing the usage of the volume at target, as the unmount would otherwise wait for it to complete
+	d.volStatter.cancelVolumeMetrics(req.GetVolumeId(), target)
+
 	klog.V(5).Infof("NodeUnpublishVolume: unmounting %s", target)
-	err = d.mounter.Unmount(target)
+	err = d.unmountVolume(target)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Could not unmount %q: %v", target, err)
 	}
 	klog.V(5).Infof("NodeUnpublishVolume: %s unmounted", target)
+	d.quotaEnforcer.untrack(target)
 
-	//TODO: If `du` is running on a volume, unmount waits for it to complete. We should stop `du` on unmount in the future for NodeUnpublish
-	//Decrement Volume ID counter and evict cache if counter is 0.
-	if d.volMetricsOptIn {
-		if value, ok := volumeIdCounter[req.GetVolumeId()]; ok {
-			value -= 1
-			if value < 1 {
-				klog.V(4).Infof("Evicting vol ID: %v, vol path : %v from cache", req.VolumeId, target)
-				d.volStatter.removeFromCache(req.VolumeId)
-				delete(volumeIdCounter, req.GetVolumeId())
-			} else {
-				volumeIdCounter[req.GetVolumeId()] = value
-			}
-		}
-	}
+	d.untrackPublish(req.GetVolumeId(), target)
 
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
+// untrackPublish records that the volume is no longer published at target, and evicts its usage from the cache once it
+// is no longer published anywhere on the node.
+func (d *Driver) untrackPublish(volumeId, target string) {
+	if remaining := d.publishTracker.unpublish(volumeId, target); remaining > 0 {
+		return
+	}
+	volumeUsage.unpublish(volumeId)
+	// The usage is cached for quota enforcement as well, so it is evicted whether or not volume metrics are enabled
+	klog.V(4).Infof("Evicting vol ID: %v, vol path : %v from cache", volumeId, target)
+	d.volStatter.removeFromCache(volumeId)
+}
+
 func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
 	klog.V(4).Infof("NodeGetVolumeStats: called with args %+v", req)
 
@@ -262,6 +449,11 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 		return nil, status.Error(codes.InvalidArgument, "Volume Path not provided")
 	}
 
+	if !d.inFlight.Insert(target) {
+		return nil, status.Errorf(codes.Aborted, operationAlreadyExistsErrorMsg, target)
+	}
+	defer d.inFlight.Delete(target)
+
 	_, err := os.Stat(target)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -277,9 +469,15 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 		return nil, status.Errorf(codes.Internal, "Could not get metrics: %v ", err)
 	}
 
-	return &csi.NodeGetVolumeStatsResponse{
+	response := &csi.NodeGetVolumeStatsResponse{
 		Usage: volMetrics.volUsage,
-	}, nil
+	}
+	if limitBytes, exceeded, ok := d.quotaEnforcer.getQuota(target); ok {
+		response.Usage = applyQuota(volMetrics.volUsage, limitBytes)
+		response.VolumeCondition = quotaVolumeCondition(limitBytes, exceeded)
+	}
+
+	return response, nil
 }
 
 func (d *Driver) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
@@ -305,9 +503,31 @@ func (d *Driver) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabi
 func (d *Driver) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
 	klog.V(4).Infof("NodeGetInfo: called with args %+v", req)
 
-	return &csi.NodeGetInfoResponse{
+	response := &csi.NodeGetInfoResponse{
 		NodeId: d.nodeID,
-	}, nil
+	}
+	// Volumes of One Zone file systems can only be mounted from the zone of the file system
+	if d.availabilityZone != "" {
+		response.AccessibleTopology = &csi.Topology{
+			Segments: map[string]string{TopologyKey: d.availabilityZone},
+		}
+	}
+	return response, nil
+}
+
+func (d *Driver) validateNodeVolumeCapability(volCap *csi.VolumeCapability) error {
+	if volCap == nil {
+		return status.Error(codes.InvalidArgument, "Volume capability not provided")
+	}
+
+	if err := d.isValidVolumeCapabilities([]*csi.VolumeCapability{volCap}); err != nil {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("Volume capability not supported: %s", err))
+	}
+
+	if volCap.GetMount() == nil {
+		return status.Error(codes.InvalidArgument, "Volume capability access type must be mount")
+	}
+	return nil
 }
 
 func (d *Driver) isValidVolumeCapabilities(volCaps []*csi.VolumeCapability) error {
@@ -393,6 +613,15 @@ func (d *Driver) validateFStype(volCaps []*csi.VolumeCapability) error {
 // - https://github.com/kubernetes-sigs/aws-efs-csi-driver/issues/100
 // - https://github.com/kubernetes-sigs/aws-efs-csi-driver/issues/167
 func parseVolumeId(volumeId string) (fsid, subpath, apid string, err error) {
+	// Volumes provisioned in efs-fs mode are whole file systems
+	if isFileSystemVolumeId(volumeId) {
+		fsid = strings.TrimPrefix(volumeId, FileSystemVolumeIdPrefix)
+		if !isValidFileSystemId(fsid) || strings.Contains(fsid, ":") {
+			err = status.Errorf(codes.InvalidArgument, "volume ID '%s' is invalid: Expected a file system ID of the form 'fs-...' after '%s'", volumeId, FileSystemVolumeIdPrefix)
+		}
+		return
+	}
+
 	// Might as well do this up front, since the FSID is required and first in the string
 	if !isValidFileSystemId(volumeId) {
 		err = status.Errorf(codes.InvalidArgument, "volume ID '%s' is invalid: Expected a file system ID of the form 'fs-...'", volumeId)
@@ -435,6 +664,11 @@ func hasOption(options []string, opt string) bool {
 	return false
 }
 
+// isFileSystemVolumeId reports whether the volume was provisioned in efs-fs mode.
+func isFileSystemVolumeId(volumeId string) bool {
+	return strings.HasPrefix(volumeId, FileSystemVolumeIdPrefix)
+}
+
 func isValidFileSystemId(filesystemId string) bool {
 	return strings.HasPrefix(filesystemId, "fs-")
 }
@@ -27,13 +27,15 @@ import (
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
 )
 
 const (
-	volumeId   = "fs-abc123"
-	targetPath = "/target/path"
+	volumeId          = "fs-abc123"
+	targetPath        = "/target/path"
+	stagingTargetPath = "/staging/path"
 )
 
 type errtyp struct {
@@ -43,15 +45,18 @@ type errtyp struct {
 
 func setup(mockCtrl *gomock.Controller, volStatter VolStatter, volMetricsOptIn bool) (*mocks.MockMounter, *Driver, context.Context) {
 	mockMounter := mocks.NewMockMounter(mockCtrl)
-	nodeCaps := SetNodeCapOptInFeatures(volMetricsOptIn)
+	nodeCaps := SetNodeCapOptInFeatures(volMetricsOptIn, false)
 	driver := &Driver{
 		endpoint:          "endpoint",
 		nodeID:            "nodeID",
 		mounter:           mockMounter,
 		volStatter:        volStatter,
+		quotaEnforcer:     NewQuotaEnforcer(mockMounter, volStatter, nil, nil, 240, 5),
+		publishTracker:    NewPublishTracker(mockMounter, ""),
 		volMetricsOptIn:   true,
 		nodeCaps:          nodeCaps,
 		fsIdentityManager: NewFileSystemIdentityManager(),
+		inFlight:          NewInFlight(),
 	}
 	ctx := context.Background()
 	return mockMounter, driver, ctx
@@ -104,6 +109,7 @@ func TestNodePublishVolume(t *testing.T) {
 		mountArgs       []interface{}
 		mountSuccess    bool
 		volMetricsOptIn bool
+		nodeStageOptIn  bool
 		expectError     errtyp
 	}{
 		{
@@ -373,6 +379,30 @@ func TestNodePublishVolume(t *testing.T) {
 				message: "volume ID 'fs-abc123:/a/b/::four!' is invalid: Expected at most three fields separated by ':'",
 			},
 		},
+		{
+			name: "success: file system provisioned in efs-fs mode",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         FileSystemVolumeIdPrefix + volumeId,
+				VolumeCapability: stdVolCap,
+				TargetPath:       targetPath,
+			},
+			expectMakeDir: true,
+			mountArgs:     []interface{}{volumeId + ":/", targetPath, "efs", []string{"tls"}},
+			mountSuccess:  true,
+		},
+		{
+			name: "fail: path in volume handle of a file system provisioned in efs-fs mode",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         FileSystemVolumeIdPrefix + volumeId + ":/a/b",
+				VolumeCapability: stdVolCap,
+				TargetPath:       targetPath,
+			},
+			expectMakeDir: false,
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "volume ID 'fs:fs-abc123:/a/b' is invalid: Expected a file system ID of the form 'fs-...' after 'fs:'",
+			},
+		},
 		{
 			name: "fail: missing target path",
 			req: &csi.NodePublishVolumeRequest{
@@ -570,6 +600,99 @@ func TestNodePublishVolume(t *testing.T) {
 				message: "Found tls in mountOptions but encryptInTransit is false",
 			},
 		},
+		{
+			name: "success: normal with quota enforcement",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         volumeId,
+				VolumeCapability: stdVolCap,
+				TargetPath:       targetPath,
+				VolumeContext: map[string]string{
+					QuotaEnforcement: QuotaEnforcementReadOnly,
+					QuotaLimitBytes:  "5368709120",
+					PvcNameKey:       "claim",
+					PvcNamespaceKey:  "default",
+				},
+			},
+			expectMakeDir:   true,
+			mountArgs:       []interface{}{volumeId + ":/", targetPath, "efs", []string{"tls"}},
+			mountSuccess:    true,
+			volMetricsOptIn: true,
+		},
+		{
+			name: "fail: quota enforcement invalid mode",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         volumeId,
+				VolumeCapability: stdVolCap,
+				TargetPath:       targetPath,
+				VolumeContext: map[string]string{
+					QuotaEnforcement: "hard",
+					QuotaLimitBytes:  "5368709120",
+				},
+			},
+			expectMakeDir: false,
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume context property \"quotaEnforcement\" must be \"report\" or \"readOnly\"",
+			},
+		},
+		{
+			name: "fail: quota enforcement without limit",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         volumeId,
+				VolumeCapability: stdVolCap,
+				TargetPath:       targetPath,
+				VolumeContext: map[string]string{
+					QuotaEnforcement: QuotaEnforcementReport,
+				},
+			},
+			expectMakeDir: false,
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume context property \"quotaEnforcement\" requires a positive \"quotaLimitBytes\"",
+			},
+		},
+		{
+			name: "success: bind mount from staging target path",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+				TargetPath:        targetPath,
+			},
+			expectMakeDir:   true,
+			mountArgs:       []interface{}{stagingTargetPath, targetPath, "", []string{"bind"}},
+			mountSuccess:    true,
+			volMetricsOptIn: true,
+			nodeStageOptIn:  true,
+		},
+		{
+			name: "success: read only bind mount from staging target path",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+				TargetPath:        targetPath,
+				Readonly:          true,
+			},
+			expectMakeDir:   true,
+			mountArgs:       []interface{}{stagingTargetPath, targetPath, "", []string{"bind", "ro"}},
+			mountSuccess:    true,
+			volMetricsOptIn: true,
+			nodeStageOptIn:  true,
+		},
+		{
+			name: "success: staging target path is ignored without staging enabled",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+				TargetPath:        targetPath,
+			},
+			expectMakeDir:   true,
+			mountArgs:       []interface{}{volumeId + ":/", targetPath, "efs", []string{"tls"}},
+			mountSuccess:    true,
+			volMetricsOptIn: true,
+		},
 		{
 			name: "fail: encryptInTransit invalid boolean value volume context",
 			req: &csi.NodePublishVolumeRequest{
@@ -590,7 +713,8 @@ func TestNodePublishVolume(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockCtrl := gomock.NewController(t)
 			defer mockCtrl.Finish()
-			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(), tc.volMetricsOptIn)
+			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), tc.volMetricsOptIn)
+			driver.nodeStageOptIn = tc.nodeStageOptIn
 
 			if tc.expectMakeDir {
 				var err error
@@ -614,6 +738,250 @@ func TestNodePublishVolume(t *testing.T) {
 	}
 }
 
+func TestNodeStageVolume(t *testing.T) {
+	var (
+		accessPointID = "fsap-abcd1234"
+		stdVolCap     = &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{
+				Mount: &csi.VolumeCapability_MountVolume{},
+			},
+			AccessMode: &csi.VolumeCapability_AccessMode{
+				Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			},
+		}
+	)
+
+	testCases := []struct {
+		name                string
+		req                 *csi.NodeStageVolumeRequest
+		getDeviceNameReturn []interface{}
+		expectMakeDir       bool
+		mountArgs           []interface{}
+		mountSuccess        bool
+		expectError         errtyp
+	}{
+		{
+			name: "success: normal",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 0, nil},
+			expectMakeDir:       true,
+			mountArgs:           []interface{}{volumeId + ":/", stagingTargetPath, "efs", []string{"tls"}},
+			mountSuccess:        true,
+		},
+		{
+			name: "success: path and access point in volume handle",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId + ":/a/b:" + accessPointID,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 0, nil},
+			expectMakeDir:       true,
+			mountArgs:           []interface{}{volumeId + ":/a/b", stagingTargetPath, "efs", []string{"accesspoint=" + accessPointID, "tls"}},
+			mountSuccess:        true,
+		},
+		{
+			name: "success: volume already staged",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{volumeId + ":/", 1, nil},
+		},
+		{
+			name: "fail: missing volume ID",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume ID not provided",
+			},
+		},
+		{
+			name: "fail: missing staging target path",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:         volumeId,
+				VolumeCapability: stdVolCap,
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Staging target path not provided",
+			},
+		},
+		{
+			name: "fail: missing volume capability",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				StagingTargetPath: stagingTargetPath,
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume capability not provided",
+			},
+		},
+		{
+			name: "fail: unsupported volume context",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+				VolumeContext:     map[string]string{"asdf": "qwer"},
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume context property asdf not supported",
+			},
+		},
+		{
+			name: "fail: mounter failed to GetDeviceName",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 0, fmt.Errorf("GetDeviceName failed")},
+			expectError: errtyp{
+				code:    "Internal",
+				message: "failed to check if volume is staged: GetDeviceName failed",
+			},
+		},
+		{
+			name: "fail: mounter failed to Mount",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeId,
+				VolumeCapability:  stdVolCap,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 0, nil},
+			expectMakeDir:       true,
+			mountArgs:           []interface{}{volumeId + ":/", stagingTargetPath, "efs", []string{"tls"}},
+			mountSuccess:        false,
+			expectError: errtyp{
+				code:    "Internal",
+				message: `Could not mount "fs-abc123:/" at "/staging/path": failed to Mount`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), true)
+			driver.nodeStageOptIn = true
+
+			if len(tc.getDeviceNameReturn) != 0 {
+				mockMounter.EXPECT().
+					GetDeviceName(stagingTargetPath).
+					Return(tc.getDeviceNameReturn[0], tc.getDeviceNameReturn[1], tc.getDeviceNameReturn[2])
+			}
+			if tc.expectMakeDir {
+				mockMounter.EXPECT().MakeDir(gomock.Eq(stagingTargetPath)).Return(nil)
+			}
+			if len(tc.mountArgs) != 0 {
+				var err error
+				if !tc.mountSuccess {
+					err = fmt.Errorf("failed to Mount")
+				}
+				mockMounter.EXPECT().Mount(tc.mountArgs[0], tc.mountArgs[1], tc.mountArgs[2], tc.mountArgs[3]).Return(err)
+			}
+
+			ret, err := driver.NodeStageVolume(ctx, tc.req)
+			testResult(t, "NodeStageVolume", ret, err, tc.expectError)
+		})
+	}
+}
+
+func TestNodeUnstageVolume(t *testing.T) {
+	testCases := []struct {
+		name                string
+		req                 *csi.NodeUnstageVolumeRequest
+		getDeviceNameReturn []interface{}
+		expectUnmount       bool
+		unmountReturn       error
+		expectError         errtyp
+	}{
+		{
+			name: "success: normal",
+			req: &csi.NodeUnstageVolumeRequest{
+				VolumeId:          volumeId,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 1, nil},
+			expectUnmount:       true,
+		},
+		{
+			name: "success: unstage with already unmounted staging target",
+			req: &csi.NodeUnstageVolumeRequest{
+				VolumeId:          volumeId,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 0, nil},
+		},
+		{
+			name: "fail: missing volume ID",
+			req: &csi.NodeUnstageVolumeRequest{
+				StagingTargetPath: stagingTargetPath,
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Volume ID not provided",
+			},
+		},
+		{
+			name: "fail: missing staging target path",
+			req: &csi.NodeUnstageVolumeRequest{
+				VolumeId: volumeId,
+			},
+			expectError: errtyp{
+				code:    "InvalidArgument",
+				message: "Staging target path not provided",
+			},
+		},
+		{
+			name: "fail: mounter failed to umount",
+			req: &csi.NodeUnstageVolumeRequest{
+				VolumeId:          volumeId,
+				StagingTargetPath: stagingTargetPath,
+			},
+			getDeviceNameReturn: []interface{}{"", 1, nil},
+			expectUnmount:       true,
+			unmountReturn:       fmt.Errorf("Unmount failed"),
+			expectError: errtyp{
+				code:    "Internal",
+				message: `Could not unmount "/staging/path": Unmount failed`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), true)
+			driver.nodeStageOptIn = true
+
+			if len(tc.getDeviceNameReturn) != 0 {
+				mockMounter.EXPECT().
+					GetDeviceName(stagingTargetPath).
+					Return(tc.getDeviceNameReturn[0], tc.getDeviceNameReturn[1], tc.getDeviceNameReturn[2])
+			}
+			if tc.expectUnmount {
+				mockMounter.EXPECT().Unmount(stagingTargetPath).Return(tc.unmountReturn)
+			}
+
+			ret, err := driver.NodeUnstageVolume(ctx, tc.req)
+			testResult(t, "NodeUnstageVolume", ret, err, tc.expectError)
+		})
+	}
+}
+
 func TestNodeUnpublishVolume(t *testing.T) {
 	var metrics = &volMetrics{
 		volPath:   targetPath,
@@ -718,7 +1086,7 @@ func TestNodeUnpublishVolume(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockCtrl := gomock.NewController(t)
 			defer mockCtrl.Finish()
-			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(), true)
+			mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), true)
 
 			if tc.expectGetDeviceName {
 				mockMounter.EXPECT().
@@ -740,6 +1108,97 @@ func TestNodeUnpublishVolume(t *testing.T) {
 	}
 }
 
+func TestNodeUnpublishVolume_EvictsUsageWithoutVolumeMetrics(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), false)
+	// The usage of volumes with a quota is cached even when volume metrics are not enabled
+	driver.volMetricsOptIn = false
+	driver.publishTracker.publish(volumeId, targetPath)
+
+	mu.Lock()
+	volUsageCache[volumeId] = &volMetrics{volPath: targetPath, timeStamp: time.Now()}
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		delete(volUsageCache, volumeId)
+		mu.Unlock()
+	}()
+
+	mockMounter.EXPECT().GetDeviceName(targetPath).Return("", 1, nil)
+	mockMounter.EXPECT().Unmount(targetPath).Return(nil)
+
+	if _, err := driver.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: volumeId, TargetPath: targetPath}); err != nil {
+		t.Fatalf("NodeUnpublishVolume failed: %v", err)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := volUsageCache[volumeId]; ok {
+		t.Fatal("Expected the usage of the volume to be evicted from the cache")
+	}
+}
+
+func TestNodeUnpublishVolume_StopsReportingUsage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockMounter, driver, ctx := setup(mockCtrl, NewVolStatter(""), true)
+	driver.publishTracker.publish(volumeId, targetPath)
+	volumeUsage.publish(volumeId, "default", "claim")
+
+	mu.Lock()
+	volUsageCache[volumeId] = &volMetrics{
+		volPath:   targetPath,
+		timeStamp: time.Now(),
+		volUsage:  []*csi.VolumeUsage{{Unit: csi.VolumeUsage_BYTES, Used: 1024}},
+	}
+	mu.Unlock()
+	defer func() {
+		volumeUsage.unpublish(volumeId)
+		mu.Lock()
+		delete(volUsageCache, volumeId)
+		mu.Unlock()
+	}()
+
+	published := testutil.CollectAndCount(volumeUsage, "efs_csi_volume_used_bytes")
+	if published == 0 {
+		t.Fatal("Expected the usage of the published volume to be reported")
+	}
+
+	mockMounter.EXPECT().GetDeviceName(targetPath).Return("", 1, nil)
+	mockMounter.EXPECT().Unmount(targetPath).Return(nil)
+	if _, err := driver.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: volumeId, TargetPath: targetPath}); err != nil {
+		t.Fatalf("NodeUnpublishVolume failed: %v", err)
+	}
+
+	if count := testutil.CollectAndCount(volumeUsage, "efs_csi_volume_used_bytes"); count != published-1 {
+		t.Fatalf("Expected the usage of the unpublished volume to disappear, got %d samples instead of %d", count, published-1)
+	}
+}
+
+func TestNodeGetInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	_, driver, ctx := setup(mockCtrl, NewVolStatter(""), true)
+
+	res, err := driver.NodeGetInfo(ctx, &csi.NodeGetInfoRequest{})
+	if err != nil {
+		t.Fatalf("NodeGetInfo failed: %v", err)
+	}
+	if res.NodeId != driver.nodeID || res.AccessibleTopology != nil {
+		t.Fatalf("Expected node without topology, got %+v", res)
+	}
+
+	driver.availabilityZone = "us-east-1a"
+	res, err = driver.NodeGetInfo(ctx, &csi.NodeGetInfoRequest{})
+	if err != nil {
+		t.Fatalf("NodeGetInfo failed: %v", err)
+	}
+	if zone := res.GetAccessibleTopology().GetSegments()[TopologyKey]; zone != "us-east-1a" {
+		t.Fatalf("Expected zone %v, got %v", "us-east-1a", zone)
+	}
+	mockCtrl.Finish()
+}
+
 func TestNodeGetVolumeStats(t *testing.T) {
 	var (
 		validPath   = "/tmp/target"
@@ -766,6 +1225,7 @@ func TestNodeGetVolumeStats(t *testing.T) {
 		name             string
 		req              *csi.NodeGetVolumeStatsRequest
 		updateCache      bool
+		quotaBytes       int64
 		expectError      errtyp
 		expectedResponse *csi.NodeGetVolumeStatsResponse
 	}{
@@ -801,6 +1261,28 @@ func TestNodeGetVolumeStats(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "success: volume with quota enforcement",
+			req: &csi.NodeGetVolumeStatsRequest{
+				VolumeId:   volumeId,
+				VolumePath: validPath,
+			},
+			updateCache: true,
+			quotaBytes:  1,
+			expectedResponse: &csi.NodeGetVolumeStatsResponse{
+				Usage: []*csi.VolumeUsage{
+					{
+						Unit:      csi.VolumeUsage_BYTES,
+						Available: 0,
+						Total:     1,
+						Used:      1,
+					},
+				},
+				VolumeCondition: &csi.VolumeCondition{
+					Message: "Volume usage is within its quota of 1 bytes",
+				},
+			},
+		},
 		{
 			name: "Fail: Path does not exist",
 			req: &csi.NodeGetVolumeStatsRequest{
@@ -845,13 +1327,16 @@ func TestNodeGetVolumeStats(t *testing.T) {
 			//setup
 			mockCtrl := gomock.NewController(t)
 			defer mockCtrl.Finish()
-			_, driver, ctx = setup(mockCtrl, NewVolStatter(), true)
+			_, driver, ctx = setup(mockCtrl, NewVolStatter(""), true)
 
 			if tc.updateCache {
 				mu.Lock()
 				volUsageCache[volumeId] = volMetrics
 				mu.Unlock()
 			}
+			if tc.quotaBytes != 0 {
+				driver.quotaEnforcer.track(volumeId, validPath, tc.quotaBytes, QuotaEnforcementReport, "", "", "")
+			}
 
 			//execute
 			ret, err := driver.NodeGetVolumeStats(ctx, tc.req)
@@ -1,12 +1,27 @@
 package driver
 
-import "os"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+)
 
 type OsClient interface {
 	MkDirAllWithPerms(path string, perms os.FileMode, uid, gid int) error
 	MkDirAllWithPermsNoOwnership(path string, perms os.FileMode) error
+	CopyTree(ctx context.Context, src, dst string, uid, gid int) error
 	Remove(path string) error
 	RemoveAll(path string) error
+	ReadDir(path string) ([]os.DirEntry, error)
 }
 
 type FakeOsClient struct{}
@@ -19,6 +34,10 @@ func (o *FakeOsClient) MkDirAllWithPermsNoOwnership(_ string, _ os.FileMode) err
 	return nil
 }
 
+func (o *FakeOsClient) CopyTree(_ context.Context, _, _ string, _, _ int) error {
+	return nil
+}
+
 func (o *FakeOsClient) Remove(_ string) error {
 	return nil
 }
@@ -27,6 +46,10 @@ func (o *FakeOsClient) RemoveAll(_ string) error {
 	return nil
 }
 
+func (o *FakeOsClient) ReadDir(_ string) ([]os.DirEntry, error) {
+	return nil, nil
+}
+
 type BrokenOsClient struct{}
 
 func (o *BrokenOsClient) MkDirAllWithPerms(_ string, _ os.FileMode, _, _ int) error {
@@ -37,6 +60,10 @@ func (o *BrokenOsClient) MkDirAllWithPermsNoOwnership(_ string, _ os.FileMode) e
 	return &os.PathError{}
 }
 
+func (o *BrokenOsClient) CopyTree(_ context.Context, _, _ string, _, _ int) error {
+	return &os.PathError{}
+}
+
 func (o *BrokenOsClient) Remove(_ string) error {
 	return &os.PathError{}
 }
@@ -45,6 +72,10 @@ func (o *BrokenOsClient) RemoveAll(_ string) error {
 	return &os.PathError{}
 }
 
+func (o *BrokenOsClient) ReadDir(_ string) ([]os.DirEntry, error) {
+	return nil, &os.PathError{}
+}
+
 type RealOsClient struct{}
 
 func (o *RealOsClient) MkDirAllWithPerms(path string, perms os.FileMode, uid, gid int) error {
@@ -67,6 +98,192 @@ func (o *RealOsClient) MkDirAllWithPermsNoOwnership(path string, perms os.FileMo
 	return nil
 }
 
+// copyTreeTempSuffix is appended to the destination of CopyTree to name the directory the tree is copied to first.
+const copyTreeTempSuffix = ".copying"
+
+// CopyTree copies the directory src to dst, preserving ownership, modes, symlinks, hard links and extended attributes.
+// Unless uid or gid is -1, entries owned by the owner of src are given to uid or gid instead.
+//
+// The tree is copied to a temporary directory next to dst, which is renamed to dst once the copy succeeds, so dst is
+// either empty or a complete copy. A CopyTree failing, or canceled through ctx, is resumed by the next one, which skips
+// the files already copied, and a CopyTree finding dst already populated returns right away.
+func (o *RealOsClient) CopyTree(ctx context.Context, src, dst string, uid, gid int) error {
+	rootInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !rootInfo.IsDir() {
+		return fmt.Errorf("%q is not a directory", src)
+	}
+	rootOwner := rootInfo.Sys().(*syscall.Stat_t)
+
+	if entries, err := ioutil.ReadDir(dst); err == nil && len(entries) > 0 {
+		klog.V(4).Infof("%q is already populated, skipping copy of %q", dst, src)
+		return nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	tmp := dst + copyTreeTempSuffix
+
+	// Files with more than one link are linked to the copy of their first link
+	links := make(map[uint64]map[uint64]string)
+	err = filepath.Walk(src, func(srcPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, srcPath)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(tmp, relPath)
+		owner := info.Sys().(*syscall.Stat_t)
+
+		mode := info.Mode()
+		switch {
+		case mode.IsDir():
+			err = copyTreeDir(dstPath)
+		case mode&os.ModeSymlink != 0:
+			err = copyTreeSymlink(srcPath, dstPath)
+		case mode.IsRegular() && owner.Nlink > 1:
+			inodes, ok := links[uint64(owner.Dev)]
+			if !ok {
+				inodes = make(map[uint64]string)
+				links[uint64(owner.Dev)] = inodes
+			}
+			if linked, ok := inodes[owner.Ino]; ok {
+				return copyTreeLink(linked, dstPath)
+			}
+			inodes[owner.Ino] = dstPath
+			err = copyTreeFile(srcPath, dstPath, info)
+		case mode.IsRegular():
+			err = copyTreeFile(srcPath, dstPath, info)
+		default:
+			klog.Warningf("Skipping copy of %q: unsupported file type %v", srcPath, mode.Type())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fileUid, fileGid := int(owner.Uid), int(owner.Gid)
+		if uid != -1 && owner.Uid == rootOwner.Uid {
+			fileUid = uid
+		}
+		if gid != -1 && owner.Gid == rootOwner.Gid {
+			fileGid = gid
+		}
+		if err := os.Lchown(dstPath, fileUid, fileGid); err != nil {
+			return err
+		}
+		// Symlink permissions are not used on Linux. Modes are set after the owner, as chown clears setuid and setgid.
+		if mode&os.ModeSymlink == 0 {
+			if err := os.Chmod(dstPath, mode); err != nil {
+				return err
+			}
+		}
+		return copyXattrs(srcPath, dstPath)
+	})
+	if err != nil {
+		return err
+	}
+	// An empty dst, e.g. a directory created before the copy, is replaced
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func copyTreeDir(dst string) error {
+	if err := os.Mkdir(dst, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+func copyTreeSymlink(src, dst string) error {
+	link, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(link, dst)
+}
+
+// copyTreeFile copies the regular file src described by info to dst, unless a previous copy already did. Copies are
+// given the modification time of src, so that a file is known to be copied when its size and modification time match.
+func copyTreeFile(src, dst string, info os.FileInfo) error {
+	if copied, err := os.Lstat(dst); err == nil && copied.Mode().IsRegular() && copied.Size() == info.Size() &&
+		copied.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// copyTreeLink links dst to linked, the copy of another link of the same file.
+func copyTreeLink(linked, dst string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Link(linked, dst)
+}
+
+// copyXattrs copies the extended attributes of src to dst. It is a no-op on file systems without extended attribute
+// support, EFS among them.
+func copyXattrs(src, dst string) error {
+	size, err := unix.Llistxattr(src, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+	names := make([]byte, size)
+	if size, err = unix.Llistxattr(src, names); err != nil {
+		return err
+	}
+
+	for _, name := range strings.Split(strings.TrimRight(string(names[:size]), "\x00"), "\x00") {
+		size, err := unix.Lgetxattr(src, name, nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, size)
+		if size, err = unix.Lgetxattr(src, name, value); err != nil {
+			return err
+		}
+		if err := unix.Lsetxattr(dst, name, value[:size], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (o *RealOsClient) Remove(path string) error {
 	return os.Remove(path)
 }
@@ -74,3 +291,7 @@ func (o *RealOsClient) Remove(path string) error {
 func (o *RealOsClient) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+func (o *RealOsClient) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRealOsClient_CopyTree(t *testing.T) {
+	src, err := ioutil.TempDir("", "copy-tree-src")
+	if err != nil {
+		t.Fatalf("Failed to create source directory: %v", err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "copy-tree-dst")
+	if err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err := os.Mkdir(filepath.Join(src, "dir"), 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "dir", "file"), []byte("content"), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Symlink("dir/file", filepath.Join(src, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "hardlink")); err != nil {
+		t.Fatalf("Failed to create hard link: %v", err)
+	}
+	// Files left by an interrupted copy are overwritten
+	if err := os.Mkdir(dst+copyTreeTempSuffix, 0700); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dst + copyTreeTempSuffix)
+	if err := ioutil.WriteFile(filepath.Join(dst+copyTreeTempSuffix, "link"), []byte("stale"), 0600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	client := &RealOsClient{}
+	if err := client.CopyTree(context.Background(), src, dst, -1, -1); err != nil {
+		t.Fatalf("CopyTree failed: %v", err)
+	}
+
+	dirInfo, err := os.Stat(filepath.Join(dst, "dir"))
+	if err != nil {
+		t.Fatalf("Failed to stat copied directory: %v", err)
+	}
+	if !dirInfo.IsDir() || dirInfo.Mode().Perm() != 0750 {
+		t.Fatalf("Expected directory with mode %v, got %v", os.FileMode(0750)|os.ModeDir, dirInfo.Mode())
+	}
+
+	fileInfo, err := os.Stat(filepath.Join(dst, "dir", "file"))
+	if err != nil {
+		t.Fatalf("Failed to stat copied file: %v", err)
+	}
+	if fileInfo.Mode().Perm() != 0640 {
+		t.Fatalf("Expected file mode %v, got %v", os.FileMode(0640), fileInfo.Mode().Perm())
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dst, "dir", "file"))
+	if err != nil {
+		t.Fatalf("Failed to read copied file: %v", err)
+	}
+	if string(content) != "content" {
+		t.Fatalf("Expected file content %q, got %q", "content", string(content))
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	if err != nil {
+		t.Fatalf("Failed to read copied symlink: %v", err)
+	}
+	if link != "dir/file" {
+		t.Fatalf("Expected symlink to %q, got %q", "dir/file", link)
+	}
+
+	hardLinkInfo, err := os.Stat(filepath.Join(dst, "hardlink"))
+	if err != nil {
+		t.Fatalf("Failed to stat copied hard link: %v", err)
+	}
+	if !os.SameFile(fileInfo, hardLinkInfo) {
+		t.Fatal("Expected hard link to be preserved")
+	}
+	if _, err := os.Stat(dst + copyTreeTempSuffix); !os.IsNotExist(err) {
+		t.Fatalf("Expected temporary directory to be renamed, got %v", err)
+	}
+}
+
+func TestRealOsClient_CopyTreeIsResumed(t *testing.T) {
+	src, err := ioutil.TempDir("", "copy-tree-src")
+	if err != nil {
+		t.Fatalf("Failed to create source directory: %v", err)
+	}
+	defer os.RemoveAll(src)
+	parent, err := ioutil.TempDir("", "copy-tree-dst")
+	if err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	defer os.RemoveAll(parent)
+	dst := filepath.Join(parent, "dst")
+
+	if err := ioutil.WriteFile(filepath.Join(src, "file"), []byte("content"), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	client := &RealOsClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.CopyTree(ctx, src, dst, -1, -1); err != context.Canceled {
+		t.Fatalf("Expected CopyTree to be canceled, got %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("Expected nothing to be copied to the destination, got %v", err)
+	}
+
+	if err := client.CopyTree(context.Background(), src, dst, -1, -1); err != nil {
+		t.Fatalf("CopyTree failed: %v", err)
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(dst, "file")); err != nil || string(content) != "content" {
+		t.Fatalf("Expected file content %q, got %q: %v", "content", string(content), err)
+	}
+
+	// A complete copy is reused
+	if err := ioutil.WriteFile(filepath.Join(src, "other"), []byte("content"), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := client.CopyTree(context.Background(), src, dst, -1, -1); err != nil {
+		t.Fatalf("CopyTree failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "other")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the copy to be reused, got %v", err)
+	}
+}
+
+func TestRealOsClient_CopyTreeSourceIsNotDirectory(t *testing.T) {
+	src, err := ioutil.TempFile("", "copy-tree-src")
+	if err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	src.Close()
+	defer os.Remove(src.Name())
+
+	client := &RealOsClient{}
+	if err := client.CopyTree(context.Background(), src.Name(), filepath.Join(os.TempDir(), "copy-tree-dst"), -1, -1); err == nil {
+		t.Fatal("Expected CopyTree to fail but it didn't")
+	}
+}
@@ -2,6 +2,11 @@ package driver
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
@@ -14,6 +19,10 @@ import (
 type Provisioner interface {
 	Provision(ctx context.Context, req *csi.CreateVolumeRequest, uid, gid int) (*csi.Volume, error)
 	Delete(ctx context.Context, req *csi.DeleteVolumeRequest) error
+	GetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error)
+	Expand(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error)
+	// List returns the volumes provisioned with the parameters of a storage class.
+	List(ctx context.Context, params map[string]string) ([]*csi.Volume, error)
 }
 
 func getProvisioners(tags map[string]string, cloud cloud.Cloud, deleteAccessPointRootDir bool, mounter Mounter, osClient OsClient, deleteProvisionedDir bool) map[string]Provisioner {
@@ -23,6 +32,11 @@ func getProvisioners(tags map[string]string, cloud cloud.Cloud, deleteAccessPoin
 			cloud:                    cloud,
 			deleteAccessPointRootDir: deleteAccessPointRootDir,
 			mounter:                  mounter,
+			osClient:                 osClient,
+		},
+		FileSystemMode: FileSystemProvisioner{
+			tags:  tags,
+			cloud: cloud,
 		},
 		DirectoryMode: DirectoryProvisioner{
 			mounter:              mounter,
@@ -33,7 +47,19 @@ func getProvisioners(tags map[string]string, cloud cloud.Cloud, deleteAccessPoin
 	}
 }
 
-func getCloud(originalCloud cloud.Cloud, secrets map[string]string) (cloud.Cloud, string, error) {
+// getTags returns the tags applied to every AWS resource created by the driver, i.e. DefaultTagKey plus the tags
+// passed through the --tags flag.
+func getTags(extraTags map[string]string) map[string]string {
+	tags := map[string]string{
+		DefaultTagKey: DefaultTagValue,
+	}
+	for k, v := range extraTags {
+		tags[k] = v
+	}
+	return tags
+}
+
+func getCloud(ctx context.Context, originalCloud cloud.Cloud, secrets map[string]string) (cloud.Cloud, string, error) {
 
 	var localCloud cloud.Cloud
 	var roleArn string
@@ -46,7 +72,15 @@ func getCloud(originalCloud cloud.Cloud, secrets map[string]string) (cloud.Cloud
 	}
 
 	if roleArn != "" {
-		localCloud, err = cloud.NewCloudWithRole(roleArn)
+		roleOpts := cloud.RoleOptions{
+			RoleArn:     roleArn,
+			ExternalId:  secrets[RoleExternalId],
+			SessionName: secrets[RoleSessionName],
+		}
+		if tags := strings.TrimSpace(secrets[RoleSessionTags]); tags != "" {
+			roleOpts.SessionTags = parseTagsFromStr(tags)
+		}
+		localCloud, err = cloud.NewCloudWithRole(ctx, roleOpts)
 		if err != nil {
 			return nil, "", status.Errorf(codes.Unauthenticated, "Unable to initialize aws cloud: %v. Please verify role has the correct AWS permissions for cross account mount", err)
 		}
@@ -57,6 +91,55 @@ func getCloud(originalCloud cloud.Cloud, secrets map[string]string) (cloud.Cloud
 	return localCloud, roleArn, nil
 }
 
+// fileSystemRoles remembers the role secrets that each file system was last reached with, for the calls that carry
+// no secrets, such as ControllerGetVolume, to reach cross account file systems with the same role.
+var fileSystemRoles = newRoleRegistry()
+
+// roleSecretKeys are the secrets which select the role of a cloud in getCloud.
+var roleSecretKeys = []string{RoleArn, RoleExternalId, RoleSessionName, RoleSessionTags}
+
+type roleRegistry struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+}
+
+func newRoleRegistry() *roleRegistry {
+	return &roleRegistry{
+		secrets: make(map[string]map[string]string),
+	}
+}
+
+// set records the role secrets of a file system, or forgets its role when the secrets select none.
+func (r *roleRegistry) set(fileSystemId string, secrets map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if secrets[RoleArn] == "" {
+		delete(r.secrets, fileSystemId)
+		return
+	}
+	roleSecrets := make(map[string]string, len(roleSecretKeys))
+	for _, k := range roleSecretKeys {
+		if v, ok := secrets[k]; ok {
+			roleSecrets[k] = v
+		}
+	}
+	r.secrets[fileSystemId] = roleSecrets
+}
+
+// get returns the role secrets recorded for the file system, which are empty if the driver's own role reaches it.
+func (r *roleRegistry) get(fileSystemId string) map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.secrets[fileSystemId]
+}
+
+// getFileSystemCloud returns the cloud of the role the file system was last reached with, for calls without secrets.
+// File systems which have not been reached with a role since the controller started are looked up with the driver's
+// own role.
+func getFileSystemCloud(ctx context.Context, originalCloud cloud.Cloud, fileSystemId string) (cloud.Cloud, string, error) {
+	return getCloud(ctx, originalCloud, fileSystemRoles.get(fileSystemId))
+}
+
 func getMountOptions(ctx context.Context, cloud cloud.Cloud, fileSystemId string, roleArn string) ([]string, error) {
 	//Mount File System at it root and delete access point root directory
 	mountOptions := []string{"tls", "iam"}
@@ -71,3 +154,197 @@ func getMountOptions(ctx context.Context, cloud cloud.Cloud, fileSystemId string
 	}
 	return mountOptions, nil
 }
+
+// getAccessibleTopology returns the zone of a One Zone file system as the accessible topology of its volumes. Regional
+// file systems can be reached from every zone with a mount target, so their volumes are not constrained.
+func getAccessibleTopology(fileSystem *cloud.FileSystem, requirement *csi.TopologyRequirement) ([]*csi.Topology, error) {
+	zone := fileSystem.AvailabilityZoneName
+	if zone == "" {
+		return nil, nil
+	}
+
+	requisite := requirement.GetRequisite()
+	accessible := len(requisite) == 0
+	for _, topology := range requisite {
+		if value, ok := topology.GetSegments()[TopologyKey]; !ok || value == zone {
+			accessible = true
+			break
+		}
+	}
+	if !accessible {
+		return nil, status.Errorf(codes.ResourceExhausted, "File System %v in zone %v cannot be accessed from the requisite topology", fileSystem.FileSystemId, zone)
+	}
+
+	return []*csi.Topology{
+		{
+			Segments: map[string]string{TopologyKey: zone},
+		},
+	}, nil
+}
+
+// getCloneSourcePath returns the path, relative to the root of the file system, of the directory backing the volume
+// the request is cloned from, or an empty string when the request has no volume content source.
+func getCloneSourcePath(ctx context.Context, localCloud cloud.Cloud, req *csi.CreateVolumeRequest, fileSystemId string) (string, error) {
+	sourceVolume := req.GetVolumeContentSource().GetVolume()
+	if sourceVolume == nil {
+		return "", nil
+	}
+
+	sourceVolumeId := sourceVolume.GetVolumeId()
+	sourceFileSystemId, subpath, accessPointId, err := parseVolumeId(sourceVolumeId)
+	if err != nil {
+		return "", status.Errorf(codes.NotFound, "Source volume %v not found: %v", sourceVolumeId, err)
+	}
+	if sourceFileSystemId != fileSystemId {
+		return "", status.Errorf(codes.InvalidArgument, "Source volume %v is not on File System %v", sourceVolumeId, fileSystemId)
+	}
+	if accessPointId == "" {
+		return path.Join("/", subpath), nil
+	}
+
+	accessPoint, err := localCloud.DescribeAccessPoint(ctx, accessPointId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return "", status.Errorf(codes.NotFound, "Source volume %v not found: Access Point %v does not exist", sourceVolumeId, accessPointId)
+		}
+		return "", cloudErrorToStatus(err, "Could not describe Access Point %v", accessPointId)
+	}
+	return path.Join(accessPoint.AccessPointRootDir, subpath), nil
+}
+
+// checkCloneTarget rejects clones into the source directory itself or one of its descendants, which would never
+// finish copying.
+func checkCloneTarget(sourcePath, targetPath string) error {
+	sourcePath, targetPath = path.Clean(sourcePath), path.Clean(targetPath)
+	if targetPath == sourcePath || strings.HasPrefix(targetPath, strings.TrimSuffix(sourcePath, "/")+"/") {
+		return status.Errorf(codes.InvalidArgument, "Cannot clone %q into %q as it is within the source directory", sourcePath, targetPath)
+	}
+	return nil
+}
+
+// cloneDirectory mounts the file system at its root and copies sourcePath to targetPath. Unless uid or gid is -1,
+// entries owned by the owner of sourcePath are given to uid or gid instead.
+func cloneDirectory(ctx context.Context, mounter Mounter, osClient OsClient, localCloud cloud.Cloud, roleArn, fileSystemId,
+	sourcePath, targetPath string, uid, gid int) (e error) {
+	if err := checkCloneTarget(sourcePath, targetPath); err != nil {
+		return err
+	}
+
+	mountOptions, err := getMountOptions(ctx, localCloud, fileSystemId, roleArn)
+	if err != nil {
+		return err
+	}
+
+	target := tempMounts.add(TempMountPathPrefix)
+	defer tempMounts.release(target)
+	if err := mounter.MakeDir(target); err != nil {
+		return status.Errorf(codes.Internal, "Could not create dir %q: %v", target, err)
+	}
+	if err := mounter.Mount(fileSystemId, target, "efs", mountOptions); err != nil {
+		osClient.Remove(target)
+		return status.Errorf(codes.Internal, "Could not mount %q at %q: %v", fileSystemId, target, err)
+	}
+
+	defer func() {
+		if err := mounter.Unmount(target); err != nil {
+			e = status.Errorf(codes.Internal, "Could not unmount %q: %v", target, err)
+		} else if err := osClient.RemoveAll(target); err != nil {
+			e = status.Errorf(codes.Internal, "Could not delete %q: %v", target, err)
+		}
+	}()
+
+	klog.V(5).Infof("Cloning %s into %s on File System %s", sourcePath, targetPath, fileSystemId)
+	if err := osClient.CopyTree(ctx, path.Join(target, sourcePath), path.Join(target, targetPath), uid, gid); err != nil {
+		return copyErrorToStatus(err, sourcePath, targetPath)
+	}
+	return nil
+}
+
+// copyErrorToStatus reports a copy that was canceled or timed out with the code of its context, so that the
+// provisioner retries it, and any other failure as an internal error.
+func copyErrorToStatus(err error, sourcePath, targetPath string) error {
+	code := codes.Internal
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		code = status.FromContextError(err).Code()
+	}
+	return status.Errorf(code, "Could not copy %q to %q: %v", sourcePath, targetPath, err)
+}
+
+// getFileSystemCondition reports the file system as abnormal when it is not available or cannot be reached through
+// an available mount target.
+func getFileSystemCondition(ctx context.Context, localCloud cloud.Cloud, fileSystemId string) (*csi.VolumeCondition, error) {
+	fileSystem, err := localCloud.DescribeFileSystem(ctx, fileSystemId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return abnormalVolumeCondition("File System %v does not exist", fileSystemId), nil
+		}
+		return nil, cloudErrorToStatus(err, "Failed to fetch File System info")
+	}
+
+	if fileSystem.LifeCycleState != FileSystemAvailable {
+		return abnormalVolumeCondition("File System %v is in %q state", fileSystemId, fileSystem.LifeCycleState), nil
+	}
+
+	if _, err := localCloud.DescribeMountTargets(ctx, fileSystemId, ""); err != nil {
+		// The condition of the volume is unknown rather than abnormal when the mount targets could not be described
+		if errors.Is(err, cloud.ErrAccessDenied) || errors.Is(err, cloud.ErrThrottled) || errors.Is(err, cloud.ErrUnavailable) {
+			return nil, cloudErrorToStatus(err, "Failed to describe mount targets of File System %v", fileSystemId)
+		}
+		return abnormalVolumeCondition("No available mount target for File System %v: %v", fileSystemId, err), nil
+	}
+
+	return &csi.VolumeCondition{Message: "Volume is healthy"}, nil
+}
+
+// provisionerErrorToStatus returns err as is if it already carries a gRPC code, e.g. one set by cloudErrorToStatus, and
+// wraps it as an internal error otherwise.
+func provisionerErrorToStatus(err error, format string, args ...interface{}) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "%s: %v", fmt.Sprintf(format, args...), err)
+}
+
+// cloudErrorToStatus translates an error returned by the cloud to a gRPC status, so that every kind of error is
+// reported with the same code whatever the call that failed. Callers handle the errors whose meaning depends on the
+// call first, e.g. a resource that is not found when deleting it.
+func cloudErrorToStatus(err error, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	switch {
+	case errors.Is(err, cloud.ErrAccessDenied):
+		return status.Errorf(codes.Unauthenticated, "Access Denied. Please ensure you have the right AWS permissions: %v", err)
+	case errors.Is(err, cloud.ErrThrottled), errors.Is(err, cloud.ErrUnavailable):
+		return status.Errorf(codes.Unavailable, "%s: %v", msg, err)
+	case errors.Is(err, cloud.ErrLimitExceeded):
+		return status.Errorf(codes.ResourceExhausted, "%s: %v", msg, err)
+	case errors.Is(err, cloud.ErrIncorrectLifeCycleState), errors.Is(err, cloud.ErrFileSystemInUse):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, cloud.ErrAlreadyExists):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", msg, err)
+	case errors.Is(err, cloud.ErrNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, cloud.ErrInvalidToken):
+		return status.Errorf(codes.Aborted, "%s: %v", msg, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Errorf(codes.DeadlineExceeded, "%s: %v", msg, err)
+	case errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+func abnormalVolumeCondition(format string, args ...interface{}) *csi.VolumeCondition {
+	return &csi.VolumeCondition{
+		Abnormal: true,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// getExpandCapacity returns the capacity a volume is expanded to, which is the required bytes of the capacity range if
+// set and its limit bytes otherwise.
+func getExpandCapacity(capRange *csi.CapacityRange) int64 {
+	if capRange.GetRequiredBytes() > 0 {
+		return capRange.GetRequiredBytes()
+	}
+	return capRange.GetLimitBytes()
+}
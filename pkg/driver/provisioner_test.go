@@ -2,9 +2,11 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,7 +19,7 @@ func TestProvisioner_GetCloud_NoRoleArnGivesOriginalObjectBack(t *testing.T) {
 	mockCtl := gomock.NewController(t)
 	mockCloud := mocks.NewMockCloud(mockCtl)
 
-	actualCloud, _, _ := getCloud(mockCloud, map[string]string{})
+	actualCloud, _, _ := getCloud(context.Background(), mockCloud, map[string]string{})
 	if actualCloud != mockCloud {
 		t.Fatalf("Expected cloud object to be %v but was %v", mockCloud, actualCloud)
 	}
@@ -29,7 +31,7 @@ func TestProvisioner_GetCloud_IncorrectRoleArnGivesError(t *testing.T) {
 	mockCtl := gomock.NewController(t)
 	mockCloud := mocks.NewMockCloud(mockCtl)
 
-	_, _, err := getCloud(mockCloud, map[string]string{
+	_, _, err := getCloud(context.Background(), mockCloud, map[string]string{
 		RoleArn: "foo",
 	})
 	if err == nil {
@@ -42,6 +44,45 @@ func TestProvisioner_GetCloud_IncorrectRoleArnGivesError(t *testing.T) {
 	mockCtl.Finish()
 }
 
+func TestProvisioner_GetFileSystemCloud_UsesRecordedRole(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	fsId := "fs-abcd1234"
+
+	actualCloud, _, err := getFileSystemCloud(context.Background(), mockCloud, fsId)
+	if err != nil || actualCloud != mockCloud {
+		t.Fatalf("Expected cloud object to be %v but was %v, err: %v", mockCloud, actualCloud, err)
+	}
+
+	fileSystemRoles.set(fsId, map[string]string{RoleArn: "foo"})
+	defer fileSystemRoles.set(fsId, nil)
+	_, _, err = getFileSystemCloud(context.Background(), mockCloud, fsId)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected the recorded role to be assumed but got %v", err)
+	}
+
+	mockCtl.Finish()
+}
+
+func TestProvisioner_RoleRegistry(t *testing.T) {
+	r := newRoleRegistry()
+	r.set("fs-abcd1234", map[string]string{
+		RoleArn:        "arn:aws:iam::123456789012:role/efs",
+		RoleExternalId: "external",
+		"other":        "secret",
+	})
+
+	expected := map[string]string{RoleArn: "arn:aws:iam::123456789012:role/efs", RoleExternalId: "external"}
+	if actual := r.get("fs-abcd1234"); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected role secrets %v but got %v", expected, actual)
+	}
+
+	r.set("fs-abcd1234", map[string]string{})
+	if actual := r.get("fs-abcd1234"); actual != nil {
+		t.Fatalf("Expected the role to be forgotten but got %v", actual)
+	}
+}
+
 func TestProvisioner_GetMountOptions_NoRoleArnGivesStandardOptions(t *testing.T) {
 	mockCtl := gomock.NewController(t)
 	mockCloud := mocks.NewMockCloud(mockCtl)
@@ -75,3 +116,91 @@ func TestProvisioner_GetMountOptions_RoleArnAddsMountTargetIp(t *testing.T) {
 		t.Fatalf("Expected returned options to be %v but was %v", expectedOptions, options)
 	}
 }
+
+func TestProvisioner_GetAccessibleTopology(t *testing.T) {
+	var (
+		zone         = "us-east-1a"
+		zoneTopology = &csi.Topology{Segments: map[string]string{TopologyKey: zone}}
+	)
+	tests := []struct {
+		name         string
+		fileSystem   *cloud.FileSystem
+		requirement  *csi.TopologyRequirement
+		expected     []*csi.Topology
+		expectedCode codes.Code
+	}{
+		{
+			name:       "Success: Regional file system has no topology",
+			fileSystem: &cloud.FileSystem{FileSystemId: fileSystemId},
+			requirement: &csi.TopologyRequirement{
+				Requisite: []*csi.Topology{{Segments: map[string]string{TopologyKey: "us-east-1b"}}},
+			},
+		},
+		{
+			name:       "Success: One Zone file system without requirement",
+			fileSystem: &cloud.FileSystem{FileSystemId: fileSystemId, AvailabilityZoneName: zone},
+			expected:   []*csi.Topology{zoneTopology},
+		},
+		{
+			name:       "Success: One Zone file system in a requisite zone",
+			fileSystem: &cloud.FileSystem{FileSystemId: fileSystemId, AvailabilityZoneName: zone},
+			requirement: &csi.TopologyRequirement{
+				Requisite: []*csi.Topology{
+					{Segments: map[string]string{TopologyKey: "us-east-1b"}},
+					zoneTopology,
+				},
+			},
+			expected: []*csi.Topology{zoneTopology},
+		},
+		{
+			name:       "Fail: One Zone file system outside of the requisite zones",
+			fileSystem: &cloud.FileSystem{FileSystemId: fileSystemId, AvailabilityZoneName: zone},
+			requirement: &csi.TopologyRequirement{
+				Requisite: []*csi.Topology{{Segments: map[string]string{TopologyKey: "us-east-1b"}}},
+			},
+			expectedCode: codes.ResourceExhausted,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topology, err := getAccessibleTopology(test.fileSystem, test.requirement)
+			if status.Code(err) != test.expectedCode {
+				t.Fatalf("Expected error code %v, got %v", test.expectedCode, err)
+			}
+			if !reflect.DeepEqual(topology, test.expected) {
+				t.Fatalf("Expected topology %v, got %v", test.expected, topology)
+			}
+		})
+	}
+}
+
+func TestProvisioner_CloudErrorToStatus(t *testing.T) {
+	cloudError := func(kind error) error {
+		return &cloud.Error{Kind: kind, Op: "Failed to create access point", Err: errors.New("AWS error")}
+	}
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+	}{
+		{name: "Access denied", err: cloudError(cloud.ErrAccessDenied), expectedCode: codes.Unauthenticated},
+		{name: "Throttled", err: cloudError(cloud.ErrThrottled), expectedCode: codes.Unavailable},
+		{name: "Unavailable", err: cloudError(cloud.ErrUnavailable), expectedCode: codes.Unavailable},
+		{name: "Access point limit exceeded", err: cloudError(cloud.ErrAccessPointLimitExceeded), expectedCode: codes.ResourceExhausted},
+		{name: "Network interface limit exceeded", err: cloudError(cloud.ErrNetworkInterfaceLimitExceeded), expectedCode: codes.ResourceExhausted},
+		{name: "Incorrect life cycle state", err: cloudError(cloud.ErrIncorrectLifeCycleState), expectedCode: codes.FailedPrecondition},
+		{name: "Mount target conflict", err: cloudError(cloud.ErrMountTargetConflict), expectedCode: codes.AlreadyExists},
+		{name: "Not found", err: cloud.ErrNotFound, expectedCode: codes.NotFound},
+		{name: "Unknown kind", err: cloudError(nil), expectedCode: codes.Internal},
+		{name: "Not a cloud error", err: errors.New("failed"), expectedCode: codes.Internal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := cloudErrorToStatus(test.err, "Failed to provision volume %v", "vol")
+			if status.Code(err) != test.expectedCode {
+				t.Fatalf("Expected code %v, got %v: %v", test.expectedCode, status.Code(err), err)
+			}
+		})
+	}
+}
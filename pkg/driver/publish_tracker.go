@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/klog"
+)
+
+// publishStateFileName is the name of the file under the efs-utils config directory in which the PublishTracker
+// persists the targets of published volumes, as the directory outlives the driver pod.
+const publishStateFileName = "efs-csi-publishes.json"
+
+// PublishTracker tracks the target paths each volume is published at on the node, so that the usage of a volume is
+// only evicted from the cache once it is no longer published anywhere. The state survives restarts of the driver: it
+// is persisted to a file and reconciled with the mount table on startup.
+type PublishTracker struct {
+	mounter   Mounter
+	stateFile string
+
+	mu sync.Mutex
+	// volumes are the target paths of each published volume, keyed by volume ID
+	volumes map[string]map[string]struct{}
+}
+
+// NewPublishTracker returns a tracker persisting its state to stateFile, or only keeping it in memory when stateFile is
+// empty.
+func NewPublishTracker(mounter Mounter, stateFile string) *PublishTracker {
+	return &PublishTracker{
+		mounter:   mounter,
+		stateFile: stateFile,
+		volumes:   make(map[string]map[string]struct{}),
+	}
+}
+
+// publish records that the volume is published at target.
+func (p *PublishTracker) publish(volumeId, target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.add(volumeId, target)
+	p.save()
+}
+
+// unpublish records that the volume is no longer published at target, and returns the number of targets the volume is
+// still published at.
+func (p *PublishTracker) unpublish(volumeId, target string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	targets, ok := p.volumes[volumeId]
+	if !ok {
+		return 0
+	}
+	delete(targets, target)
+	if len(targets) == 0 {
+		delete(p.volumes, volumeId)
+	}
+	p.save()
+	return len(targets)
+}
+
+// load restores the state persisted by a previous run of the driver, and drops the targets that were unmounted while
+// the driver was not running. Volumes missing from the state, as when it was lost or written by a version of the
+// driver without it, are found from the mount table and the volume data kubelet stores next to each CSI mount.
+func (p *PublishTracker) load() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := make(map[string][]string)
+	if p.stateFile != "" {
+		content, err := ioutil.ReadFile(p.stateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not read %s: %v", p.stateFile, err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(content, &state); err != nil {
+				klog.Warningf("Ignoring invalid publish state in %s: %v", p.stateFile, err)
+			}
+		}
+	}
+
+	mountPoints, err := p.mounter.List()
+	if err != nil {
+		return fmt.Errorf("could not list mounts: %v", err)
+	}
+	mounted := make(map[string]struct{})
+	for _, mp := range mountPoints {
+		mounted[mp.Path] = struct{}{}
+	}
+
+	p.volumes = make(map[string]map[string]struct{})
+	known := make(map[string]struct{})
+	for volumeId, targets := range state {
+		for _, target := range targets {
+			if _, ok := mounted[target]; !ok {
+				klog.V(4).Infof("Volume %s is no longer published at %s", volumeId, target)
+				continue
+			}
+			p.add(volumeId, target)
+			known[target] = struct{}{}
+		}
+	}
+	for target := range mounted {
+		if _, ok := known[target]; ok {
+			continue
+		}
+		if volumeId, ok := readCSIVolumeHandle(target); ok {
+			klog.V(4).Infof("Found volume %s published at %s", volumeId, target)
+			p.add(volumeId, target)
+		}
+	}
+
+	p.save()
+	return nil
+}
+
+// volumeIds returns the IDs of the volumes published on the node.
+func (p *PublishTracker) volumeIds() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	volumeIds := make([]string, 0, len(p.volumes))
+	for volumeId := range p.volumes {
+		volumeIds = append(volumeIds, volumeId)
+	}
+	return volumeIds
+}
+
+func (p *PublishTracker) add(volumeId, target string) {
+	if _, ok := p.volumes[volumeId]; !ok {
+		p.volumes[volumeId] = make(map[string]struct{})
+	}
+	p.volumes[volumeId][target] = struct{}{}
+}
+
+// save persists the state, which must be called with the lock held. Failures are logged rather than failing the
+// publish, as the mount table is the source of truth for the targets on restart.
+func (p *PublishTracker) save() {
+	if p.stateFile == "" {
+		return
+	}
+	state := make(map[string][]string, len(p.volumes))
+	for volumeId, targets := range p.volumes {
+		for target := range targets {
+			state[volumeId] = append(state[volumeId], target)
+		}
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		klog.Errorf("Could not encode publish state: %v", err)
+		return
+	}
+	// Write to a temporary file first so that the state is never left half written
+	tmpFile := p.stateFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		klog.Errorf("Could not write publish state to %s: %v", tmpFile, err)
+		return
+	}
+	if err := os.Rename(tmpFile, p.stateFile); err != nil {
+		klog.Errorf("Could not write publish state to %s: %v", p.stateFile, err)
+	}
+}
+
+// csiVolumeData is the part of the vol_data.json file kubelet writes next to the mount of CSI volumes that is of use.
+type csiVolumeData struct {
+	DriverName   string `json:"driverName"`
+	VolumeHandle string `json:"volumeHandle"`
+}
+
+// readCSIVolumeHandle returns the ID of the volume of this driver published at target, if target is the mount of a CSI
+// volume in a pod. Staging target paths are named globalmount rather than mount.
+func readCSIVolumeHandle(target string) (string, bool) {
+	if filepath.Base(target) != "mount" {
+		return "", false
+	}
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(target), "vol_data.json"))
+	if err != nil {
+		return "", false
+	}
+	var data csiVolumeData
+	if err := json.Unmarshal(content, &data); err != nil || data.DriverName != driverName || data.VolumeHandle == "" {
+		return "", false
+	}
+	return data.VolumeHandle, true
+}
@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/mount-utils"
+)
+
+func TestPublishTracker(t *testing.T) {
+	tracker := NewPublishTracker(NewFakeMounter(), "")
+
+	tracker.publish(volumeId, "/target/path1")
+	tracker.publish(volumeId, "/target/path2")
+	// Publishing again at the same target is idempotent
+	tracker.publish(volumeId, "/target/path2")
+
+	if remaining := tracker.unpublish(volumeId, "/target/path1"); remaining != 1 {
+		t.Fatalf("Expected volume to still be published at 1 target, got %d", remaining)
+	}
+	if remaining := tracker.unpublish(volumeId, "/target/path2"); remaining != 0 {
+		t.Fatalf("Expected volume to no longer be published, got %d targets", remaining)
+	}
+	if remaining := tracker.unpublish("fs-unknown", "/target/path3"); remaining != 0 {
+		t.Fatalf("Expected unknown volume not to be published, got %d targets", remaining)
+	}
+}
+
+func TestPublishTracker_Load(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publish-tracker")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		stateFile      = filepath.Join(dir, publishStateFileName)
+		persisted      = "/target/persisted"
+		unmounted      = "/target/unmounted"
+		podVolumeDir   = filepath.Join(dir, "pods", "uid", "volumes", "kubernetes.io~csi", "pv")
+		discovered     = filepath.Join(podVolumeDir, "mount")
+		discoveredId   = "fs-abcd1234::fsap-abcd1234"
+		otherDriverDir = filepath.Join(dir, "pods", "uid", "volumes", "kubernetes.io~csi", "other")
+	)
+
+	// State persisted by a previous run, in which one of the targets was unmounted since
+	tracker := NewPublishTracker(NewFakeMounter(), stateFile)
+	tracker.publish(volumeId, persisted)
+	tracker.publish(volumeId, unmounted)
+
+	for volDir, data := range map[string]string{
+		podVolumeDir:   `{"driverName":"efs.csi.aws.com","volumeHandle":"` + discoveredId + `"}`,
+		otherDriverDir: `{"driverName":"ebs.csi.aws.com","volumeHandle":"vol-abcd1234"}`,
+	} {
+		if err := os.MkdirAll(volDir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(volDir, "vol_data.json"), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write volume data: %v", err)
+		}
+	}
+
+	mounter := &NodeMounter{
+		Interface: &mount.FakeMounter{
+			MountPoints: []mount.MountPoint{
+				{Path: persisted},
+				{Path: discovered},
+				{Path: filepath.Join(otherDriverDir, "mount")},
+			},
+		},
+	}
+	tracker = NewPublishTracker(mounter, stateFile)
+	if err := tracker.load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expected := map[string]map[string]struct{}{
+		volumeId:     {persisted: {}},
+		discoveredId: {discovered: {}},
+	}
+	if !reflect.DeepEqual(expected, tracker.volumes) {
+		t.Fatalf("Expected: %v, Actual: %v", expected, tracker.volumes)
+	}
+}
@@ -0,0 +1,286 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
+)
+
+// Reasons of the events emitted on the PVC of a volume with quota enforcement
+const (
+	quotaExceededReason = "QuotaExceeded"
+	quotaRestoredReason = "QuotaRestored"
+)
+
+// quotaCheckInterval is how often the usage of published volumes is compared with their quota. Usage itself is
+// refreshed by the VolStatter every --vol-metrics-refresh-period.
+var quotaCheckInterval = time.Minute
+
+type quotaVolume struct {
+	volumeId   string
+	limitBytes int64
+	mode       string
+	// pvName is used to follow the capacity of the PV as it is expanded, and is empty when the volume was not
+	// provisioned with --extra-create-metadata
+	pvName string
+	// pvc is nil when the volume was not provisioned with --extra-create-metadata, in which case no events are emitted
+	pvc *v1.ObjectReference
+	// usedBytes is the usage of the volume when it was last checked, and is only set once measured is
+	usedBytes int64
+	measured  bool
+	exceeded  bool
+	readOnly  bool
+}
+
+// QuotaEnforcer compares the usage of published volumes with the capacity requested by their PVC. EFS has no notion of
+// quotas, so they are soft: exceeding one is reported through events and the volume condition, and volumes in
+// read-only mode are remounted read-only until their usage drops below the limit again.
+type QuotaEnforcer struct {
+	mounter       Mounter
+	volStatter    VolStatter
+	kubeClient    kubernetes.Interface
+	recorder      record.EventRecorder
+	refreshPeriod float64
+	fsRateLimit   int
+
+	mu sync.Mutex
+	// volumes are keyed by target path, as the same volume may be published more than once on a node
+	volumes map[string]*quotaVolume
+}
+
+func NewQuotaEnforcer(mounter Mounter, volStatter VolStatter, kubeClient kubernetes.Interface, recorder record.EventRecorder, refreshPeriod float64, fsRateLimit int) *QuotaEnforcer {
+	return &QuotaEnforcer{
+		mounter:       mounter,
+		volStatter:    volStatter,
+		kubeClient:    kubeClient,
+		recorder:      recorder,
+		refreshPeriod: refreshPeriod,
+		fsRateLimit:   fsRateLimit,
+		volumes:       make(map[string]*quotaVolume),
+	}
+}
+
+// newKubernetesClient returns a client of the Kubernetes API, or nil when the driver does not run in a cluster.
+func newKubernetesClient() kubernetes.Interface {
+	clientset, err := cloud.DefaultKubernetesAPIClient()
+	if err != nil {
+		klog.Warningf("Could not create Kubernetes API client, quota events will not be emitted, expanded capacities will not be followed and volumes will not be listed: %v", err)
+		return nil
+	}
+	return clientset
+}
+
+// newEventRecorder returns a recorder emitting events through the Kubernetes API, or nil without a client.
+func newEventRecorder(clientset kubernetes.Interface) record.EventRecorder {
+	if clientset == nil {
+		return nil
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: driverName})
+}
+
+func (q *QuotaEnforcer) start() {
+	go wait.Forever(q.enforce, quotaCheckInterval)
+}
+
+func (q *QuotaEnforcer) track(volumeId, targetPath string, limitBytes int64, mode, pvName, pvcNamespace, pvcName string) {
+	volume := &quotaVolume{
+		volumeId:   volumeId,
+		limitBytes: limitBytes,
+		mode:       mode,
+		pvName:     pvName,
+	}
+	if pvcName != "" && pvcNamespace != "" {
+		volume.pvc = &v1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  pvcNamespace,
+			Name:       pvcName,
+		}
+	}
+
+	q.mu.Lock()
+	q.volumes[targetPath] = volume
+	q.mu.Unlock()
+}
+
+func (q *QuotaEnforcer) untrack(targetPath string) {
+	q.mu.Lock()
+	delete(q.volumes, targetPath)
+	q.mu.Unlock()
+}
+
+// getQuota returns the quota of the volume published at targetPath and whether its usage exceeds it. ok is false when
+// the volume has no quota enforcement.
+func (q *QuotaEnforcer) getQuota(targetPath string) (limitBytes int64, exceeded, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	volume, ok := q.volumes[targetPath]
+	if !ok {
+		return 0, false, false
+	}
+	return volume.limitBytes, volume.exceeded, true
+}
+
+// quotas returns copies of the volumes tracked.
+func (q *QuotaEnforcer) quotas() []quotaVolume {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	volumes := make([]quotaVolume, 0, len(q.volumes))
+	for _, volume := range q.volumes {
+		volumes = append(volumes, *volume)
+	}
+	return volumes
+}
+
+// enforce checks the volumes tracked when it starts. Checking gets PVs and walks volumes, so it works on copies of the
+// volumes without holding the lock, which publishing and unpublishing need. The results are only recorded for volumes
+// that are still tracked and were not published again in the meantime.
+func (q *QuotaEnforcer) enforce() {
+	q.mu.Lock()
+	tracked := make(map[string]*quotaVolume, len(q.volumes))
+	for targetPath, volume := range q.volumes {
+		tracked[targetPath] = volume
+	}
+	q.mu.Unlock()
+
+	for targetPath, volume := range tracked {
+		q.mu.Lock()
+		checked := *volume
+		q.mu.Unlock()
+
+		q.check(targetPath, &checked)
+
+		q.mu.Lock()
+		if q.volumes[targetPath] == volume {
+			*volume = checked
+		}
+		q.mu.Unlock()
+	}
+}
+
+// check compares the usage of the volume with its quota, updating volume with the results.
+func (q *QuotaEnforcer) check(targetPath string, volume *quotaVolume) {
+	q.refreshLimit(volume)
+
+	metrics, err := q.volStatter.computeVolumeMetrics(volume.volumeId, targetPath, q.refreshPeriod, q.fsRateLimit)
+	if err != nil {
+		klog.Errorf("Could not get usage of volume %v at %v: %v", volume.volumeId, targetPath, err)
+		return
+	}
+	used, ok := getUsedBytes(metrics.volUsage)
+	if !ok {
+		// Usage is still being computed
+		return
+	}
+
+	volume.usedBytes, volume.measured = used, true
+
+	exceeded := used > volume.limitBytes
+	if exceeded && !volume.exceeded {
+		klog.Warningf("Volume %v at %v uses %d bytes, exceeding its quota of %d bytes", volume.volumeId, targetPath, used, volume.limitBytes)
+		q.event(volume, v1.EventTypeWarning, quotaExceededReason, "Volume uses %d bytes, exceeding the requested capacity of %d bytes", used, volume.limitBytes)
+	} else if !exceeded && volume.exceeded {
+		klog.Infof("Volume %v at %v uses %d bytes, back within its quota of %d bytes", volume.volumeId, targetPath, used, volume.limitBytes)
+		q.event(volume, v1.EventTypeNormal, quotaRestoredReason, "Volume uses %d bytes, back within the requested capacity of %d bytes", used, volume.limitBytes)
+	}
+	volume.exceeded = exceeded
+
+	if volume.mode == QuotaEnforcementReadOnly && exceeded != volume.readOnly {
+		if err := q.remount(targetPath, exceeded); err != nil {
+			klog.Errorf("Could not remount volume %v at %v: %v", volume.volumeId, targetPath, err)
+			return
+		}
+		volume.readOnly = exceeded
+	}
+}
+
+// refreshLimit updates the quota of the volume to the capacity of its PV. The limit in the volume context is the
+// capacity at provisioning time, while ControllerExpandVolume does not require the node to be told about expansion.
+func (q *QuotaEnforcer) refreshLimit(volume *quotaVolume) {
+	if q.kubeClient == nil || volume.pvName == "" {
+		return
+	}
+	pv, err := q.kubeClient.CoreV1().PersistentVolumes().Get(context.TODO(), volume.pvName, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Could not get PV %v of volume %v, keeping quota of %d bytes: %v", volume.pvName, volume.volumeId, volume.limitBytes, err)
+		return
+	}
+	capacity, ok := pv.Spec.Capacity[v1.ResourceStorage]
+	if !ok || capacity.Value() <= 0 || capacity.Value() == volume.limitBytes {
+		return
+	}
+	klog.Infof("Quota of volume %v changed from %d to %d bytes", volume.volumeId, volume.limitBytes, capacity.Value())
+	volume.limitBytes = capacity.Value()
+}
+
+// remount toggles the volume published at targetPath between read-only and read-write. The superblock rather than the
+// bind mount is remounted so that the change is seen by the containers already using the volume.
+func (q *QuotaEnforcer) remount(targetPath string, readOnly bool) error {
+	option := "rw"
+	if readOnly {
+		option = "ro"
+	}
+	klog.V(4).Infof("Remounting %s %s", targetPath, option)
+	return q.mounter.Mount("", targetPath, "", []string{"remount", option})
+}
+
+func (q *QuotaEnforcer) event(volume *quotaVolume, eventType, reason, messageFmt string, args ...interface{}) {
+	if q.recorder == nil || volume.pvc == nil {
+		return
+	}
+	q.recorder.Eventf(volume.pvc, eventType, reason, messageFmt, args...)
+}
+
+func getUsedBytes(usage []*csi.VolumeUsage) (int64, bool) {
+	for _, u := range usage {
+		if u.GetUnit() == csi.VolumeUsage_BYTES {
+			return u.GetUsed(), true
+		}
+	}
+	return 0, false
+}
+
+// applyQuota reports the quota as the total size of the volume, so that kubelet volume stats reflect the capacity
+// requested by the PVC rather than the virtually unlimited size of the file system.
+func applyQuota(usage []*csi.VolumeUsage, limitBytes int64) []*csi.VolumeUsage {
+	quotaUsage := make([]*csi.VolumeUsage, 0, len(usage))
+	for _, u := range usage {
+		if u.GetUnit() != csi.VolumeUsage_BYTES {
+			quotaUsage = append(quotaUsage, u)
+			continue
+		}
+		available := limitBytes - u.GetUsed()
+		if available < 0 {
+			available = 0
+		}
+		quotaUsage = append(quotaUsage, &csi.VolumeUsage{
+			Unit:      csi.VolumeUsage_BYTES,
+			Used:      u.GetUsed(),
+			Available: available,
+			Total:     limitBytes,
+		})
+	}
+	return quotaUsage
+}
+
+func quotaVolumeCondition(limitBytes int64, exceeded bool) *csi.VolumeCondition {
+	if exceeded {
+		return abnormalVolumeCondition("Volume usage exceeds its quota of %d bytes", limitBytes)
+	}
+	return &csi.VolumeCondition{Message: fmt.Sprintf("Volume usage is within its quota of %d bytes", limitBytes)}
+}
@@ -0,0 +1,280 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/golang/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
+
+type fakeVolStatter struct {
+	usage []*csi.VolumeUsage
+	// computing is called while the usage of a volume is computed, if set
+	computing func()
+}
+
+func (f *fakeVolStatter) computeVolumeMetrics(_, volPath string, _ float64, _ int) (*volMetrics, error) {
+	if f.computing != nil {
+		f.computing()
+	}
+	return &volMetrics{volPath: volPath, volUsage: f.usage}, nil
+}
+
+func (f *fakeVolStatter) retrieveFromCache(_ string) (*volMetrics, bool) {
+	return nil, false
+}
+
+func (f *fakeVolStatter) removeFromCache(_ string) {
+}
+
+func (f *fakeVolStatter) cancelVolumeMetrics(_, _ string) {
+}
+
+func bytesUsage(used int64) []*csi.VolumeUsage {
+	return []*csi.VolumeUsage{
+		{
+			Unit:      csi.VolumeUsage_BYTES,
+			Used:      used,
+			Available: 1 << 50,
+			Total:     1<<50 + used,
+		},
+	}
+}
+
+func TestQuotaEnforcer_Enforce(t *testing.T) {
+	var (
+		volId      = "fs-abcd1234::fsap-abcd1234"
+		target     = "/target/path"
+		limitBytes = int64(1000)
+	)
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Exceeding the quota emits an event on the PVC",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+				recorder := record.NewFakeRecorder(10)
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, recorder, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReport, "", "default", "claim")
+				enforcer.enforce()
+
+				if _, exceeded, _ := enforcer.getQuota(target); !exceeded {
+					t.Fatal("Expected quota to be exceeded")
+				}
+				if len(recorder.Events) != 1 {
+					t.Fatalf("Expected 1 event, got %d", len(recorder.Events))
+				}
+				if event := <-recorder.Events; !strings.Contains(event, quotaExceededReason) {
+					t.Fatalf("Expected %s event, got %q", quotaExceededReason, event)
+				}
+
+				// Events are only emitted on transitions
+				enforcer.enforce()
+				if len(recorder.Events) != 0 {
+					t.Fatalf("Expected no event, got %d", len(recorder.Events))
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Read-only volume is remounted read-only until usage drops below the quota",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+				recorder := record.NewFakeRecorder(10)
+
+				gomock.InOrder(
+					mockMounter.EXPECT().Mount("", target, "", []string{"remount", "ro"}).Return(nil),
+					mockMounter.EXPECT().Mount("", target, "", []string{"remount", "rw"}).Return(nil),
+				)
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, recorder, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReadOnly, "", "default", "claim")
+				enforcer.enforce()
+
+				volStatter.usage = bytesUsage(500)
+				enforcer.enforce()
+
+				if _, exceeded, _ := enforcer.getQuota(target); exceeded {
+					t.Fatal("Expected quota not to be exceeded")
+				}
+				if event := <-recorder.Events; !strings.Contains(event, quotaExceededReason) {
+					t.Fatalf("Expected %s event, got %q", quotaExceededReason, event)
+				}
+				if event := <-recorder.Events; !strings.Contains(event, quotaRestoredReason) {
+					t.Fatalf("Expected %s event, got %q", quotaRestoredReason, event)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Failed remount is retried on the next check",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+
+				gomock.InOrder(
+					mockMounter.EXPECT().Mount("", target, "", []string{"remount", "ro"}).Return(errors.New("remount failed")),
+					mockMounter.EXPECT().Mount("", target, "", []string{"remount", "ro"}).Return(nil),
+				)
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReadOnly, "", "", "")
+				enforcer.enforce()
+				enforcer.enforce()
+				// Already read-only
+				enforcer.enforce()
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Quota follows the capacity of the expanded PV",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+				kubeClient := fake.NewSimpleClientset(&v1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+					Spec: v1.PersistentVolumeSpec{
+						Capacity: v1.ResourceList{v1.ResourceStorage: *resource.NewQuantity(2000, resource.BinarySI)},
+					},
+				})
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, kubeClient, nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReadOnly, "pv", "", "")
+				enforcer.enforce()
+
+				limit, exceeded, _ := enforcer.getQuota(target)
+				if limit != 2000 {
+					t.Fatalf("Expected quota of 2000 bytes, got %d", limit)
+				}
+				if exceeded {
+					t.Fatal("Expected quota not to be exceeded")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Quota is kept when the PV cannot be found",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(500)}
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, fake.NewSimpleClientset(), nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReport, "pv", "", "")
+				enforcer.enforce()
+
+				if limit, _, _ := enforcer.getQuota(target); limit != limitBytes {
+					t.Fatalf("Expected quota of %d bytes, got %d", limitBytes, limit)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Volume whose usage is still being computed is skipped",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: []*csi.VolumeUsage{{Unit: csi.VolumeUsage_UNKNOWN}}}
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReadOnly, "", "", "")
+				enforcer.enforce()
+
+				if _, exceeded, _ := enforcer.getQuota(target); exceeded {
+					t.Fatal("Expected quota not to be exceeded")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Untracked volume is no longer checked",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReadOnly, "", "", "")
+				enforcer.untrack(target)
+				enforcer.enforce()
+
+				if _, _, ok := enforcer.getQuota(target); ok {
+					t.Fatal("Expected volume not to be tracked")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Volumes can be published while they are checked",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				volStatter := &fakeVolStatter{usage: bytesUsage(1500)}
+
+				enforcer := NewQuotaEnforcer(mockMounter, volStatter, nil, nil, 240, 5)
+				enforcer.track(volId, target, limitBytes, QuotaEnforcementReport, "", "", "")
+				volStatter.computing = func() {
+					// The volume is unpublished and published again with a larger quota during the check
+					enforcer.untrack(target)
+					enforcer.track(volId, target, 2000, QuotaEnforcementReport, "", "", "")
+				}
+				enforcer.enforce()
+
+				limit, exceeded, ok := enforcer.getQuota(target)
+				if !ok || limit != 2000 {
+					t.Fatalf("Expected quota of 2000 bytes, got %d", limit)
+				}
+				if exceeded {
+					t.Fatal("Expected the result of the check of the unpublished volume to be dropped")
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
+
+func TestApplyQuota(t *testing.T) {
+	usage := []*csi.VolumeUsage{
+		bytesUsage(1500)[0],
+		{
+			Unit: csi.VolumeUsage_INODES,
+			Used: 10,
+		},
+	}
+
+	expected := []*csi.VolumeUsage{
+		{
+			Unit:      csi.VolumeUsage_BYTES,
+			Used:      1500,
+			Available: 0,
+			Total:     1000,
+		},
+		usage[1],
+	}
+	if actual := applyQuota(usage, 1000); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
@@ -105,6 +105,7 @@ func waitIfZombieStunnel(p ps.Process) bool {
 					klog.Warningf("reaper: failed to wait for process %v: %v", p, err)
 				}
 				klog.V(4).Infof("reaper: waited for process %v", p)
+				reaperReapedTotal.Inc()
 				return true
 			} else {
 				return false
@@ -25,8 +25,10 @@ import (
 
 	"k8s.io/mount-utils"
 
+	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
 	"github.com/kubernetes-csi/csi-test/pkg/sanity"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/cloud"
 )
@@ -65,23 +67,31 @@ func TestSanityEFSCSI(t *testing.T) {
 		TestVolumeParameters: parameters,
 	}
 
-	nodeCaps := SetNodeCapOptInFeatures(true)
-
 	mockCtrl := gomock.NewController(t)
 	mockCloud := cloud.NewFakeCloudProvider()
 	mounter := NewFakeMounter()
+	volStatter := NewVolStatter("")
 
 	drv := Driver{
 		endpoint:          endpoint,
 		nodeID:            "sanity",
+		availabilityZone:  "us-east-1a",
 		mounter:           mounter,
 		efsWatchdog:       &mockWatchdog{},
 		cloud:             mockCloud,
-		nodeCaps:          nodeCaps,
+		pluginCaps:        sanityPluginCaps(),
+		controllerCaps:    sanityControllerCaps(),
+		nodeCaps:          sanityNodeCaps(),
 		volMetricsOptIn:   true,
-		volStatter:        NewVolStatter(),
+		nodeStageOptIn:    true,
+		volStatter:        volStatter,
+		quotaEnforcer:     NewQuotaEnforcer(mounter, volStatter, nil, nil, 240, 5),
+		kubeClient:        fake.NewSimpleClientset(),
+		publishTracker:    NewPublishTracker(mounter, ""),
 		provisioners:      getProvisioners(nil, mockCloud, false, mounter, &FakeOsClient{}, false),
+		backupVaultName:   "Default",
 		fsIdentityManager: NewFileSystemIdentityManager(),
+		inFlight:          NewInFlight(),
 	}
 	defer func() {
 		if r := recover(); r != nil {
@@ -100,6 +110,45 @@ func TestSanityEFSCSI(t *testing.T) {
 	mockCtrl.Finish()
 }
 
+// sanityPluginCaps filters out the plugin capabilities that are not services, as the identity sanity suite fails on
+// them.
+func sanityPluginCaps() []*csi.PluginCapability {
+	var caps []*csi.PluginCapability
+	for _, cap := range pluginCaps {
+		if cap.GetService() == nil {
+			continue
+		}
+		caps = append(caps, cap)
+	}
+	return caps
+}
+
+// sanityControllerCaps filters out the controller capabilities that were added to the CSI spec after the
+// csi-test version in use, as its sanity suite fails on any capability it does not know about.
+func sanityControllerCaps() []csi.ControllerServiceCapability_RPC_Type {
+	var caps []csi.ControllerServiceCapability_RPC_Type
+	for _, cap := range controllerCaps {
+		switch cap {
+		case csi.ControllerServiceCapability_RPC_GET_VOLUME, csi.ControllerServiceCapability_RPC_VOLUME_CONDITION:
+			continue
+		}
+		caps = append(caps, cap)
+	}
+	return caps
+}
+
+// sanityNodeCaps is the node counterpart of sanityControllerCaps.
+func sanityNodeCaps() []csi.NodeServiceCapability_RPC_Type {
+	var caps []csi.NodeServiceCapability_RPC_Type
+	for _, cap := range SetNodeCapOptInFeatures(true, true) {
+		if cap == csi.NodeServiceCapability_RPC_VOLUME_CONDITION {
+			continue
+		}
+		caps = append(caps, cap)
+	}
+	return caps
+}
+
 func NewFakeMounter() Mounter {
 	return &NodeMounter{
 		Interface: &mount.FakeMounter{
@@ -0,0 +1,250 @@
+package driver
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/klog"
+	"k8s.io/mount-utils"
+)
+
+// sharedMountsDir is where the root mounts shared between the volumes of a file system are made. It is under the
+// kubelet directory so that the root mounts are propagated to the host along with the bind mounts of the volumes.
+const sharedMountsDir = "/var/lib/kubelet/plugins/efs.csi.aws.com/mounts"
+
+const mountInfoPath = "/proc/self/mountinfo"
+
+// SharedMountManager mounts the root of each file system once per node, and exposes volumes by bind mounting their
+// subpath from it, so that all the volumes of a file system go through a single mount and TLS tunnel. Volumes with an
+// access point still need a mount of their own, as the access point is enforced by the mount, but it is shared between
+// the publishes of the volume.
+//
+// Root mounts are reference-counted by the targets bind mounted from them and unmounted with the last one. Targets
+// are not persisted, they are rebuilt from the mount table by restore: bind mounts are on the same device as the
+// mount they were made from.
+type SharedMountManager struct {
+	mounter       Mounter
+	dir           string
+	mountInfoPath string
+
+	mu sync.Mutex
+	// targets are the targets bind mounted from each root mount, keyed by the path of the root mount
+	targets map[string]map[string]struct{}
+	// roots are the root mount of each target
+	roots map[string]string
+	// locks serialize the mounts and unmounts of each root mount, so that mounting a file system over the network does
+	// not hold up the volumes of other file systems
+	locks map[string]*rootLock
+}
+
+type rootLock struct {
+	sync.Mutex
+	// users are the callers holding or waiting for the lock
+	users int
+}
+
+func NewSharedMountManager(mounter Mounter, dir string) *SharedMountManager {
+	return &SharedMountManager{
+		mounter:       mounter,
+		dir:           dir,
+		mountInfoPath: mountInfoPath,
+		targets:       make(map[string]map[string]struct{}),
+		roots:         make(map[string]string),
+		locks:         make(map[string]*rootLock),
+	}
+}
+
+// lockRoot locks the root mount, returning the function unlocking it.
+func (s *SharedMountManager) lockRoot(root string) func() {
+	s.mu.Lock()
+	lock, ok := s.locks[root]
+	if !ok {
+		lock = &rootLock{}
+		s.locks[root] = lock
+	}
+	lock.users++
+	s.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		lock.users--
+		if lock.users == 0 {
+			delete(s.locks, root)
+		}
+	}
+}
+
+// mount bind mounts the volume at target from the root mount of its file system, mounting the root first if needed.
+func (s *SharedMountManager) mount(vm *volumeMount, target string) error {
+	rootOptions, readOnly := vm.rootMountOptions()
+	root := filepath.Join(s.dir, rootMountName(vm, rootOptions))
+
+	unlock := s.lockRoot(root)
+	defer unlock()
+
+	s.mu.Lock()
+	_, mounted := s.targets[root]
+	s.mu.Unlock()
+	if !mounted {
+		rootSource := fmt.Sprintf("%s:/", vm.fsid)
+		klog.V(5).Infof("Mounting %s at %s with options %v", rootSource, root, rootOptions)
+		if err := s.mounter.MakeDir(root); err != nil {
+			return fmt.Errorf("could not create dir %q: %v", root, err)
+		}
+		if err := s.mounter.Mount(rootSource, root, "efs", rootOptions); err != nil {
+			os.Remove(root)
+			return fmt.Errorf("could not mount %q at %q: %v", rootSource, root, err)
+		}
+		s.mu.Lock()
+		s.targets[root] = make(map[string]struct{})
+		s.mu.Unlock()
+	}
+
+	source := filepath.Join(root, vm.subpath)
+	options := []string{"bind"}
+	if readOnly {
+		options = append(options, "ro")
+	}
+	klog.V(5).Infof("Bind mounting %s at %s with options %v", source, target, options)
+	if err := s.mounter.Mount(source, target, "", options); err != nil {
+		s.mu.Lock()
+		unused := len(s.targets[root]) == 0
+		s.mu.Unlock()
+		if unused {
+			s.unmountRoot(root)
+		}
+		return fmt.Errorf("could not bind mount %q at %q: %v", source, target, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets[root][target] = struct{}{}
+	s.roots[target] = root
+	return nil
+}
+
+// release is called once target is unmounted, and unmounts the root mount it was bind mounted from if it was the last
+// target using it. Targets not mounted by the manager are ignored.
+func (s *SharedMountManager) release(target string) error {
+	s.mu.Lock()
+	root, ok := s.roots[target]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	unlock := s.lockRoot(root)
+	defer unlock()
+
+	s.mu.Lock()
+	if s.roots[target] != root {
+		// Released concurrently
+		s.mu.Unlock()
+		return nil
+	}
+	delete(s.roots, target)
+	delete(s.targets[root], target)
+	unused := len(s.targets[root]) == 0
+	s.mu.Unlock()
+
+	if !unused {
+		return nil
+	}
+	return s.unmountRoot(root)
+}
+
+// unmountRoot unmounts the root mount, whose lock must be held.
+func (s *SharedMountManager) unmountRoot(root string) error {
+	klog.V(5).Infof("Unmounting %s as no volume uses it anymore", root)
+	if err := s.mounter.Unmount(root); err != nil {
+		return fmt.Errorf("could not unmount %q: %v", root, err)
+	}
+	s.mu.Lock()
+	delete(s.targets, root)
+	s.mu.Unlock()
+	os.Remove(root)
+	return nil
+}
+
+// restore rebuilds the targets of the root mounts after a restart of the driver from the mount table, and unmounts the
+// root mounts that are no longer used. A bind mount is on the same device as the mount it was made from, and has the
+// same source, while the path it exposes is within the path the root mount exposes.
+func (s *SharedMountManager) restore() error {
+	infos, err := mount.ParseMountInfo(s.mountInfoPath)
+	if err != nil {
+		return fmt.Errorf("could not read mount table: %v", err)
+	}
+
+	var unused []string
+	s.mu.Lock()
+	roots := make(map[string][]mount.MountInfo)
+	for _, info := range infos {
+		if filepath.Dir(info.MountPoint) == s.dir {
+			key := fmt.Sprintf("%d:%d", info.Major, info.Minor)
+			roots[key] = append(roots[key], info)
+			s.targets[info.MountPoint] = make(map[string]struct{})
+		}
+	}
+	for _, info := range infos {
+		if filepath.Dir(info.MountPoint) == s.dir {
+			continue
+		}
+		for _, root := range roots[fmt.Sprintf("%d:%d", info.Major, info.Minor)] {
+			if isBindMountOf(info, root) {
+				s.targets[root.MountPoint][info.MountPoint] = struct{}{}
+				s.roots[info.MountPoint] = root.MountPoint
+				break
+			}
+		}
+	}
+	for root, targets := range s.targets {
+		if len(targets) > 0 {
+			klog.Infof("Restored root mount %s used by %d volumes", root, len(targets))
+			continue
+		}
+		unused = append(unused, root)
+	}
+	s.mu.Unlock()
+
+	sort.Strings(unused)
+	for _, root := range unused {
+		unlock := s.lockRoot(root)
+		if err := s.unmountRoot(root); err != nil {
+			klog.Errorf("Could not clean up unused root mount: %v", err)
+		}
+		unlock()
+	}
+	return nil
+}
+
+// isBindMountOf reports whether the mount described by info may have been bind mounted from the root mount.
+func isBindMountOf(info, root mount.MountInfo) bool {
+	if info.Source != root.Source || info.FsType != root.FsType {
+		return false
+	}
+	rootPath := strings.TrimSuffix(root.Root, "/")
+	return info.Root == root.Root || strings.HasPrefix(info.Root, rootPath+"/")
+}
+
+// rootMountName returns the name of the root mount of the volume, which is shared with volumes of the same file
+// system and access point mounted with the same options.
+func rootMountName(vm *volumeMount, rootOptions []string) string {
+	options := append([]string{}, rootOptions...)
+	sort.Strings(options)
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(options, ",")))
+
+	name := vm.fsid
+	if vm.apid != "" {
+		name += "-" + vm.apid
+	}
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
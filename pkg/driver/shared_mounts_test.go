@@ -0,0 +1,226 @@
+package driver
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/kubernetes-sigs/aws-efs-csi-driver/pkg/driver/mocks"
+)
+
+func TestSharedMountManager(t *testing.T) {
+	var (
+		dir          = "/shared/mounts"
+		fsid         = "fs-abcd1234"
+		apid         = "fsap-abcd1234"
+		target1      = "/target/path1"
+		target2      = "/target/path2"
+		rootOptions  = []string{"tls"}
+		subpathMount = &volumeMount{fsid: fsid, subpath: "/a", mountOptions: rootOptions}
+		otherMount   = &volumeMount{fsid: fsid, subpath: "/b", mountOptions: []string{"tls", "ro"}}
+		root         = filepath.Join(dir, rootMountName(subpathMount, rootOptions))
+	)
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Volumes of a file system share its root mount until the last one is released",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				gomock.InOrder(
+					mockMounter.EXPECT().MakeDir(root).Return(nil),
+					mockMounter.EXPECT().Mount(fsid+":/", root, "efs", rootOptions).Return(nil),
+					mockMounter.EXPECT().Mount(root+"/a", target1, "", []string{"bind"}).Return(nil),
+					mockMounter.EXPECT().Mount(root+"/b", target2, "", []string{"bind", "ro"}).Return(nil),
+					mockMounter.EXPECT().Unmount(root).Return(nil),
+				)
+
+				manager := NewSharedMountManager(mockMounter, dir)
+				if err := manager.mount(subpathMount, target1); err != nil {
+					t.Fatalf("Mount failed: %v", err)
+				}
+				if err := manager.mount(otherMount, target2); err != nil {
+					t.Fatalf("Mount failed: %v", err)
+				}
+				if err := manager.release(target1); err != nil {
+					t.Fatalf("Release failed: %v", err)
+				}
+				if err := manager.release(target2); err != nil {
+					t.Fatalf("Release failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Volumes with an access point have their own root mount",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+				apOptions := []string{"accesspoint=" + apid, "tls"}
+				apMount := &volumeMount{fsid: fsid, apid: apid, subpath: "/", mountOptions: apOptions}
+				apRoot := filepath.Join(dir, rootMountName(apMount, apOptions))
+				if apRoot == root {
+					t.Fatalf("Expected access point volume not to share root mount %s", root)
+				}
+
+				gomock.InOrder(
+					mockMounter.EXPECT().MakeDir(apRoot).Return(nil),
+					mockMounter.EXPECT().Mount(fsid+":/", apRoot, "efs", apOptions).Return(nil),
+					mockMounter.EXPECT().Mount(apRoot, target1, "", []string{"bind"}).Return(nil),
+				)
+
+				manager := NewSharedMountManager(mockMounter, dir)
+				if err := manager.mount(apMount, target1); err != nil {
+					t.Fatalf("Mount failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Success: Targets not mounted by the manager are ignored",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				manager := NewSharedMountManager(mockMounter, dir)
+				if err := manager.release(target1); err != nil {
+					t.Fatalf("Release failed: %v", err)
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Root mount is unmounted when the first bind mount fails",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				gomock.InOrder(
+					mockMounter.EXPECT().MakeDir(root).Return(nil),
+					mockMounter.EXPECT().Mount(fsid+":/", root, "efs", rootOptions).Return(nil),
+					mockMounter.EXPECT().Mount(root+"/a", target1, "", []string{"bind"}).Return(errors.New("no such file or directory")),
+					mockMounter.EXPECT().Unmount(root).Return(nil),
+				)
+
+				manager := NewSharedMountManager(mockMounter, dir)
+				if err := manager.mount(subpathMount, target1); err == nil {
+					t.Fatal("Mount succeeded but it should have failed")
+				}
+				mockCtl.Finish()
+			},
+		},
+		{
+			name: "Fail: Root mount fails",
+			testFunc: func(t *testing.T) {
+				mockCtl := gomock.NewController(t)
+				mockMounter := mocks.NewMockMounter(mockCtl)
+
+				gomock.InOrder(
+					mockMounter.EXPECT().MakeDir(root).Return(nil),
+					mockMounter.EXPECT().Mount(fsid+":/", root, "efs", rootOptions).Return(errors.New("mount failed")),
+				)
+
+				manager := NewSharedMountManager(mockMounter, dir)
+				if err := manager.mount(subpathMount, target1); err == nil {
+					t.Fatal("Mount succeeded but it should have failed")
+				}
+				mockCtl.Finish()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
+
+func TestSharedMountManager_Restore(t *testing.T) {
+	dir := "/shared/mounts"
+	usedRoot := dir + "/fs-abcd1234-00000000"
+	unusedRoot := dir + "/fs-efgh5678-00000000"
+	mountInfo := `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/root rw
+100 22 0:50 / ` + usedRoot + ` rw,relatime shared:50 - nfs4 127.0.0.1:/ rw
+101 22 0:50 /a /target/path1 rw,relatime shared:50 - nfs4 127.0.0.1:/ rw
+103 22 0:50 /b /target/path2 rw,relatime shared:50 - nfs4 127.0.0.2:/ rw
+102 22 0:51 / ` + unusedRoot + ` rw,relatime shared:51 - nfs4 127.0.0.1:/ rw
+`
+	file, err := ioutil.TempFile("", "mountinfo")
+	if err != nil {
+		t.Fatalf("Failed to create mountinfo file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(mountInfo); err != nil {
+		t.Fatalf("Failed to write mountinfo file: %v", err)
+	}
+	file.Close()
+
+	mockCtl := gomock.NewController(t)
+	mockMounter := mocks.NewMockMounter(mockCtl)
+	gomock.InOrder(
+		mockMounter.EXPECT().Unmount(unusedRoot).Return(nil),
+		mockMounter.EXPECT().Unmount(usedRoot).Return(nil),
+	)
+
+	manager := NewSharedMountManager(mockMounter, dir)
+	manager.mountInfoPath = file.Name()
+	if err := manager.restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	// The mount on the same device from another source was not bind mounted from the root mount
+	if err := manager.release("/target/path2"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := manager.release("/target/path1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	mockCtl.Finish()
+}
+
+func TestSharedMountManager_ConcurrentFileSystems(t *testing.T) {
+	var (
+		dir         = "/shared/mounts"
+		rootOptions = []string{"tls"}
+		slowMount   = &volumeMount{fsid: "fs-abcd1234", subpath: "/", mountOptions: rootOptions}
+		fastMount   = &volumeMount{fsid: "fs-efgh5678", subpath: "/", mountOptions: rootOptions}
+		slowRoot    = filepath.Join(dir, rootMountName(slowMount, rootOptions))
+		fastRoot    = filepath.Join(dir, rootMountName(fastMount, rootOptions))
+	)
+
+	mockCtl := gomock.NewController(t)
+	mockMounter := mocks.NewMockMounter(mockCtl)
+	mounting := make(chan struct{})
+	unblock := make(chan struct{})
+	mockMounter.EXPECT().MakeDir(gomock.Any()).Return(nil).Times(2)
+	mockMounter.EXPECT().Mount("fs-abcd1234:/", slowRoot, "efs", rootOptions).DoAndReturn(
+		func(source, target, fstype string, options []string) error {
+			close(mounting)
+			<-unblock
+			return nil
+		})
+	mockMounter.EXPECT().Mount("fs-efgh5678:/", fastRoot, "efs", rootOptions).Return(nil)
+	mockMounter.EXPECT().Mount(gomock.Any(), gomock.Any(), "", []string{"bind"}).Return(nil).Times(2)
+
+	manager := NewSharedMountManager(mockMounter, dir)
+	done := make(chan error)
+	go func() {
+		done <- manager.mount(slowMount, "/target/path1")
+	}()
+
+	// The file system mounted while another one is still being mounted does not wait for it
+	<-mounting
+	if err := manager.mount(fastMount, "/target/path2"); err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("Mount failed: %v", err)
+	}
+	mockCtl.Finish()
+}
@@ -0,0 +1,355 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// usageCheckpointDirName is the directory under the efs-utils config directory the progress of computing the usage of
+// volumes is checkpointed to, as the directory outlives the driver pod.
+const usageCheckpointDirName = "efs-csi-usage"
+
+const (
+	// usageCheckpointInterval is how often the progress of a walk is checkpointed. Walks that complete sooner never
+	// write a checkpoint.
+	usageCheckpointInterval = time.Minute
+	// usageCheckpointMaxAge is how long a checkpoint can be resumed from before the usage it holds is considered too
+	// stale to be reused.
+	usageCheckpointMaxAge = 24 * time.Hour
+	// readDirBatchSize is the number of entries read from a directory at once, which bounds the memory used to walk
+	// directories with many entries.
+	readDirBatchSize = 1024
+)
+
+// openDir opens the directories read by the usage walkers.
+var openDir = os.Open
+
+type diskUsage struct {
+	bytes  int64
+	inodes int64
+	// unreadable is the number of directories that could not be opened, whose content is not counted
+	unreadable int64
+}
+
+// usageCheckpoint is the progress of a walk: the usage of the directories read so far, and the directories still to
+// read, relative to the root of the walk.
+type usageCheckpoint struct {
+	Bytes      int64     `json:"bytes"`
+	Inodes     int64     `json:"inodes"`
+	Unreadable int64     `json:"unreadable"`
+	Pending    []string  `json:"pending"`
+	Time       time.Time `json:"time"`
+}
+
+// usageWalker computes the disk usage of a directory tree the same way as du, reading directories in parallel. Reads
+// are bounded by tokens, which are shared between all the walks of a file system, so that walking many volumes does
+// not put more load on the file system than walking one.
+//
+// The progress of long walks is checkpointed to checkpointFile, if set, so that a walk interrupted by a restart of the
+// driver resumes where it stopped. Hard links are only deduplicated within a run of the walk, as the inodes seen are
+// not checkpointed.
+type usageWalker struct {
+	root           string
+	workers        int
+	tokens         chan struct{}
+	checkpointFile string
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// pending are the directories left to read, relative to root
+	pending []string
+	// reading are the directories being read
+	reading        map[string]struct{}
+	usage          diskUsage
+	hardLinks      map[uint64]struct{}
+	err            error
+	lastCheckpoint time.Time
+}
+
+func newUsageWalker(root string, workers int, tokens chan struct{}, checkpointFile string) *usageWalker {
+	if workers < 1 {
+		workers = 1
+	}
+	w := &usageWalker{
+		root:           root,
+		workers:        workers,
+		tokens:         tokens,
+		checkpointFile: checkpointFile,
+		reading:        make(map[string]struct{}),
+		hardLinks:      make(map[uint64]struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	return w
+}
+
+// dirUsage is the result of reading a directory.
+type dirUsage struct {
+	usage   diskUsage
+	subdirs []string
+	// hardLinks are the blocks used by the entries with several links, keyed by inode
+	hardLinks map[uint64]int64
+}
+
+// walk returns the usage of the tree, or the error of ctx if it is done before the walk completes.
+func (w *usageWalker) walk(ctx context.Context) (diskUsage, error) {
+	var rootStat syscall.Stat_t
+	if err := syscall.Lstat(w.root, &rootStat); err != nil {
+		return diskUsage{}, fmt.Errorf("could not stat %s: %v", w.root, err)
+	}
+
+	if !w.restore() {
+		w.pending = []string{"."}
+		w.usage = diskUsage{bytes: rootStat.Blocks * 512, inodes: 1}
+	}
+	w.lastCheckpoint = time.Now()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// Wake up the workers waiting for directories to read
+		<-ctx.Done()
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := w.next(ctx)
+				if !ok {
+					return
+				}
+				result, err := w.readDir(ctx, dir, uint64(rootStat.Dev))
+				w.done(dir, result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = ctx.Err()
+	}
+	if w.err != nil {
+		w.checkpoint()
+		return diskUsage{}, w.err
+	}
+	w.removeCheckpoint()
+	return w.usage, nil
+}
+
+// next returns the next directory to read, waiting for one if all the pending directories are being read. It returns
+// false once the walk is over.
+func (w *usageWalker) next(ctx context.Context) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.pending) == 0 && len(w.reading) > 0 && w.err == nil && ctx.Err() == nil {
+		w.cond.Wait()
+	}
+	if len(w.pending) == 0 || w.err != nil || ctx.Err() != nil {
+		return "", false
+	}
+	// Read the directories found last first, so that the pending directories do not grow with the width of the tree
+	dir := w.pending[len(w.pending)-1]
+	w.pending = w.pending[:len(w.pending)-1]
+	w.reading[dir] = struct{}{}
+	return dir, true
+}
+
+// done adds the usage of a directory once it was read in full, so that the usage of a directory is either counted or
+// the directory is pending whenever the progress is checkpointed.
+func (w *usageWalker) done(dir string, result *dirUsage, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer w.cond.Broadcast()
+
+	if err != nil {
+		if w.err == nil && err != context.Canceled && err != context.DeadlineExceeded {
+			w.err = err
+		}
+		// Leave the directory to be read again when the walk is resumed
+		w.pending = append(w.pending, dir)
+		delete(w.reading, dir)
+		return
+	}
+	delete(w.reading, dir)
+
+	w.usage.bytes += result.usage.bytes
+	w.usage.inodes += result.usage.inodes
+	w.usage.unreadable += result.usage.unreadable
+	for ino, bytes := range result.hardLinks {
+		if _, ok := w.hardLinks[ino]; ok {
+			continue
+		}
+		w.hardLinks[ino] = struct{}{}
+		w.usage.bytes += bytes
+		w.usage.inodes++
+	}
+	w.pending = append(w.pending, result.subdirs...)
+
+	if time.Since(w.lastCheckpoint) > usageCheckpointInterval {
+		w.checkpoint()
+		w.lastCheckpoint = time.Now()
+	}
+}
+
+// readDir returns the usage of the entries of a directory, without descending into directories on other devices.
+// Entries removed while the directory is read are ignored, and directories that cannot be opened, e.g. for lack of
+// permissions, are skipped and counted as unreadable rather than failing the walk.
+func (w *usageWalker) readDir(ctx context.Context, dir string, dev uint64) (*dirUsage, error) {
+	select {
+	case w.tokens <- struct{}{}:
+		defer func() { <-w.tokens }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	result := &dirUsage{hardLinks: make(map[uint64]int64)}
+	f, err := openDir(filepath.Join(w.root, dir))
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		klog.V(4).Infof("Skipping unreadable directory %s of %s: %v", dir, w.root, err)
+		result.usage.unreadable++
+		return result, nil
+	}
+	defer f.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		infos, err := f.Readdir(readDirBatchSize)
+		for _, info := range infos {
+			s, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				return nil, fmt.Errorf("unsupported fileinfo for %s; could not convert to stat_t", info.Name())
+			}
+			if uint64(s.Dev) != dev {
+				continue
+			}
+			if info.IsDir() {
+				result.subdirs = append(result.subdirs, filepath.Join(dir, info.Name()))
+			} else if s.Nlink > 1 {
+				result.hardLinks[s.Ino] = s.Blocks * 512
+				continue
+			}
+			result.usage.bytes += s.Blocks * 512 // blocksize in bytes
+			result.usage.inodes++
+		}
+		if err == io.EOF {
+			return result, nil
+		}
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read directory %s: %v", dir, err)
+		}
+	}
+}
+
+// restore resumes the walk from its checkpoint, if there is a recent enough one.
+func (w *usageWalker) restore() bool {
+	if w.checkpointFile == "" {
+		return false
+	}
+	content, err := ioutil.ReadFile(w.checkpointFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			klog.Warningf("Could not read usage checkpoint %s: %v", w.checkpointFile, err)
+		}
+		return false
+	}
+	var checkpoint usageCheckpoint
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		klog.Warningf("Ignoring invalid usage checkpoint %s: %v", w.checkpointFile, err)
+		w.removeCheckpoint()
+		return false
+	}
+	if time.Since(checkpoint.Time) > usageCheckpointMaxAge {
+		klog.V(4).Infof("Ignoring usage checkpoint %s from %v", w.checkpointFile, checkpoint.Time)
+		w.removeCheckpoint()
+		return false
+	}
+	klog.V(4).Infof("Resuming usage computation of %s with %d directories left to read", w.root, len(checkpoint.Pending))
+	w.pending = checkpoint.Pending
+	w.usage = diskUsage{bytes: checkpoint.Bytes, inodes: checkpoint.Inodes, unreadable: checkpoint.Unreadable}
+	return true
+}
+
+// checkpoint persists the progress of the walk, which must be called with the lock held. Failures are only logged, as
+// the walk can always be started over.
+func (w *usageWalker) checkpoint() {
+	if w.checkpointFile == "" {
+		return
+	}
+	checkpoint := usageCheckpoint{
+		Bytes:      w.usage.bytes,
+		Inodes:     w.usage.inodes,
+		Unreadable: w.usage.unreadable,
+		Pending:    append([]string{}, w.pending...),
+		Time:       time.Now(),
+	}
+	for dir := range w.reading {
+		checkpoint.Pending = append(checkpoint.Pending, dir)
+	}
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		klog.Errorf("Could not encode usage checkpoint: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(w.checkpointFile), 0755); err != nil {
+		klog.Errorf("Could not create usage checkpoint directory: %v", err)
+		return
+	}
+	// Write to a temporary file first so that the checkpoint is never left half written
+	tmpFile := w.checkpointFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		klog.Errorf("Could not write usage checkpoint to %s: %v", tmpFile, err)
+		return
+	}
+	if err := os.Rename(tmpFile, w.checkpointFile); err != nil {
+		klog.Errorf("Could not write usage checkpoint to %s: %v", w.checkpointFile, err)
+	}
+}
+
+func (w *usageWalker) removeCheckpoint() {
+	removeUsageCheckpoint(w.checkpointFile)
+}
+
+// removeUsageCheckpoint removes the checkpoint of a walk, if any.
+func removeUsageCheckpoint(checkpointFile string) {
+	if checkpointFile == "" {
+		return
+	}
+	if err := os.Remove(checkpointFile); err != nil && !os.IsNotExist(err) {
+		klog.Warningf("Could not remove usage checkpoint %s: %v", checkpointFile, err)
+	}
+}
+
+// usageCheckpointFile returns the file the progress of computing the usage of the volume is checkpointed to.
+func usageCheckpointFile(dir, volId string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, url.PathEscape(volId)+".json")
+}
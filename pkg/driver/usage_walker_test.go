@@ -0,0 +1,238 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/volume/util/fs"
+)
+
+// makeTree creates a tree of directories with files of various sizes and a hard link under root.
+func makeTree(t *testing.T, root string) {
+	for _, dir := range []string{"a/b/c", "a/d", "e"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+	}
+	for i, file := range []string{"f1", "a/f2", "a/b/f3", "a/b/c/f4", "a/d/f5", "e/f6"} {
+		content := strings.Repeat("x", i*5000)
+		if err := ioutil.WriteFile(filepath.Join(root, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	if err := os.Link(filepath.Join(root, "a/b/f3"), filepath.Join(root, "e/link")); err != nil {
+		t.Fatalf("Failed to create hard link: %v", err)
+	}
+}
+
+func TestUsageWalker(t *testing.T) {
+	root, err := ioutil.TempDir("", "usage-walker")
+	if err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	defer os.RemoveAll(root)
+	makeTree(t, root)
+
+	expected, err := fs.DiskUsage(root)
+	if err != nil {
+		t.Fatalf("Failed to compute expected usage: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		workers int
+		tokens  int
+	}{
+		{name: "Success: Single worker", workers: 1, tokens: 1},
+		{name: "Success: Parallel workers", workers: 4, tokens: 4},
+		{name: "Success: More workers than tokens", workers: 8, tokens: 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walker := newUsageWalker(root, tc.workers, make(chan struct{}, tc.tokens), "")
+			usage, err := walker.walk(context.Background())
+			if err != nil {
+				t.Fatalf("Walk failed: %v", err)
+			}
+			if usage.bytes != expected.Bytes || usage.inodes != expected.Inodes {
+				t.Fatalf("Expected %d bytes and %d inodes, got %d bytes and %d inodes", expected.Bytes, expected.Inodes, usage.bytes, usage.inodes)
+			}
+		})
+	}
+}
+
+func TestUsageWalker_UnreadableDirectory(t *testing.T) {
+	root, err := ioutil.TempDir("", "usage-walker")
+	if err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	defer os.RemoveAll(root)
+	makeTree(t, root)
+
+	total, err := fs.DiskUsage(root)
+	if err != nil {
+		t.Fatalf("Failed to compute total usage: %v", err)
+	}
+	unreadable := filepath.Join(root, "a/d")
+	subtree, err := fs.DiskUsage(unreadable)
+	if err != nil {
+		t.Fatalf("Failed to compute subtree usage: %v", err)
+	}
+	var subtreeRoot syscall.Stat_t
+	if err := syscall.Lstat(unreadable, &subtreeRoot); err != nil {
+		t.Fatalf("Failed to stat subtree: %v", err)
+	}
+
+	// Permissions do not apply to root, so the directory is made unreadable by failing to open it
+	defer func() { openDir = os.Open }()
+	openDir = func(name string) (*os.File, error) {
+		if name == unreadable {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EACCES}
+		}
+		return os.Open(name)
+	}
+
+	walker := newUsageWalker(root, 2, make(chan struct{}, 2), "")
+	usage, err := walker.walk(context.Background())
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	// The unreadable directory itself is counted when its parent is read, but not its content
+	expectedBytes := total.Bytes - subtree.Bytes + subtreeRoot.Blocks*512
+	expectedInodes := total.Inodes - subtree.Inodes + 1
+	if usage.bytes != expectedBytes || usage.inodes != expectedInodes || usage.unreadable != 1 {
+		t.Fatalf("Expected %d bytes, %d inodes and 1 unreadable directory, got %d bytes, %d inodes and %d unreadable directories",
+			expectedBytes, expectedInodes, usage.bytes, usage.inodes, usage.unreadable)
+	}
+}
+
+func TestUsageWalker_Cancel(t *testing.T) {
+	root, err := ioutil.TempDir("", "usage-walker")
+	if err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	defer os.RemoveAll(root)
+	makeTree(t, root)
+	checkpointFile := filepath.Join(root, "checkpoint.json")
+
+	// Hold the only token so that no directory can be read until the walk is cancelled
+	tokens := make(chan struct{}, 1)
+	tokens <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	walker := newUsageWalker(root, 2, tokens, checkpointFile)
+	if _, err := walker.walk(ctx); err != context.Canceled {
+		t.Fatalf("Expected walk to be cancelled, got %v", err)
+	}
+
+	content, err := ioutil.ReadFile(checkpointFile)
+	if err != nil {
+		t.Fatalf("Expected checkpoint to be written: %v", err)
+	}
+	var checkpoint usageCheckpoint
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		t.Fatalf("Failed to decode checkpoint: %v", err)
+	}
+	if len(checkpoint.Pending) != 1 || checkpoint.Pending[0] != "." {
+		t.Fatalf("Expected root to be left to read, got %v", checkpoint.Pending)
+	}
+}
+
+func TestUsageWalker_Resume(t *testing.T) {
+	root, err := ioutil.TempDir("", "usage-walker")
+	if err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	defer os.RemoveAll(root)
+	volume := filepath.Join(root, "volume")
+	makeTree(t, volume)
+
+	subtree, err := fs.DiskUsage(filepath.Join(volume, "a/b"))
+	if err != nil {
+		t.Fatalf("Failed to compute subtree usage: %v", err)
+	}
+	var subtreeRoot syscall.Stat_t
+	if err := syscall.Lstat(filepath.Join(volume, "a/b"), &subtreeRoot); err != nil {
+		t.Fatalf("Failed to stat subtree: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		checkpoint usageCheckpoint
+		resumed    bool
+	}{
+		{
+			name: "Success: Walk resumes from a recent checkpoint",
+			checkpoint: usageCheckpoint{
+				Bytes:   1000000,
+				Inodes:  100,
+				Pending: []string{"a/b"},
+				Time:    time.Now(),
+			},
+			resumed: true,
+		},
+		{
+			name: "Success: Stale checkpoint is ignored",
+			checkpoint: usageCheckpoint{
+				Bytes:   1000000,
+				Inodes:  100,
+				Pending: []string{"a/b"},
+				Time:    time.Now().Add(-2 * usageCheckpointMaxAge),
+			},
+			resumed: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checkpointFile := filepath.Join(root, "checkpoints", "volume.json")
+			content, err := json.Marshal(tc.checkpoint)
+			if err != nil {
+				t.Fatalf("Failed to encode checkpoint: %v", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(checkpointFile), 0755); err != nil {
+				t.Fatalf("Failed to create checkpoint dir: %v", err)
+			}
+			if err := ioutil.WriteFile(checkpointFile, content, 0644); err != nil {
+				t.Fatalf("Failed to write checkpoint: %v", err)
+			}
+
+			walker := newUsageWalker(volume, 2, make(chan struct{}, 2), checkpointFile)
+			usage, err := walker.walk(context.Background())
+			if err != nil {
+				t.Fatalf("Walk failed: %v", err)
+			}
+
+			if tc.resumed {
+				// The root of the subtree was already counted when its parent was read
+				expectedBytes := tc.checkpoint.Bytes + subtree.Bytes - subtreeRoot.Blocks*512
+				expectedInodes := tc.checkpoint.Inodes + subtree.Inodes - 1
+				if usage.bytes != expectedBytes || usage.inodes != expectedInodes {
+					t.Fatalf("Expected %d bytes and %d inodes, got %d bytes and %d inodes", expectedBytes, expectedInodes, usage.bytes, usage.inodes)
+				}
+			} else {
+				expected, err := fs.DiskUsage(volume)
+				if err != nil {
+					t.Fatalf("Failed to compute expected usage: %v", err)
+				}
+				if usage.bytes != expected.Bytes || usage.inodes != expected.Inodes {
+					t.Fatalf("Expected %d bytes and %d inodes, got %d bytes and %d inodes", expected.Bytes, expected.Inodes, usage.bytes, usage.inodes)
+				}
+			}
+			if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+				t.Fatalf("Expected checkpoint to be removed once the walk completed, got %v", err)
+			}
+		})
+	}
+}
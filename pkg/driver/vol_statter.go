@@ -15,6 +15,8 @@ limitations under the License.
 package driver
 
 import (
+	"context"
+
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
@@ -31,23 +33,39 @@ type volMetrics struct {
 
 var (
 	volUsageCache        = make(map[string]*volMetrics)
-	volStatterJobTracker = make(map[string]bool)
+	volStatterJobTracker = make(map[string]*volStatsJob)
 	fsRateLimiter        = make(map[string]int)
 	mu                   sync.RWMutex
 	jitter               = time.Duration(5 * time.Minute)
+	// fsWalkTokens bound the directories of each file system read at once by the volume stats routines
+	fsWalkTokens = make(map[string]chan struct{})
+	// cancelTimeout is how long cancelling a volume stats routine waits for it to stop
+	cancelTimeout = 10 * time.Second
 )
 
+// volStatsJob is a volume stats routine computing the usage of a volume at volPath.
+type volStatsJob struct {
+	volPath string
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
 type VolStatter interface {
 	computeVolumeMetrics(volId, volPath string, refreshRate float64, fsRateLimit int) (*volMetrics, error)
 	retrieveFromCache(volId string) (*volMetrics, bool)
 	removeFromCache(volId string)
+	cancelVolumeMetrics(volId, volPath string)
 }
 
 type VolStatterImpl struct {
+	// checkpointDir is where the progress of computing the usage of large volumes is checkpointed, if set
+	checkpointDir string
 }
 
-func NewVolStatter() VolStatter {
-	return &VolStatterImpl{}
+func NewVolStatter(checkpointDir string) VolStatter {
+	return &VolStatterImpl{
+		checkpointDir: checkpointDir,
+	}
 }
 
 func (v VolStatterImpl) computeVolumeMetrics(volId, volPath string, refreshRate float64, fsRateLimit int) (*volMetrics, error) {
@@ -86,10 +104,13 @@ func (v VolStatterImpl) retrieveFromCache(volId string) (*volMetrics, bool) {
 	}
 }
 
+// removeFromCache evicts the usage of the volume, along with the checkpoint of computing it, as the volume is no
+// longer published on the node.
 func (v VolStatterImpl) removeFromCache(volId string) {
 	mu.Lock()
 	delete(volUsageCache, volId)
 	mu.Unlock()
+	removeUsageCheckpoint(usageCheckpointFile(v.checkpointDir, volId))
 }
 
 func (v VolStatterImpl) launchVolStatsRoutine(volId, volPath string, fsRateLimit int) {
@@ -104,8 +125,10 @@ func (v VolStatterImpl) launchVolStatsRoutine(volId, volPath string, fsRateLimit
 		klog.V(5).Infof("Volume stats computation job is underway for volume Id : %v. Awaiting results", volId)
 	} else {
 		if ok := canStatFS(fsId, fsRateLimit); ok {
-			volStatterJobTracker[volId] = true
-			go v.computeDiskUsage(fsId, volId, volPath)
+			ctx, cancel := context.WithCancel(context.Background())
+			job := &volStatsJob{volPath: volPath, cancel: cancel, done: make(chan struct{})}
+			volStatterJobTracker[volId] = job
+			go v.computeDiskUsage(ctx, job, fsId, volId, fsRateLimit)
 		} else {
 			klog.V(5).Infof("Too many stat routines are running against FS : %s. Retry stat for volume Id: %s later", fsId, volId)
 		}
@@ -113,22 +136,67 @@ func (v VolStatterImpl) launchVolStatsRoutine(volId, volPath string, fsRateLimit
 	mu.Unlock()
 }
 
-func (v VolStatterImpl) computeDiskUsage(fsId, volId, volPath string) {
+// cancelVolumeMetrics stops the volume stats routine computing the usage of the volume at volPath, if any, so that it
+// does not keep volPath busy while it is unmounted. It waits for the routine to stop for up to cancelTimeout.
+func (v VolStatterImpl) cancelVolumeMetrics(volId, volPath string) {
+	mu.RLock()
+	job, ok := volStatterJobTracker[volId]
+	mu.RUnlock()
+	if !ok || job.volPath != volPath {
+		return
+	}
+
+	klog.V(4).Infof("Cancelling volume stats computation for vol ID: %v, vol path: %v", volId, volPath)
+	job.cancel()
+	select {
+	case <-job.done:
+	case <-time.After(cancelTimeout):
+		klog.Warningf("Volume stats computation for vol ID: %v did not stop within %v", volId, cancelTimeout)
+	}
+}
+
+func (v VolStatterImpl) computeDiskUsage(ctx context.Context, job *volStatsJob, fsId, volId string, fsRateLimit int) {
+	volPath := job.volPath
+	// Release the job whether it succeeds, fails or is cancelled, so that the usage of the volume is computed again
+	defer func() {
+		job.cancel()
+		mu.Lock()
+		if volStatterJobTracker[volId] == job {
+			delete(volStatterJobTracker, volId)
+		}
+		if count, ok := fsRateLimiter[fsId]; ok && count > 0 {
+			fsRateLimiter[fsId] = count - 1
+		}
+		mu.Unlock()
+		close(job.done)
+	}()
+
 	waitTime := wait.Jitter(jitter, 2.0)
 	klog.V(5).Infof("Compute Volume Metrics invoked for Vol ID: %v, Sleeping for %v before execution", volId, waitTime)
 
 	//jittered execution
-	time.Sleep(waitTime)
+	select {
+	case <-time.After(waitTime):
+	case <-ctx.Done():
+		klog.V(4).Infof("Volume stats computation for vol ID: %v cancelled", volId)
+		return
+	}
 
-	used, err := fs.DiskUsage(volPath)
+	walker := newUsageWalker(volPath, fsRateLimit, getWalkTokens(fsId, fsRateLimit), usageCheckpointFile(v.checkpointDir, volId))
+	used, err := walker.walk(ctx)
+	if err == context.Canceled {
+		klog.V(4).Infof("Volume stats computation for vol ID: %v cancelled", volId)
+		return
+	}
 	if err != nil {
 		klog.Errorf("Failed to compute volume usage on path %s: %v", volPath, err)
 		return
 	}
+	if used.unreadable > 0 {
+		klog.Warningf("Could not read %d directories of volume %v, the usage of their content is not counted", used.unreadable, volId)
+	}
 
-	volUsed := used.Bytes
-
-	available, capacity, _, _, _, _, err := fs.Info(volPath)
+	available, capacity, _, inodes, inodesFree, _, err := fs.Info(volPath)
 	if err != nil {
 		klog.Errorf("Failed to fetch FsInfo on volume path %s: %v", volPath, err)
 		return
@@ -137,10 +205,16 @@ func (v VolStatterImpl) computeDiskUsage(fsId, volId, volPath string) {
 	usage := []*csi.VolumeUsage{
 		{
 			Unit:      csi.VolumeUsage_BYTES,
-			Used:      volUsed,
+			Used:      used.bytes,
 			Available: available,
 			Total:     capacity,
 		},
+		{
+			Unit:      csi.VolumeUsage_INODES,
+			Used:      used.inodes,
+			Available: inodesFree,
+			Total:     inodes,
+		},
 	}
 
 	volMetrics := &volMetrics{
@@ -149,13 +223,11 @@ func (v VolStatterImpl) computeDiskUsage(fsId, volId, volPath string) {
 		volUsage:  usage}
 
 	mu.Lock()
-	volUsageCache[volId] = volMetrics
-	delete(volStatterJobTracker, volId)
-	if count, ok := fsRateLimiter[fsId]; ok && count > 0 {
-		fsRateLimiter[fsId] = count - 1
+	// Do not cache the usage of a volume cancelled while its usage was being cached
+	if ctx.Err() == nil {
+		volUsageCache[volId] = volMetrics
 	}
 	mu.Unlock()
-	return
 }
 
 func canStatFS(fsId string, fsRateLimit int) bool {
@@ -171,3 +243,19 @@ func canStatFS(fsId string, fsRateLimit int) bool {
 
 	return true
 }
+
+// getWalkTokens returns the tokens shared by the volume stats routines of the file system, so that they read at most
+// fsRateLimit directories of it at once.
+func getWalkTokens(fsId string, fsRateLimit int) chan struct{} {
+	if fsRateLimit < 1 {
+		fsRateLimit = 1
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	tokens, ok := fsWalkTokens[fsId]
+	if !ok {
+		tokens = make(chan struct{}, fsRateLimit)
+		fsWalkTokens[fsId] = tokens
+	}
+	return tokens
+}
@@ -0,0 +1,149 @@
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+// waitForVolStatsJob waits for the volume stats routine of the volume to stop.
+func waitForVolStatsJob(t *testing.T, volId string) {
+	mu.RLock()
+	job, ok := volStatterJobTracker[volId]
+	mu.RUnlock()
+	if !ok {
+		return
+	}
+	select {
+	case <-job.done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Volume stats routine of %s did not stop", volId)
+	}
+}
+
+func TestVolStatter_LaunchVolStatsRoutine(t *testing.T) {
+	var (
+		fsId  = "fs-abcd1234"
+		volId = "fs-abcd1234:/dir"
+	)
+	defaultJitter := jitter
+	defer func() { jitter = defaultJitter }()
+
+	volPath, err := ioutil.TempDir("", "vol-statter")
+	if err != nil {
+		t.Fatalf("Failed to create volume path: %v", err)
+	}
+	defer os.RemoveAll(volPath)
+
+	tests := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "Success: Usage is cached with bytes and inodes",
+			testFunc: func(t *testing.T) {
+				jitter = 0
+				statter := NewVolStatter("")
+				defer statter.removeFromCache(volId)
+
+				statter.(*VolStatterImpl).launchVolStatsRoutine(volId, volPath, 1)
+				waitForVolStatsJob(t, volId)
+
+				metrics, ok := statter.retrieveFromCache(volId)
+				if !ok {
+					t.Fatal("Expected usage to be cached")
+				}
+				if len(metrics.volUsage) != 2 || metrics.volUsage[0].GetUnit() != csi.VolumeUsage_BYTES || metrics.volUsage[1].GetUnit() != csi.VolumeUsage_INODES {
+					t.Fatalf("Expected bytes and inodes usage, got %v", metrics.volUsage)
+				}
+			},
+		},
+		{
+			name: "Fail: Failed scan releases the job and the file system slot",
+			testFunc: func(t *testing.T) {
+				jitter = 0
+				statter := NewVolStatter("")
+
+				statter.(*VolStatterImpl).launchVolStatsRoutine(volId, "/does/not/exist", 1)
+				waitForVolStatsJob(t, volId)
+
+				if _, ok := statter.retrieveFromCache(volId); ok {
+					t.Fatal("Expected usage not to be cached")
+				}
+				assertVolStatsReleased(t, fsId, volId)
+			},
+		},
+		{
+			name: "Success: Cancelled scan releases the job and the file system slot",
+			testFunc: func(t *testing.T) {
+				jitter = time.Hour
+				statter := NewVolStatter("")
+
+				statter.(*VolStatterImpl).launchVolStatsRoutine(volId, volPath, 1)
+				statter.cancelVolumeMetrics(volId, volPath)
+
+				if _, ok := statter.retrieveFromCache(volId); ok {
+					t.Fatal("Expected usage not to be cached")
+				}
+				assertVolStatsReleased(t, fsId, volId)
+			},
+		},
+		{
+			name: "Success: Scan of the volume at another path is not cancelled",
+			testFunc: func(t *testing.T) {
+				jitter = time.Hour
+				statter := NewVolStatter("")
+
+				statter.(*VolStatterImpl).launchVolStatsRoutine(volId, volPath, 1)
+				statter.cancelVolumeMetrics(volId, "/other/path")
+
+				mu.RLock()
+				_, ok := volStatterJobTracker[volId]
+				mu.RUnlock()
+				if !ok {
+					t.Fatal("Expected volume stats routine to keep running")
+				}
+				statter.cancelVolumeMetrics(volId, volPath)
+				assertVolStatsReleased(t, fsId, volId)
+			},
+		},
+		{
+			name: "Success: Evicting the usage removes its checkpoint",
+			testFunc: func(t *testing.T) {
+				checkpointDir, err := ioutil.TempDir("", "vol-statter-checkpoints")
+				if err != nil {
+					t.Fatalf("Failed to create checkpoint dir: %v", err)
+				}
+				defer os.RemoveAll(checkpointDir)
+				checkpointFile := usageCheckpointFile(checkpointDir, volId)
+				if err := ioutil.WriteFile(checkpointFile, []byte("{}"), 0644); err != nil {
+					t.Fatalf("Failed to write checkpoint: %v", err)
+				}
+
+				statter := NewVolStatter(checkpointDir)
+				statter.removeFromCache(volId)
+
+				if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+					t.Fatalf("Expected checkpoint to be removed, got %v", err)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.testFunc)
+	}
+}
+
+func assertVolStatsReleased(t *testing.T, fsId, volId string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, ok := volStatterJobTracker[volId]; ok {
+		t.Fatalf("Expected volume stats job of %s to be released", volId)
+	}
+	if count := fsRateLimiter[fsId]; count != 0 {
+		t.Fatalf("Expected file system slot of %s to be released, got %d routines", fsId, count)
+	}
+}